@@ -0,0 +1,30 @@
+package config
+
+import (
+	"gopkg.in/ini.v1"
+	"log"
+	"strconv"
+)
+
+// AlertConfig 存储高风险预警配置
+type AlertConfig struct {
+	CounselorRoleID int // 新预警默认分派的辅导员角色ID
+}
+
+// AlertSettings 作为全局变量存储预警配置
+var AlertSettings AlertConfig
+
+// LoadAlertConfig 读取预警配置，未配置时辅导员角色为 3；辅导员角色不能与管理员角色相同，
+// 否则预警全部分派给管理员、辅导员权限退化为仅管理员，启动时直接报错（须在 LoadDataScopeConfig 之后调用）
+func LoadAlertConfig() {
+	cfg, err := ini.Load("./config/app.ini")
+	if err != nil {
+		log.Fatalf("读取配置文件失败: %v", err)
+	}
+	AlertSettings = AlertConfig{
+		CounselorRoleID: cfg.Section("alert").Key("counselor_role_id").MustInt(3),
+	}
+	if strconv.Itoa(AlertSettings.CounselorRoleID) == DataScopeSettings.AdminRoleID {
+		log.Fatalf("配置错误: alert.counselor_role_id（%d）不能与 datascope.admin_role_id 相同", AlertSettings.CounselorRoleID)
+	}
+}
@@ -0,0 +1,32 @@
+package config
+
+import (
+	"gopkg.in/ini.v1"
+	"log"
+)
+
+// AppointmentConfig 存储心理咨询预约配置
+type AppointmentConfig struct {
+	ReminderMinutes   int // 预约开始前多少分钟发送提醒
+	ScanSeconds       int // 提醒任务扫描间隔（秒）
+	CancelBeforeHours int // 学生最晚在预约开始前多少小时取消
+	MaxSlotMinutes    int // 单个咨询时段最长时长（分钟）
+}
+
+// AppointmentSettings 作为全局变量存储预约配置
+var AppointmentSettings AppointmentConfig
+
+// LoadAppointmentConfig 读取预约配置，未配置的项使用默认值
+func LoadAppointmentConfig() {
+	cfg, err := ini.Load("./config/app.ini")
+	if err != nil {
+		log.Fatalf("读取配置文件失败: %v", err)
+	}
+	section := cfg.Section("appointment")
+	AppointmentSettings = AppointmentConfig{
+		ReminderMinutes:   section.Key("reminder_minutes").MustInt(60),
+		ScanSeconds:       section.Key("scan_seconds").MustInt(60),
+		CancelBeforeHours: section.Key("cancel_before_hours").MustInt(2),
+		MaxSlotMinutes:    section.Key("max_slot_minutes").MustInt(240),
+	}
+}
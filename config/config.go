@@ -6,4 +6,8 @@ func InitAll() {
 	LoadJWTConfig()
 	InitRedis()
 	InitMinio()
+	LoadDataScopeConfig()
+	LoadAlertConfig() // 校验辅导员角色与管理员角色不同，须在数据范围配置之后
+	LoadAppointmentConfig()
+	LoadReportConfig()
 }
@@ -0,0 +1,29 @@
+package config
+
+import (
+	"gopkg.in/ini.v1"
+	"log"
+)
+
+// DataScopeConfig 存储数据范围配置
+type DataScopeConfig struct {
+	AdminRoleID       string // 管理员角色ID，始终可访问全部数据
+	AdminPermissionID int    // 仅管理员可访问的接口登记的权限ID
+	DefaultScope      string // 未配置数据范围的角色使用的默认范围
+}
+
+// DataScopeSettings 作为全局变量存储数据范围配置
+var DataScopeSettings DataScopeConfig
+
+// LoadDataScopeConfig 读取数据范围配置，未配置时管理员角色和管理员接口权限均为 1，其余角色默认仅本人
+func LoadDataScopeConfig() {
+	cfg, err := ini.Load("./config/app.ini")
+	if err != nil {
+		log.Fatalf("读取配置文件失败: %v", err)
+	}
+	DataScopeSettings = DataScopeConfig{
+		AdminRoleID:       cfg.Section("datascope").Key("admin_role_id").MustString("1"),
+		AdminPermissionID: cfg.Section("datascope").Key("admin_permission_id").MustInt(1),
+		DefaultScope:      cfg.Section("datascope").Key("default_scope").MustString("self"),
+	}
+}
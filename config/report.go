@@ -0,0 +1,28 @@
+package config
+
+import (
+	"gopkg.in/ini.v1"
+	"log"
+)
+
+// ReportConfig 存储 PDF 测评报告配置
+type ReportConfig struct {
+	FontPath string // 报告使用的中文 TrueType 字体文件路径
+	Org      string // 报告页眉显示的机构名称
+}
+
+// ReportSettings 作为全局变量存储报告配置
+var ReportSettings ReportConfig
+
+// LoadReportConfig 读取报告配置，未配置的项使用默认值
+func LoadReportConfig() {
+	cfg, err := ini.Load("./config/app.ini")
+	if err != nil {
+		log.Fatalf("读取配置文件失败: %v", err)
+	}
+	section := cfg.Section("report")
+	ReportSettings = ReportConfig{
+		FontPath: section.Key("font_path").MustString("./config/fonts/simhei.ttf"),
+		Org:      section.Key("org").MustString("心理健康教育中心"),
+	}
+}
@@ -2,8 +2,19 @@ package constant
 
 // 前缀常量
 
-var RegisterPrefix string = "mental:register:"              // 注册前缀
-var BlackListPrefix string = "mental:blacklist:"            // Redis黑名单前缀
-var UserRolePrefix string = "mental:user_role:"             // 用户角色前缀（用户id对应的角色id集合）
-var RolePermissionPrefix string = "mental:role_permission:" // 角色权限前缀（角色id对应的权限id集合）
-var APIPermissionPrefix string = "mental:api_permission:"   // 接口权限前缀（权限id对应的可访问的接口路径集合）
+var RegisterPrefix string = "mental:register:"                     // 注册前缀
+var BlackListPrefix string = "mental:blacklist:"                   // Redis黑名单前缀
+var UserRolePrefix string = "mental:user_role:"                    // 用户角色前缀（用户id对应的角色id集合）
+var RolePermissionPrefix string = "mental:role_permission:"        // 角色权限前缀（角色id对应的权限id集合）
+var APIPermissionPrefix string = "mental:api_permission:"          // 接口权限前缀（权限id对应的可访问的接口路径集合）
+var SessionPrefix string = "mental:session:"                       // 在线测评会话前缀（会话id对应的作答进度）
+var StudentSessionPrefix string = "mental:student_session:"        // 学生未完成会话前缀（学生id+量表编码对应的会话id）
+var SessionLockPrefix string = "mental:session_lock:"              // 在线测评会话保存/提交锁前缀
+var AppointmentLockPrefix string = "mental:appointment_lock:"      // 咨询时段预约锁前缀（时段id）
+var AppointmentReminderLock string = "mental:appointment_reminder" // 预约提醒任务锁，多实例部署时只有一个实例执行扫描
+var CohortStatsPrefix string = "mental:cohort_stats:"              // 群体统计结果缓存前缀（数据版本+查询条件摘要）
+var CohortStatsVersion string = "mental:cohort_stats_version"      // 群体统计数据版本号，测评或花名册数据变化时加 1 使缓存失效
+var ImportPreviewPrefix string = "mental:import_preview:"          // Excel 导入预览确认凭证前缀（凭证对应的导入参数）
+var ImportJobPrefix string = "mental:import_job:"                  // 后台导入任务前缀（任务id对应的进度）
+var ImportJobCancelPrefix string = "mental:import_job_cancel:"     // 后台导入任务取消标记前缀
+var ImportFileLockPrefix string = "mental:import_file_lock:"       // 导入文件锁前缀（file_id），同一文件同一时刻只有一个导入在执行
@@ -23,3 +23,12 @@ func (BaseController) Error(c *gin.Context, data interface{}, err string) {
 		"data": data,
 	})
 }
+
+// ErrorWithStatus 以指定的 HTTP 状态码返回错误，用于区分资源不存在（404）与无权访问（403）
+func (BaseController) ErrorWithStatus(c *gin.Context, status int, data interface{}, err string) {
+	c.JSON(status, gin.H{
+		"code": 0,
+		"msg":  err,
+		"data": data,
+	})
+}
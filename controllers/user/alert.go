@@ -0,0 +1,264 @@
+package user
+
+import (
+	"github.com/gin-gonic/gin"
+	"mental/controllers/common"
+	"mental/dao"
+	"mental/models"
+	"mental/service"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AlertController 处理高风险预警相关请求
+type AlertController struct {
+	common.BaseController
+}
+
+// ListAlerts 查询预警列表
+// @Summary 查询高风险预警列表
+// @Description 查询当前用户数据范围内学生的预警，按状态（逗号分隔，all 为全部，默认只查未关闭）、等级、学生、负责人、创建日期范围（from、to，YYYY-MM-DD，均含）筛选；预警相关接口仅辅导员或管理员可用，否则返回 403
+// @Tags 管理员
+// @Produce json
+// @Router /alert/list [get]
+func (con AlertController) ListAlerts(c *gin.Context) {
+	var filter dao.AlertFilter
+	switch status := c.Query("status"); status {
+	case "": // 默认只查询未关闭的预警
+	case "all":
+		filter.Statuses = []string{models.AlertStatusNew, models.AlertStatusAcknowledged, models.AlertStatusFollowUp, models.AlertStatusClosed}
+	default:
+		filter.Statuses = strings.Split(status, ",")
+	}
+	filter.Level = c.Query("level")
+	for _, param := range []struct {
+		name   string
+		target **int64
+	}{
+		{"student_id", &filter.StudentID},
+		{"assignee_id", &filter.AssigneeID},
+	} {
+		if idStr := c.Query(param.name); idStr != "" {
+			id, err := strconv.ParseInt(idStr, 10, 64)
+			if err != nil {
+				con.Error(c, nil, "无效的"+param.name)
+				return
+			}
+			*param.target = &id
+		}
+	}
+	if from := c.Query("from"); from != "" {
+		t, err := time.ParseInLocation("2006-01-02", from, time.Local)
+		if err != nil {
+			con.Error(c, nil, "开始日期格式错误，应为 YYYY-MM-DD")
+			return
+		}
+		filter.From = &t
+	}
+	if to := c.Query("to"); to != "" {
+		t, err := time.ParseInLocation("2006-01-02", to, time.Local)
+		if err != nil {
+			con.Error(c, nil, "结束日期格式错误，应为 YYYY-MM-DD")
+			return
+		}
+		t = t.AddDate(0, 0, 1) // 结束日期当天包含在内
+		filter.To = &t
+	}
+
+	scope, err := currentDataScope(c)
+	if err != nil {
+		con.Error(c, nil, err.Error())
+		return
+	}
+	alertService := service.NewAlertService()
+	list, err := alertService.ListAlerts(filter, scope)
+	if err != nil {
+		permissionError(c, err, "")
+		return
+	}
+	con.Success(c, list)
+}
+
+// GetAlert 查询预警详情
+// @Summary 查询高风险预警详情
+// @Description 查询预警的触发原因及全部处理备注，只能查询数据范围内学生的预警；SCL-90 预警关联测评记录（scl_id），通用量表的单题预警（如 PHQ-9 第 9 题）关联作答记录（response_id）
+// @Tags 管理员
+// @Produce json
+// @Router /alert [get]
+func (con AlertController) GetAlert(c *gin.Context) {
+	alertId, err := strconv.ParseInt(c.Query("id"), 10, 64)
+	if err != nil {
+		con.Error(c, nil, "无效的预警id")
+		return
+	}
+	scope, err := currentDataScope(c)
+	if err != nil {
+		con.Error(c, nil, err.Error())
+		return
+	}
+	alertService := service.NewAlertService()
+	alert, err := alertService.GetAlert(alertId, scope)
+	if err != nil {
+		permissionError(c, err, "")
+		return
+	}
+	con.Success(c, alert)
+}
+
+// ChangeStatus 变更预警状态
+// @Summary 变更高风险预警状态
+// @Description 状态流转：new → acknowledged/follow_up/closed，acknowledged → follow_up/closed，follow_up → closed，closed → follow_up；关闭时必须填写备注
+// @Tags 管理员
+// @Accept json
+// @Produce json
+// @Router /alert/status [post]
+func (con AlertController) ChangeStatus(c *gin.Context) {
+	operator, err := currentDataScope(c)
+	if err != nil {
+		con.Error(c, nil, err.Error())
+		return
+	}
+	var form struct {
+		ID     int64  `json:"id"`
+		Status string `json:"status"`
+		Note   string `json:"note"`
+	}
+	if err := c.ShouldBindJSON(&form); err != nil {
+		con.Error(c, nil, "参数格式错误: "+err.Error())
+		return
+	}
+	alertService := service.NewAlertService()
+	if err := alertService.ChangeStatus(form.ID, operator, form.Status, form.Note); err != nil {
+		permissionError(c, err, "")
+		return
+	}
+	con.Success(c, nil)
+}
+
+// Assign 分派预警负责人
+// @Summary 分派高风险预警负责人
+// @Description 将未关闭的预警分派给指定辅导员
+// @Tags 管理员
+// @Accept json
+// @Produce json
+// @Router /alert/assign [post]
+func (con AlertController) Assign(c *gin.Context) {
+	operator, err := currentDataScope(c)
+	if err != nil {
+		con.Error(c, nil, err.Error())
+		return
+	}
+	var form struct {
+		ID         int64  `json:"id"`
+		AssigneeID int64  `json:"assignee_id"`
+		Note       string `json:"note"`
+	}
+	if err := c.ShouldBindJSON(&form); err != nil {
+		con.Error(c, nil, "参数格式错误: "+err.Error())
+		return
+	}
+	alertService := service.NewAlertService()
+	if err := alertService.Assign(form.ID, operator, form.AssigneeID, form.Note); err != nil {
+		permissionError(c, err, "")
+		return
+	}
+	con.Success(c, nil)
+}
+
+// AddNote 添加预警处理备注
+// @Summary 添加高风险预警处理备注
+// @Description 为预警添加处理备注，不改变状态
+// @Tags 管理员
+// @Accept json
+// @Produce json
+// @Router /alert/note [post]
+func (con AlertController) AddNote(c *gin.Context) {
+	operator, err := currentDataScope(c)
+	if err != nil {
+		con.Error(c, nil, err.Error())
+		return
+	}
+	var form struct {
+		ID      int64  `json:"id"`
+		Content string `json:"content"`
+	}
+	if err := c.ShouldBindJSON(&form); err != nil {
+		con.Error(c, nil, "参数格式错误: "+err.Error())
+		return
+	}
+	alertService := service.NewAlertService()
+	if err := alertService.AddNote(form.ID, operator, form.Content); err != nil {
+		permissionError(c, err, "")
+		return
+	}
+	con.Success(c, nil)
+}
+
+// CreateRule 新建预警规则
+// @Summary 新建高风险预警规则
+// @Description 指标为因子字段名或中文名、total_score/positive_items/gsi，或 level（总体等级，轻度 1、中度 2、重度 3）；达到阈值即触发；仅管理员可操作，否则返回 403
+// @Tags 管理员
+// @Accept json
+// @Produce json
+// @Router /alert/rule [post]
+func (con AlertController) CreateRule(c *gin.Context) {
+	operator, err := currentDataScope(c)
+	if err != nil {
+		con.Error(c, nil, err.Error())
+		return
+	}
+	var rule models.AlertRule
+	if err := c.ShouldBindJSON(&rule); err != nil {
+		con.Error(c, nil, "参数格式错误: "+err.Error())
+		return
+	}
+	rule.CreatedBy = operator.UserID
+	alertService := service.NewAlertService()
+	if err := alertService.CreateRule(&rule, operator); err != nil {
+		permissionError(c, err, "")
+		return
+	}
+	con.Success(c, rule)
+}
+
+// ListRules 查询预警规则
+// @Summary 查询高风险预警规则
+// @Description 返回全部规则及当前生效的规则（没有启用的规则时为内置规则）
+// @Tags 管理员
+// @Produce json
+// @Router /alert/rule/list [get]
+func (con AlertController) ListRules(c *gin.Context) {
+	alertService := service.NewAlertService()
+	list, err := alertService.ListRules()
+	if err != nil {
+		con.Error(c, nil, "查询预警规则失败")
+		return
+	}
+	con.Success(c, list)
+}
+
+// SetRuleEnabled 启用或停用预警规则
+// @Summary 启用或停用高风险预警规则
+// @Description enabled=true 启用，false 停用；停用全部规则后内置规则生效；仅管理员可操作，否则返回 403
+// @Tags 管理员
+// @Produce json
+// @Router /alert/rule/enable [post]
+func (con AlertController) SetRuleEnabled(c *gin.Context) {
+	ruleId, err := strconv.ParseInt(c.Query("id"), 10, 64)
+	if err != nil {
+		con.Error(c, nil, "无效的规则id")
+		return
+	}
+	operator, err := currentDataScope(c)
+	if err != nil {
+		con.Error(c, nil, err.Error())
+		return
+	}
+	alertService := service.NewAlertService()
+	if err := alertService.SetRuleEnabled(ruleId, c.Query("enabled") == "true", operator); err != nil {
+		permissionError(c, err, "")
+		return
+	}
+	con.Success(c, nil)
+}
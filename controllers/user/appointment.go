@@ -0,0 +1,304 @@
+package user
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"mental/controllers/common"
+	"mental/service"
+	"mental/vo"
+	"strconv"
+	"time"
+)
+
+// AppointmentController 处理心理咨询预约相关请求
+type AppointmentController struct {
+	common.BaseController
+}
+
+// parseDate 解析 YYYY-MM-DD 格式的日期查询参数（本地时区），为空时返回默认值
+func parseDate(c *gin.Context, key string, def time.Time) (time.Time, error) {
+	value := c.Query(key)
+	if value == "" {
+		return def, nil
+	}
+	return time.ParseInLocation("2006-01-02", value, time.Local)
+}
+
+// optionalInt64 解析可选的 int64 查询参数，为空时返回 nil
+func optionalInt64(c *gin.Context, key string) (*int64, error) {
+	value := c.Query(key)
+	if value == "" {
+		return nil, nil
+	}
+	v, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+// PublishSlots 发布咨询时段
+// @Summary 辅导员发布可预约的咨询时段
+// @Description 批量发布当前用户的咨询时段（start_at/end_at 为 RFC3339 时间），时段不能早于当前时间，不能与本人已有的未关闭时段重叠；仅辅导员或管理员可发布，否则返回 403
+// @Tags 管理员
+// @Accept json
+// @Produce json
+// @Router /appointment/slot [post]
+func (con AppointmentController) PublishSlots(c *gin.Context) {
+	operator, err := currentDataScope(c)
+	if err != nil {
+		con.Error(c, nil, err.Error())
+		return
+	}
+	var forms []vo.SlotForm
+	if err := c.ShouldBindJSON(&forms); err != nil {
+		con.Error(c, nil, "参数格式错误: "+err.Error())
+		return
+	}
+	appointmentService := service.NewAppointmentService()
+	slots, err := appointmentService.PublishSlots(operator, forms)
+	if err != nil {
+		permissionError(c, err, "")
+		return
+	}
+	con.Success(c, slots)
+}
+
+// CloseSlot 关闭咨询时段
+// @Summary 辅导员关闭未被预约的咨询时段
+// @Description 关闭当前用户发布的可预约时段，已被预约的时段需先取消预约；仅辅导员或管理员可操作，否则返回 403
+// @Tags 管理员
+// @Produce json
+// @Router /appointment/slot/close [post]
+func (con AppointmentController) CloseSlot(c *gin.Context) {
+	slotId, err := strconv.ParseInt(c.Query("id"), 10, 64)
+	if err != nil {
+		con.Error(c, nil, "无效的时段id")
+		return
+	}
+	operator, err := currentDataScope(c)
+	if err != nil {
+		con.Error(c, nil, err.Error())
+		return
+	}
+	appointmentService := service.NewAppointmentService()
+	if err := appointmentService.CloseSlot(operator, slotId); err != nil {
+		permissionError(c, err, "")
+		return
+	}
+	con.Success(c, nil)
+}
+
+// ListAvailable 查询可预约时段
+// @Summary 查询可预约的咨询时段
+// @Description 查询 [from, to] 日期范围内（默认今天起 14 天）尚未开始的可预约时段，可按辅导员 counselor_id 筛选
+// @Tags 管理员/用户
+// @Produce json
+// @Router /appointment/slot/available [get]
+func (con AppointmentController) ListAvailable(c *gin.Context) {
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	from, err := parseDate(c, "from", today)
+	if err != nil {
+		con.Error(c, nil, "无效的开始日期")
+		return
+	}
+	to, err := parseDate(c, "to", from.AddDate(0, 0, 13))
+	if err != nil {
+		con.Error(c, nil, "无效的结束日期")
+		return
+	}
+	counselorId, err := optionalInt64(c, "counselor_id")
+	if err != nil {
+		con.Error(c, nil, "无效的辅导员id")
+		return
+	}
+	appointmentService := service.NewAppointmentService()
+	slots, err := appointmentService.ListAvailable(counselorId, from, to.AddDate(0, 0, 1))
+	if err != nil {
+		con.Error(c, nil, "查询可预约时段失败")
+		return
+	}
+	con.Success(c, slots)
+}
+
+// Book 预约咨询
+// @Summary 学生预约咨询时段
+// @Description 预约指定的可预约时段（slot_id），同一时段只能被一人预约，学生不能预约与本人已有预约时间重叠的时段；预约成功后通知辅导员
+// @Tags 管理员/用户
+// @Accept json
+// @Produce json
+// @Router /appointment/book [post]
+func (con AppointmentController) Book(c *gin.Context) {
+	id, _ := c.Get("id")
+	userId, ok := id.(int64)
+	if !ok {
+		con.Error(c, nil, "无效的用户id")
+		return
+	}
+	var req struct {
+		SlotID int64  `json:"slot_id"`
+		Reason string `json:"reason"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		con.Error(c, nil, "参数格式错误: "+err.Error())
+		return
+	}
+	appointmentService := service.NewAppointmentService()
+	appointment, err := appointmentService.Book(userId, req.SlotID, req.Reason)
+	if err != nil {
+		con.Error(c, nil, err.Error())
+		return
+	}
+	con.Success(c, appointment)
+}
+
+// Cancel 取消预约
+// @Summary 取消咨询预约
+// @Description 学生须在开始前规定时间（配置 appointment.cancel_before_hours）之前取消，辅导员在开始前均可取消；取消后时段重新开放并通知对方
+// @Tags 管理员/用户
+// @Accept json
+// @Produce json
+// @Router /appointment/cancel [post]
+func (con AppointmentController) Cancel(c *gin.Context) {
+	id, _ := c.Get("id")
+	userId, ok := id.(int64)
+	if !ok {
+		con.Error(c, nil, "无效的用户id")
+		return
+	}
+	var req struct {
+		ID     int64  `json:"id"`
+		Reason string `json:"reason"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		con.Error(c, nil, "参数格式错误: "+err.Error())
+		return
+	}
+	appointmentService := service.NewAppointmentService()
+	if err := appointmentService.Cancel(userId, req.ID, req.Reason); err != nil {
+		con.Error(c, nil, err.Error())
+		return
+	}
+	con.Success(c, nil)
+}
+
+// Finish 结束预约
+// @Summary 辅导员标记预约为已完成或未到
+// @Description 咨询开始后，辅导员将本人的预约标记为 completed（已完成）或 no_show（未到）
+// @Tags 管理员
+// @Accept json
+// @Produce json
+// @Router /appointment/finish [post]
+func (con AppointmentController) Finish(c *gin.Context) {
+	id, _ := c.Get("id")
+	userId, ok := id.(int64)
+	if !ok {
+		con.Error(c, nil, "无效的用户id")
+		return
+	}
+	var req struct {
+		ID     int64  `json:"id"`
+		Status string `json:"status"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		con.Error(c, nil, "参数格式错误: "+err.Error())
+		return
+	}
+	appointmentService := service.NewAppointmentService()
+	if err := appointmentService.Finish(userId, req.ID, req.Status); err != nil {
+		con.Error(c, nil, err.Error())
+		return
+	}
+	con.Success(c, nil)
+}
+
+// ListMine 查询我的预约
+// @Summary 查询当前学生的全部预约
+// @Description 按开始时间倒序返回当前用户作为学生的全部预约
+// @Tags 管理员/用户
+// @Produce json
+// @Router /appointment/mine [get]
+func (con AppointmentController) ListMine(c *gin.Context) {
+	id, _ := c.Get("id")
+	userId, ok := id.(int64)
+	if !ok {
+		con.Error(c, nil, "无效的用户id")
+		return
+	}
+	appointmentService := service.NewAppointmentService()
+	list, err := appointmentService.ListMine(userId)
+	if err != nil {
+		con.Error(c, nil, "查询预约失败")
+		return
+	}
+	con.Success(c, list)
+}
+
+// Calendar 查询预约日历
+// @Summary 管理员查询咨询预约日历
+// @Description 按天汇总 [from, to] 日期范围内（默认今天起 7 天，最长 92 天）的咨询时段及预约，可按辅导员 counselor_id 筛选；仅管理员可查询，否则返回 403
+// @Tags 管理员
+// @Produce json
+// @Router /appointment/calendar [get]
+func (con AppointmentController) Calendar(c *gin.Context) {
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	from, err := parseDate(c, "from", today)
+	if err != nil {
+		con.Error(c, nil, "无效的开始日期")
+		return
+	}
+	to, err := parseDate(c, "to", from.AddDate(0, 0, 6))
+	if err != nil {
+		con.Error(c, nil, "无效的结束日期")
+		return
+	}
+	counselorId, err := optionalInt64(c, "counselor_id")
+	if err != nil {
+		con.Error(c, nil, "无效的辅导员id")
+		return
+	}
+	operator, err := currentDataScope(c)
+	if err != nil {
+		con.Error(c, nil, err.Error())
+		return
+	}
+	appointmentService := service.NewAppointmentService()
+	days, err := appointmentService.Calendar(from, to.AddDate(0, 0, 1), counselorId, operator)
+	if err != nil {
+		permissionError(c, err, "")
+		return
+	}
+	con.Success(c, days)
+}
+
+// ExportICS 导出辅导员预约日历
+// @Summary 导出辅导员的咨询预约为 iCalendar 文件
+// @Description 导出指定辅导员（counselor_id，默认当前用户）最近 30 天起已预约及已完成的咨询，可导入 Outlook、Google 日历等；只能导出本人的咨询，管理员可导出任一辅导员，否则返回 403
+// @Tags 管理员
+// @Produce text/calendar
+// @Router /appointment/ics [get]
+func (con AppointmentController) ExportICS(c *gin.Context) {
+	counselorId, err := optionalInt64(c, "counselor_id")
+	if err != nil {
+		con.Error(c, nil, "无效的辅导员id")
+		return
+	}
+	operator, err := currentDataScope(c)
+	if err != nil {
+		con.Error(c, nil, err.Error())
+		return
+	}
+	if counselorId == nil {
+		counselorId = &operator.UserID
+	}
+	appointmentService := service.NewAppointmentService()
+	data, err := appointmentService.ExportICS(*counselorId, operator)
+	if err != nil {
+		permissionError(c, err, "导出预约日历失败: ")
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=appointment_%d.ics", *counselorId))
+	c.Data(200, "text/calendar; charset=utf-8", data)
+}
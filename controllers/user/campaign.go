@@ -0,0 +1,164 @@
+package user
+
+import (
+	"github.com/gin-gonic/gin"
+	"mental/controllers/common"
+	"mental/service"
+	"mental/vo"
+	"strconv"
+	"strings"
+)
+
+// CampaignController 处理批量筛查批次相关请求
+type CampaignController struct {
+	common.BaseController
+}
+
+// CreateCampaign 新建筛查批次
+// @Summary 新建批量筛查批次
+// @Description 指定批次名称、量表编码、目标组织（org_ids，含其全部下级班级）和开放窗口（open_at/close_at，RFC3339）；目标班级学生在窗口内提交该量表的测评时，记录自动关联到批次，多个批次符合时关联开放时间最晚的；仅管理员或全部目标组织（含上级组织）的负责人可操作，否则返回 403
+// @Tags 管理员
+// @Accept json
+// @Produce json
+// @Router /campaign [post]
+func (con CampaignController) CreateCampaign(c *gin.Context) {
+	operator, err := currentDataScope(c)
+	if err != nil {
+		con.Error(c, nil, err.Error())
+		return
+	}
+	var form vo.CampaignForm
+	if err := c.ShouldBindJSON(&form); err != nil {
+		con.Error(c, nil, "参数格式错误: "+err.Error())
+		return
+	}
+	campaignService := service.NewCampaignService()
+	campaign, err := campaignService.CreateCampaign(form, operator)
+	if err != nil {
+		permissionError(c, err, "")
+		return
+	}
+	con.Success(c, campaign)
+}
+
+// UpdateCampaign 修改筛查批次
+// @Summary 修改批量筛查批次
+// @Description 修改批次的名称、量表、开放窗口和目标组织；已关联的测评记录保持不变，修改只影响之后提交的记录；仅管理员或原有及新的全部目标组织的负责人可操作，否则返回 403
+// @Tags 管理员
+// @Accept json
+// @Produce json
+// @Router /campaign/update [post]
+func (con CampaignController) UpdateCampaign(c *gin.Context) {
+	operator, err := currentDataScope(c)
+	if err != nil {
+		con.Error(c, nil, err.Error())
+		return
+	}
+	var form vo.CampaignForm
+	if err := c.ShouldBindJSON(&form); err != nil {
+		con.Error(c, nil, "参数格式错误: "+err.Error())
+		return
+	}
+	campaignService := service.NewCampaignService()
+	if err := campaignService.UpdateCampaign(form, operator); err != nil {
+		permissionError(c, err, "")
+		return
+	}
+	con.Success(c, nil)
+}
+
+// ListCampaigns 查询筛查批次列表
+// @Summary 查询批量筛查批次列表
+// @Description 按开放时间倒序返回筛查批次及其目标组织，status 为 pending（未开始）、open（进行中）或 closed（已结束）；可通过 scale_code 只查询使用该量表的批次
+// @Tags 管理员
+// @Produce json
+// @Router /campaign/list [get]
+func (con CampaignController) ListCampaigns(c *gin.Context) {
+	campaignService := service.NewCampaignService()
+	list, err := campaignService.ListCampaigns(c.Query("scale_code"))
+	if err != nil {
+		con.Error(c, nil, "查询筛查批次失败")
+		return
+	}
+	con.Success(c, list)
+}
+
+// GetCampaign 查询筛查批次详情
+// @Summary 查询指定id的筛查批次
+// @Description 返回筛查批次及其目标组织和当前状态
+// @Tags 管理员
+// @Produce json
+// @Router /campaign [get]
+func (con CampaignController) GetCampaign(c *gin.Context) {
+	campaignId, err := strconv.ParseInt(c.Query("id"), 10, 64)
+	if err != nil {
+		con.Error(c, nil, "无效的筛查批次id")
+		return
+	}
+	campaignService := service.NewCampaignService()
+	campaign, err := campaignService.GetCampaign(campaignId)
+	if err != nil {
+		con.Error(c, nil, err.Error())
+		return
+	}
+	con.Success(c, campaign)
+}
+
+// Progress 查询筛查批次完成情况
+// @Summary 查询筛查批次各班级的完成情况
+// @Description 按目标班级返回花名册人数、已完成人数、完成率以及已完成和未完成的学生名单；学生取关联到批次的最近一次有效作答，只有无效作答的学生计为未完成并标记 invalid；只统计当前用户数据范围内的班级
+// @Tags 管理员
+// @Produce json
+// @Router /campaign/progress [get]
+func (con CampaignController) Progress(c *gin.Context) {
+	campaignId, err := strconv.ParseInt(c.Query("id"), 10, 64)
+	if err != nil {
+		con.Error(c, nil, "无效的筛查批次id")
+		return
+	}
+	scope, err := currentDataScope(c)
+	if err != nil {
+		con.Error(c, nil, err.Error())
+		return
+	}
+	campaignService := service.NewCampaignService()
+	progress, err := campaignService.Progress(campaignId, scope)
+	if err != nil {
+		con.Error(c, nil, err.Error())
+		return
+	}
+	con.Success(c, progress)
+}
+
+// Compare 比较筛查批次
+// @Summary 比较多个筛查批次的结果
+// @Description ids 为逗号分隔的批次id（如 1,2），批次须使用同一量表；按开放时间正序返回各批次的完成率、等级分布、阳性率及总分和各分量表得分的均值/标准差，并给出各指标跨批次的变化序列；只统计当前用户数据范围内班级学生的有效作答
+// @Tags 管理员
+// @Produce json
+// @Router /campaign/compare [get]
+func (con CampaignController) Compare(c *gin.Context) {
+	var ids []int64
+	for _, idStr := range strings.Split(c.Query("ids"), ",") {
+		if idStr = strings.TrimSpace(idStr); idStr == "" {
+			continue
+		}
+		campaignId, err := strconv.ParseInt(idStr, 10, 64)
+		if err != nil {
+			con.Error(c, nil, "无效的筛查批次id")
+			return
+		}
+		ids = append(ids, campaignId)
+	}
+	scope, err := currentDataScope(c)
+	if err != nil {
+		con.Error(c, nil, err.Error())
+		return
+	}
+	campaignService := service.NewCampaignService()
+	comparison, err := campaignService.Compare(ids, scope)
+	if err != nil {
+		con.Error(c, nil, err.Error())
+		return
+	}
+	con.Success(c, comparison)
+}
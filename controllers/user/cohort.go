@@ -0,0 +1,75 @@
+package user
+
+import (
+	"github.com/gin-gonic/gin"
+	"mental/controllers/common"
+	"mental/service"
+	"mental/vo"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CohortController 处理群体统计（筛查看板）相关请求
+type CohortController struct {
+	common.BaseController
+}
+
+// Stats 查询群体统计
+// @Summary 查询SCL测评的群体统计
+// @Description 统计当前用户数据范围内测评记录的各因子及总分均值/标准差、分布直方图、各等级占比和筛查阳性率；group_by 可为 gender（性别）、age_band（年龄段，age_bands 指定分段下界如 18,21,24）、org（org_id 的直接下级）、period（period 为 month/quarter/year）；from/to 为测评日期范围（YYYY-MM-DD，含两端）；默认不计入无效作答，include_invalid=true 时计入；结果缓存，测评或花名册数据变化后自动失效
+// @Tags 管理员
+// @Produce json
+// @Router /scl/stats [get]
+func (con CohortController) Stats(c *gin.Context) {
+	query := vo.CohortQuery{
+		GroupBy:        c.Query("group_by"),
+		Period:         c.Query("period"),
+		IncludeInvalid: c.Query("include_invalid") == "true",
+	}
+	var err error
+	if query.OrgID, err = strconv.ParseInt(c.DefaultQuery("org_id", "0"), 10, 64); err != nil {
+		con.Error(c, nil, "无效的组织id")
+		return
+	}
+	if fromStr := c.Query("from"); fromStr != "" {
+		from, err := time.ParseInLocation("2006-01-02", fromStr, time.Local)
+		if err != nil {
+			con.Error(c, nil, "无效的开始日期")
+			return
+		}
+		query.From = &from
+	}
+	if toStr := c.Query("to"); toStr != "" {
+		to, err := time.ParseInLocation("2006-01-02", toStr, time.Local)
+		if err != nil {
+			con.Error(c, nil, "无效的结束日期")
+			return
+		}
+		to = to.AddDate(0, 0, 1)
+		query.To = &to
+	}
+	if bands := c.Query("age_bands"); bands != "" {
+		for _, band := range strings.Split(bands, ",") {
+			age, err := strconv.Atoi(strings.TrimSpace(band))
+			if err != nil {
+				con.Error(c, nil, "无效的年龄分段")
+				return
+			}
+			query.AgeBands = append(query.AgeBands, age)
+		}
+	}
+
+	scope, err := currentDataScope(c)
+	if err != nil {
+		con.Error(c, nil, err.Error())
+		return
+	}
+	cohortService := service.NewCohortService()
+	stats, err := cohortService.Stats(query, scope)
+	if err != nil {
+		con.Error(c, nil, err.Error())
+		return
+	}
+	con.Success(c, stats)
+}
@@ -0,0 +1,177 @@
+package user
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"mental/controllers/common"
+	"mental/dao"
+	"mental/service"
+	"net/http"
+	"strconv"
+)
+
+// currentDataScope 解析当前登录用户的数据范围
+func currentDataScope(c *gin.Context) (*dao.DataScope, error) {
+	id, _ := c.Get("id")
+	userId, ok := id.(int64)
+	if !ok {
+		return nil, errors.New("无效的用户id")
+	}
+	roles, _ := c.Get("roles")
+	roleIds, _ := roles.([]string)
+	return service.NewDataScopeService().Resolve(userId, roleIds)
+}
+
+// permissionError 返回业务错误：当前用户无权执行该操作时为 403，其余按业务错误返回
+func permissionError(c *gin.Context, err error, prefix string) {
+	if errors.Is(err, service.ErrPermissionDenied) {
+		common.BaseController{}.ErrorWithStatus(c, http.StatusForbidden, nil, err.Error())
+		return
+	}
+	common.BaseController{}.Error(c, nil, prefix+err.Error())
+}
+
+// DataScopeController 处理角色数据范围与组织负责人相关请求
+type DataScopeController struct {
+	common.BaseController
+}
+
+// SetRoleScope 设置角色数据范围
+// @Summary 设置角色的数据范围
+// @Description 设置角色可访问的测评数据范围：self 仅本人、class 所负责的组织（班级）、department 所负责组织所在的院系、all 全部；用户有多个角色时取最大范围，管理员角色始终为全部；仅管理员可操作，否则返回 403
+// @Tags 管理员
+// @Accept json
+// @Produce json
+// @Router /datascope/role [post]
+func (con DataScopeController) SetRoleScope(c *gin.Context) {
+	var req struct {
+		RoleID int    `json:"role_id"`
+		Scope  string `json:"scope"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		con.Error(c, nil, "参数格式错误: "+err.Error())
+		return
+	}
+	operator, err := currentDataScope(c)
+	if err != nil {
+		con.Error(c, nil, err.Error())
+		return
+	}
+	scopeService := service.NewDataScopeService()
+	if err := scopeService.SetRoleScope(req.RoleID, req.Scope, operator); err != nil {
+		permissionError(c, err, "")
+		return
+	}
+	con.Success(c, nil)
+}
+
+// ListRoleScopes 查询角色数据范围
+// @Summary 查询全部角色的数据范围配置
+// @Description 查询已配置数据范围的角色，未配置的角色使用配置文件中的默认范围
+// @Tags 管理员
+// @Produce json
+// @Router /datascope/role/list [get]
+func (con DataScopeController) ListRoleScopes(c *gin.Context) {
+	scopeService := service.NewDataScopeService()
+	list, err := scopeService.ListRoleScopes()
+	if err != nil {
+		con.Error(c, nil, "查询数据范围失败")
+		return
+	}
+	con.Success(c, list)
+}
+
+// MyScope 查询当前用户的数据范围
+// @Summary 查询当前用户生效的数据范围
+// @Description 返回当前用户生效的数据范围及可访问的组织
+// @Tags 管理员/用户
+// @Produce json
+// @Router /datascope/mine [get]
+func (con DataScopeController) MyScope(c *gin.Context) {
+	scope, err := currentDataScope(c)
+	if err != nil {
+		con.Error(c, nil, err.Error())
+		return
+	}
+	con.Success(c, scope)
+}
+
+// AddManager 指定组织负责人
+// @Summary 指定用户为组织负责人
+// @Description 指定辅导员等教职工负责的组织，数据范围为 class/department 的用户据此确定可访问的学生；仅管理员可操作，否则返回 403
+// @Tags 管理员
+// @Accept json
+// @Produce json
+// @Router /datascope/manager [post]
+func (con DataScopeController) AddManager(c *gin.Context) {
+	var req struct {
+		OrgID  int64 `json:"org_id"`
+		UserID int64 `json:"user_id"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		con.Error(c, nil, "参数格式错误: "+err.Error())
+		return
+	}
+	operator, err := currentDataScope(c)
+	if err != nil {
+		con.Error(c, nil, err.Error())
+		return
+	}
+	scopeService := service.NewDataScopeService()
+	if err := scopeService.AddManager(req.OrgID, req.UserID, operator); err != nil {
+		permissionError(c, err, "")
+		return
+	}
+	con.Success(c, nil)
+}
+
+// RemoveManager 取消组织负责人
+// @Summary 取消用户的组织负责人身份
+// @Description 取消指定用户（user_id）对组织（org_id）的负责；仅管理员可操作，否则返回 403
+// @Tags 管理员
+// @Produce json
+// @Router /datascope/manager [delete]
+func (con DataScopeController) RemoveManager(c *gin.Context) {
+	orgId, err := strconv.ParseInt(c.Query("org_id"), 10, 64)
+	if err != nil {
+		con.Error(c, nil, "无效的组织id")
+		return
+	}
+	userId, err := strconv.ParseInt(c.Query("user_id"), 10, 64)
+	if err != nil {
+		con.Error(c, nil, "无效的用户id")
+		return
+	}
+	operator, err := currentDataScope(c)
+	if err != nil {
+		con.Error(c, nil, err.Error())
+		return
+	}
+	scopeService := service.NewDataScopeService()
+	if err := scopeService.RemoveManager(orgId, userId, operator); err != nil {
+		permissionError(c, err, "取消负责人失败: ")
+		return
+	}
+	con.Success(c, nil)
+}
+
+// ListManagers 查询组织负责人
+// @Summary 查询组织的负责人
+// @Description 查询指定组织（org_id）的负责人列表
+// @Tags 管理员
+// @Produce json
+// @Router /datascope/manager/list [get]
+func (con DataScopeController) ListManagers(c *gin.Context) {
+	orgId, err := strconv.ParseInt(c.Query("org_id"), 10, 64)
+	if err != nil {
+		con.Error(c, nil, "无效的组织id")
+		return
+	}
+	scopeService := service.NewDataScopeService()
+	list, err := scopeService.ListManagers(orgId)
+	if err != nil {
+		con.Error(c, nil, "查询负责人失败")
+		return
+	}
+	con.Success(c, list)
+}
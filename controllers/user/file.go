@@ -9,6 +9,7 @@ import (
 	"mental/utils"
 	"os"
 	"path/filepath"
+	"strconv"
 )
 
 type FileController struct {
@@ -217,7 +218,7 @@ func (con FileController) Upload(c *gin.Context) {
 // 根据传入的file_id，解析文件，插入到scl表
 // ParseFile 解析文件接口
 // @Summary 解析文件，插入scl到数据库
-// @Description 解析文件接口
+// @Description 解析文件接口，按映射方案定位各列并按新增测评记录的规则校验（因子分 0-5、性别、年龄、测评日期），校验未通过的行不导入；preview=true 时只预览，返回逐行错误和警告及确认凭证 token，不写入数据，确认后调用 /common/parse-file/commit 导入；profile_id 指定方案，否则按 org_id（为空时为当前用户所在组织）逐级向上查找启用的方案，都没有时使用内置方案；sheet 指定工作表，all_sheets=true 时读取全部工作表；每次导入生成导入批次（batch_id），可通过 /common/import-batch/rollback 回滚；atomic=true 时全部行在同一事务中写入，任一行失败则整批不导入；async=true 时在后台导入，立即返回导入任务，可通过 /common/import-job 查询进度；有未导入的行时返回失败行文件 error_file，保留原表头并追加错误信息列、标红出错单元格，修改后可重新导入；同一文件同时只能有一个导入在执行
 // @Tags 解析文件
 // @Produce json
 // @Router /common/parse-file [post]
@@ -227,13 +228,82 @@ func (con FileController) ParseFile(c *gin.Context) {
 		con.Error(c, nil, "文件id不可为空！")
 		return
 	}
+	id, _ := c.Get("id")
+	userId, _ := id.(int64)
+	profileId, err := strconv.ParseInt(c.DefaultQuery("profile_id", "0"), 10, 64)
+	if err != nil {
+		con.Error(c, nil, "无效的映射方案id")
+		return
+	}
+	orgId, err := strconv.ParseInt(c.DefaultQuery("org_id", "0"), 10, 64)
+	if err != nil {
+		con.Error(c, nil, "无效的组织id")
+		return
+	}
 	// 初始化 FileService
-	fileService := service.FileService{FileId: fileID}
-	// 文件解析
-	successNum, errorRows := fileService.ImportFromFileId()
-	// 生成返回 JSON
-	con.Success(c, gin.H{
-		"success_num": successNum,
-		"error_rows":  errorRows, // 返回错误信息
-	})
+	fileService := service.FileService{
+		FileId:    fileID,
+		ProfileID: profileId,
+		OrgID:     orgId,
+		UserID:    userId,
+		Sheet:     c.Query("sheet"),
+		AllSheets: c.Query("all_sheets") == "true",
+		Atomic:    c.Query("atomic") == "true",
+	}
+	if c.Query("preview") == "true" {
+		preview, err := fileService.Preview()
+		if err != nil {
+			con.Error(c, nil, err.Error())
+			return
+		}
+		con.Success(c, preview)
+		return
+	}
+	if c.Query("async") == "true" {
+		job, err := fileService.StartImportJob()
+		if err != nil {
+			con.Error(c, nil, err.Error())
+			return
+		}
+		con.Success(c, job)
+		return
+	}
+	// 文件解析，返回导入批次、成功行数和错误信息
+	con.Success(c, fileService.ImportFromFileId())
+}
+
+// CommitParse 确认导入预览过的文件
+// @Summary 确认导入预览过的文件
+// @Description 使用预览返回的 token 确认导入，沿用预览时的映射方案和工作表参数；token 有效期 30 分钟、只能使用一次，且只能由预览人确认；导入时重新校验，校验未通过的行不导入；atomic=true 时任一行失败则整批不导入；async=true 时在后台导入并返回导入任务；有未导入的行时返回失败行文件 error_file
+// @Tags 解析文件
+// @Produce json
+// @Router /common/parse-file/commit [post]
+func (con FileController) CommitParse(c *gin.Context) {
+	id, _ := c.Get("id")
+	userId, ok := id.(int64)
+	if !ok {
+		con.Error(c, nil, "无效的用户id")
+		return
+	}
+	token := c.Query("token")
+	if token == "" {
+		con.Error(c, nil, "确认凭证不可为空！")
+		return
+	}
+	fileService, err := service.ConfirmPreview(token, userId)
+	if err != nil {
+		con.Error(c, nil, err.Error())
+		return
+	}
+	fileService.Atomic = fileService.Atomic || c.Query("atomic") == "true"
+	if c.Query("async") == "true" {
+		job, err := fileService.StartImportJob()
+		if err != nil {
+			con.Error(c, nil, err.Error())
+			return
+		}
+		con.Success(c, job)
+		return
+	}
+	con.Success(c, fileService.ImportFromFileId())
 }
@@ -0,0 +1,177 @@
+package user
+
+import (
+	"github.com/gin-gonic/gin"
+	"mental/controllers/common"
+	"mental/models"
+	"mental/service"
+	"strconv"
+)
+
+// FollowUpController 处理辅导员跟进记录相关请求
+type FollowUpController struct {
+	common.BaseController
+}
+
+// CreateFollowUp 新建跟进记录
+// @Summary 新建辅导员跟进记录
+// @Description 记录对学生的跟进：干预方式（interview/phone/group/parent/referral/observe/other）、访谈记录、跟进结果、下次跟进日期，可关联测评记录（scl_id）和预警（alert_id）；附件先通过 /common/upload 上传，再以 file_id 引用；关联的预警为新建或已确认状态时自动进入跟进中；跟进记录相关接口仅辅导员或管理员可用，且学生须在当前用户的数据范围内，否则返回 403
+// @Tags 管理员
+// @Accept json
+// @Produce json
+// @Router /followup [post]
+func (con FollowUpController) CreateFollowUp(c *gin.Context) {
+	operator, err := currentDataScope(c)
+	if err != nil {
+		con.Error(c, nil, err.Error())
+		return
+	}
+	var followUp models.FollowUp
+	if err := c.ShouldBindJSON(&followUp); err != nil {
+		con.Error(c, nil, "参数格式错误: "+err.Error())
+		return
+	}
+	followUpService := service.NewFollowUpService()
+	if err := followUpService.Create(&followUp, operator); err != nil {
+		permissionError(c, err, "")
+		return
+	}
+	con.Success(c, followUp)
+}
+
+// UpdateFollowUp 修改跟进记录
+// @Summary 修改辅导员跟进记录
+// @Description 修改跟进记录内容并以提交的附件列表替换原有附件，学生和记录人不可修改
+// @Tags 管理员
+// @Accept json
+// @Produce json
+// @Router /followup/update [post]
+func (con FollowUpController) UpdateFollowUp(c *gin.Context) {
+	operator, err := currentDataScope(c)
+	if err != nil {
+		con.Error(c, nil, err.Error())
+		return
+	}
+	var followUp models.FollowUp
+	if err := c.ShouldBindJSON(&followUp); err != nil {
+		con.Error(c, nil, "参数格式错误: "+err.Error())
+		return
+	}
+	followUpService := service.NewFollowUpService()
+	if err := followUpService.Update(&followUp, operator); err != nil {
+		permissionError(c, err, "更新跟进记录失败: ")
+		return
+	}
+	con.Success(c, nil)
+}
+
+// GetFollowUp 查询跟进记录详情
+// @Summary 查询辅导员跟进记录详情
+// @Description 查询指定id的跟进记录及其附件
+// @Tags 管理员
+// @Produce json
+// @Router /followup [get]
+func (con FollowUpController) GetFollowUp(c *gin.Context) {
+	followUpId, err := strconv.ParseInt(c.Query("id"), 10, 64)
+	if err != nil {
+		con.Error(c, nil, "无效的跟进记录id")
+		return
+	}
+	operator, err := currentDataScope(c)
+	if err != nil {
+		con.Error(c, nil, err.Error())
+		return
+	}
+	followUpService := service.NewFollowUpService()
+	followUp, err := followUpService.Get(followUpId, operator)
+	if err != nil {
+		permissionError(c, err, "")
+		return
+	}
+	con.Success(c, followUp)
+}
+
+// ListFollowUps 查询学生的跟进记录
+// @Summary 查询学生的全部跟进记录
+// @Description 按跟进日期倒序返回指定学生的跟进记录及附件
+// @Tags 管理员
+// @Produce json
+// @Router /followup/list [get]
+func (con FollowUpController) ListFollowUps(c *gin.Context) {
+	studentId, err := strconv.ParseInt(c.Query("student_id"), 10, 64)
+	if err != nil {
+		con.Error(c, nil, "无效的学生id")
+		return
+	}
+	operator, err := currentDataScope(c)
+	if err != nil {
+		con.Error(c, nil, err.Error())
+		return
+	}
+	followUpService := service.NewFollowUpService()
+	list, err := followUpService.ListByStudent(studentId, operator)
+	if err != nil {
+		permissionError(c, err, "查询跟进记录失败: ")
+		return
+	}
+	con.Success(c, list)
+}
+
+// ListDue 查询待跟进的学生
+// @Summary 查询即将到期或已逾期的下次跟进
+// @Description 返回 days 天内（默认 7 天，含已逾期）到期的下次跟进，每个学生只取最近一次跟进记录；mine=true 时只查询当前用户记录的跟进
+// @Tags 管理员
+// @Produce json
+// @Router /followup/due [get]
+func (con FollowUpController) ListDue(c *gin.Context) {
+	days := 7
+	if daysStr := c.Query("days"); daysStr != "" {
+		var err error
+		if days, err = strconv.Atoi(daysStr); err != nil {
+			con.Error(c, nil, "无效的天数")
+			return
+		}
+	}
+	operator, err := currentDataScope(c)
+	if err != nil {
+		con.Error(c, nil, err.Error())
+		return
+	}
+	var counselorId *int64
+	if c.Query("mine") == "true" {
+		counselorId = &operator.UserID
+	}
+	followUpService := service.NewFollowUpService()
+	list, err := followUpService.ListDue(days, counselorId, operator)
+	if err != nil {
+		permissionError(c, err, "")
+		return
+	}
+	con.Success(c, list)
+}
+
+// Timeline 查询学生时间线
+// @Summary 查询学生的测评与跟进时间线
+// @Description 按日期正序合并学生的全部测评结果（SCL-90 及其他量表）、高风险预警和跟进记录
+// @Tags 管理员
+// @Produce json
+// @Router /followup/timeline [get]
+func (con FollowUpController) Timeline(c *gin.Context) {
+	studentId, err := strconv.ParseInt(c.Query("student_id"), 10, 64)
+	if err != nil {
+		con.Error(c, nil, "无效的学生id")
+		return
+	}
+	operator, err := currentDataScope(c)
+	if err != nil {
+		con.Error(c, nil, err.Error())
+		return
+	}
+	followUpService := service.NewFollowUpService()
+	timeline, err := followUpService.Timeline(studentId, operator)
+	if err != nil {
+		permissionError(c, err, "")
+		return
+	}
+	con.Success(c, timeline)
+}
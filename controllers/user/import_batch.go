@@ -0,0 +1,66 @@
+package user
+
+import (
+	"github.com/gin-gonic/gin"
+	"mental/controllers/common"
+	"mental/service"
+	"strconv"
+)
+
+// ImportBatchController 处理 Excel 导入批次相关请求
+type ImportBatchController struct {
+	common.BaseController
+}
+
+// ListBatches 查询导入批次列表
+// @Summary 查询Excel导入批次列表
+// @Description 按导入时间倒序查询导入批次，可通过 file_id 只查询该文件的批次；管理员可查看全部批次，其他用户只能查看自己导入的批次
+// @Tags 管理员
+// @Produce json
+// @Router /common/import-batch/list [get]
+func (con ImportBatchController) ListBatches(c *gin.Context) {
+	operator, err := currentDataScope(c)
+	if err != nil {
+		con.Error(c, nil, err.Error())
+		return
+	}
+	batchService := service.NewImportBatchService()
+	list, err := batchService.ListBatches(c.Query("file_id"), operator)
+	if err != nil {
+		permissionError(c, err, "查询导入批次失败: ")
+		return
+	}
+	con.Success(c, list)
+}
+
+// Rollback 回滚导入批次
+// @Summary 回滚指定id的Excel导入批次
+// @Description 软删除该批次导入的全部测评记录并将批次标记为已回滚，返回删除的记录数；批次中的记录须全部在当前用户的数据范围内
+// @Tags 管理员
+// @Produce json
+// @Router /common/import-batch/rollback [post]
+func (con ImportBatchController) Rollback(c *gin.Context) {
+	id, _ := c.Get("id")
+	userId, ok := id.(int64)
+	if !ok {
+		con.Error(c, nil, "无效的用户id")
+		return
+	}
+	batchId, err := strconv.ParseInt(c.Query("id"), 10, 64)
+	if err != nil {
+		con.Error(c, nil, "无效的导入批次id")
+		return
+	}
+	scope, err := currentDataScope(c)
+	if err != nil {
+		con.Error(c, nil, err.Error())
+		return
+	}
+	batchService := service.NewImportBatchService()
+	deleted, err := batchService.Rollback(batchId, userId, scope)
+	if err != nil {
+		con.Error(c, nil, err.Error())
+		return
+	}
+	con.Success(c, gin.H{"deleted": deleted})
+}
@@ -0,0 +1,100 @@
+package user
+
+import (
+	"github.com/gin-gonic/gin"
+	"io"
+	"mental/controllers/common"
+	"mental/service"
+	"time"
+)
+
+// importJobEventInterval 推送导入进度的间隔
+const importJobEventInterval = time.Second
+
+// ImportJobController 处理后台导入任务相关请求
+type ImportJobController struct {
+	common.BaseController
+}
+
+// GetJob 查询导入任务进度
+// @Summary 查询后台导入任务进度
+// @Description 查询本人发起的导入任务的状态（pending/running/completed/failed/canceled）、数据行数、已处理行数、失败行数及导入结果；任务进度保留 24 小时
+// @Tags 解析文件
+// @Produce json
+// @Router /common/import-job [get]
+func (con ImportJobController) GetJob(c *gin.Context) {
+	id, _ := c.Get("id")
+	userId, ok := id.(int64)
+	if !ok {
+		con.Error(c, nil, "无效的用户id")
+		return
+	}
+	job, err := service.GetImportJob(c.Query("id"), userId)
+	if err != nil {
+		con.Error(c, nil, err.Error())
+		return
+	}
+	con.Success(c, job)
+}
+
+// Events 推送导入任务进度
+// @Summary 以服务器推送事件（SSE）实时推送导入任务进度
+// @Description 每秒推送一次 progress 事件，内容与查询导入任务进度接口相同；任务结束后推送最终进度并关闭连接，查询失败时推送 error 事件
+// @Tags 解析文件
+// @Produce text/event-stream
+// @Router /common/import-job/events [get]
+func (con ImportJobController) Events(c *gin.Context) {
+	id, _ := c.Get("id")
+	userId, ok := id.(int64)
+	if !ok {
+		con.Error(c, nil, "无效的用户id")
+		return
+	}
+	jobId := c.Query("id")
+	job, err := service.GetImportJob(jobId, userId)
+	if err != nil {
+		con.Error(c, nil, err.Error())
+		return
+	}
+
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no") // 关闭反向代理缓冲，保证进度及时送达
+	ticker := time.NewTicker(importJobEventInterval)
+	defer ticker.Stop()
+	c.Stream(func(w io.Writer) bool {
+		c.SSEvent("progress", job)
+		if job.Finished() {
+			return false
+		}
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case <-ticker.C:
+		}
+		if job, err = service.GetImportJob(jobId, userId); err != nil {
+			c.SSEvent("error", err.Error())
+			return false
+		}
+		return true
+	})
+}
+
+// CancelJob 取消导入任务
+// @Summary 取消后台导入任务
+// @Description 取消本人发起的未结束的导入任务，任务在处理下一批行时停止，已写入的记录随之撤销，文件不标记为已解析
+// @Tags 解析文件
+// @Produce json
+// @Router /common/import-job/cancel [post]
+func (con ImportJobController) CancelJob(c *gin.Context) {
+	id, _ := c.Get("id")
+	userId, ok := id.(int64)
+	if !ok {
+		con.Error(c, nil, "无效的用户id")
+		return
+	}
+	if err := service.CancelImportJob(c.Query("id"), userId); err != nil {
+		con.Error(c, nil, err.Error())
+		return
+	}
+	con.Success(c, nil)
+}
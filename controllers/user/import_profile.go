@@ -0,0 +1,171 @@
+package user
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"mental/controllers/common"
+	"mental/models"
+	"mental/service"
+	"net/http"
+	"strconv"
+)
+
+// ImportProfileController 处理 Excel 导入列映射方案相关请求
+type ImportProfileController struct {
+	common.BaseController
+}
+
+// importProfileForm 新建/修改映射方案的参数
+type importProfileForm struct {
+	ID      int64           `json:"id"`
+	Name    string          `json:"name"`
+	OrgID   int64           `json:"org_id"`
+	Content json.RawMessage `json:"content"` // 映射内容（JSON 对象）
+	Remark  string          `json:"remark"`
+}
+
+// CreateProfile 新建映射方案
+// @Summary 新建Excel导入列映射方案
+// @Description 新建组织的导入映射方案，content 包含 sheet（工作表名）、all_sheets（读取全部工作表）、header_row（表头行）、columns（字段映射：field 为 student_id/name/gender/age/test_date 或因子字段名，headers 为表头名称及别名，column 为兜底列）和 items_start（逐题作答起始列）；新建后需单独启用；仅管理员或该组织（含上级组织）的负责人可操作，全局方案仅管理员可新建，否则返回 403
+// @Tags 管理员
+// @Accept json
+// @Produce json
+// @Router /common/import-profile [post]
+func (con ImportProfileController) CreateProfile(c *gin.Context) {
+	operator, err := currentDataScope(c)
+	if err != nil {
+		con.Error(c, nil, err.Error())
+		return
+	}
+	var form importProfileForm
+	if err := c.ShouldBindJSON(&form); err != nil {
+		con.Error(c, nil, "参数格式错误: "+err.Error())
+		return
+	}
+	profile := &models.ImportProfile{
+		Name:      form.Name,
+		OrgID:     form.OrgID,
+		Content:   string(form.Content),
+		Remark:    form.Remark,
+		CreatedBy: operator.UserID,
+	}
+	profileService := service.NewImportProfileService()
+	if err := profileService.CreateProfile(profile, operator); err != nil {
+		permissionError(c, err, "")
+		return
+	}
+	con.Success(c, profile)
+}
+
+// UpdateProfile 修改映射方案
+// @Summary 修改Excel导入列映射方案
+// @Description 修改指定id映射方案的名称、内容和备注，所属组织和启用状态不变；仅管理员或方案所属组织（含上级组织）的负责人可操作，否则返回 403
+// @Tags 管理员
+// @Accept json
+// @Produce json
+// @Router /common/import-profile/update [post]
+func (con ImportProfileController) UpdateProfile(c *gin.Context) {
+	operator, err := currentDataScope(c)
+	if err != nil {
+		con.Error(c, nil, err.Error())
+		return
+	}
+	var form importProfileForm
+	if err := c.ShouldBindJSON(&form); err != nil {
+		con.Error(c, nil, "参数格式错误: "+err.Error())
+		return
+	}
+	profile := &models.ImportProfile{
+		ID:      form.ID,
+		Name:    form.Name,
+		Content: string(form.Content),
+		Remark:  form.Remark,
+	}
+	profileService := service.NewImportProfileService()
+	if err := profileService.UpdateProfile(profile, operator); err != nil {
+		permissionError(c, err, "")
+		return
+	}
+	con.Success(c, nil)
+}
+
+// ListProfiles 查询映射方案列表
+// @Summary 查询Excel导入列映射方案列表
+// @Description 查询指定组织的映射方案，org_id 为空时查询全局方案；仅管理员或该组织（含上级组织）的负责人可查询，全局方案仅管理员可查询，否则返回 403
+// @Tags 管理员
+// @Produce json
+// @Router /common/import-profile/list [get]
+func (con ImportProfileController) ListProfiles(c *gin.Context) {
+	orgId, _ := strconv.ParseInt(c.DefaultQuery("org_id", "0"), 10, 64)
+	operator, err := currentDataScope(c)
+	if err != nil {
+		con.Error(c, nil, err.Error())
+		return
+	}
+	profileService := service.NewImportProfileService()
+	list, err := profileService.ListProfiles(orgId, operator)
+	if err != nil {
+		permissionError(c, err, "查询映射方案失败: ")
+		return
+	}
+	con.Success(c, list)
+}
+
+// ActivateProfile 启用映射方案
+// @Summary 启用指定id的Excel导入列映射方案
+// @Description 启用映射方案，同一组织下其余方案自动停用；下级组织未启用自己的方案时沿用该方案；仅管理员或方案所属组织（含上级组织）的负责人可操作，否则返回 403
+// @Tags 管理员
+// @Produce json
+// @Router /common/import-profile/activate [post]
+func (con ImportProfileController) ActivateProfile(c *gin.Context) {
+	profileId, err := strconv.ParseInt(c.Query("id"), 10, 64)
+	if err != nil {
+		con.Error(c, nil, "无效的映射方案id")
+		return
+	}
+	operator, err := currentDataScope(c)
+	if err != nil {
+		con.Error(c, nil, err.Error())
+		return
+	}
+	profileService := service.NewImportProfileService()
+	if err := profileService.ActivateProfile(profileId, operator); err != nil {
+		permissionError(c, err, "")
+		return
+	}
+	con.Success(c, nil)
+}
+
+// Template 下载空白导入模板
+// @Summary 下载SCL测评数据的空白导入模板
+// @Description 按映射方案生成空白Excel模板：profile_id 指定方案，否则使用 org_id（为空时为全局）当前启用的方案，都没有时使用内置方案；items=true 时以 90 道题的逐题作答列代替十个因子分；仅管理员或方案所属组织（未指定方案时为 org_id，含上级组织）的负责人可下载，全局方案和内置方案仅管理员可下载，否则返回 403
+// @Tags 管理员
+// @Produce application/octet-stream
+// @Router /common/import-template [get]
+func (con ImportProfileController) Template(c *gin.Context) {
+	profileId, err := strconv.ParseInt(c.DefaultQuery("profile_id", "0"), 10, 64)
+	if err != nil {
+		con.Error(c, nil, "无效的映射方案id")
+		return
+	}
+	orgId, err := strconv.ParseInt(c.DefaultQuery("org_id", "0"), 10, 64)
+	if err != nil {
+		con.Error(c, nil, "无效的组织id")
+		return
+	}
+	operator, err := currentDataScope(c)
+	if err != nil {
+		con.Error(c, nil, err.Error())
+		return
+	}
+	profileService := service.NewImportProfileService()
+	var buf bytes.Buffer
+	if err := profileService.Template(profileId, orgId, c.Query("items") == "true", operator, &buf); err != nil {
+		permissionError(c, err, "生成模板失败: ")
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", "scl_import_template.xlsx"))
+	c.Data(http.StatusOK, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", buf.Bytes())
+}
@@ -0,0 +1,111 @@
+package user
+
+import (
+	"github.com/gin-gonic/gin"
+	"mental/controllers/common"
+	"mental/models"
+	"mental/service"
+	"strconv"
+)
+
+// NormController 处理常模相关请求
+type NormController struct {
+	common.BaseController
+}
+
+// CreateGroup 新建常模组
+// @Summary 新建常模组
+// @Description 以 JSON 提交常模组及各指标在不同性别、年龄段下的均值和标准差；仅管理员可操作，否则返回 403
+// @Tags 管理员
+// @Accept json
+// @Produce json
+// @Router /norm [post]
+func (con NormController) CreateGroup(c *gin.Context) {
+	operator, err := currentDataScope(c)
+	if err != nil {
+		con.Error(c, nil, err.Error())
+		return
+	}
+	var group models.NormGroup
+	if err := c.ShouldBindJSON(&group); err != nil {
+		con.Error(c, nil, "参数格式错误: "+err.Error())
+		return
+	}
+	normService := service.NewNormService()
+	if err := normService.CreateGroup(&group, operator); err != nil {
+		permissionError(c, err, "")
+		return
+	}
+	con.Success(c, group)
+}
+
+// ImportGroup 从Excel导入常模组
+// @Summary 从已上传的Excel导入常模组
+// @Description 列依次为：指标、性别、年龄下限、年龄上限、均值、标准差；仅管理员可操作，否则返回 403
+// @Tags 管理员
+// @Produce json
+// @Router /norm/import [post]
+func (con NormController) ImportGroup(c *gin.Context) {
+	fileId := c.Query("file_id")
+	if fileId == "" {
+		con.Error(c, nil, "文件id不可为空！")
+		return
+	}
+	operator, err := currentDataScope(c)
+	if err != nil {
+		con.Error(c, nil, err.Error())
+		return
+	}
+	group := &models.NormGroup{
+		Code:        c.Query("code"),
+		Name:        c.Query("name"),
+		Description: c.Query("description"),
+	}
+	normService := service.NewNormService()
+	if err := normService.ImportFromFileId(fileId, group, operator); err != nil {
+		permissionError(c, err, "")
+		return
+	}
+	con.Success(c, group)
+}
+
+// ListGroups 查询常模组列表
+// @Summary 查询常模组列表
+// @Description 查询全部常模组（含内置常模）
+// @Tags 管理员/用户
+// @Produce json
+// @Router /norm/list [get]
+func (con NormController) ListGroups(c *gin.Context) {
+	normService := service.NewNormService()
+	list, err := normService.ListGroups()
+	if err != nil {
+		con.Error(c, nil, "查询常模组失败")
+		return
+	}
+	con.Success(c, list)
+}
+
+// SetDefault 设置默认常模组
+// @Summary 设置默认常模组
+// @Description 设置未指定常模时使用的默认常模组；仅管理员可操作，否则返回 403
+// @Tags 管理员
+// @Produce json
+// @Router /norm/default [post]
+func (con NormController) SetDefault(c *gin.Context) {
+	groupId, err := strconv.ParseInt(c.Query("id"), 10, 64)
+	if err != nil {
+		con.Error(c, nil, "无效的常模组id")
+		return
+	}
+	operator, err := currentDataScope(c)
+	if err != nil {
+		con.Error(c, nil, err.Error())
+		return
+	}
+	normService := service.NewNormService()
+	if err := normService.SetDefault(groupId, operator); err != nil {
+		permissionError(c, err, "")
+		return
+	}
+	con.Success(c, nil)
+}
@@ -0,0 +1,63 @@
+package user
+
+import (
+	"github.com/gin-gonic/gin"
+	"mental/controllers/common"
+	"mental/service"
+)
+
+// NotificationController 处理站内通知相关请求
+type NotificationController struct {
+	common.BaseController
+}
+
+// ListNotifications 查询我的通知
+// @Summary 查询当前用户的站内通知
+// @Description 按时间倒序返回当前用户的通知（预约成功、预约取消、预约提醒等），unread=true 时只返回未读通知
+// @Tags 管理员/用户
+// @Produce json
+// @Router /notification/list [get]
+func (con NotificationController) ListNotifications(c *gin.Context) {
+	id, _ := c.Get("id")
+	userId, ok := id.(int64)
+	if !ok {
+		con.Error(c, nil, "无效的用户id")
+		return
+	}
+	notificationService := service.NewNotificationService()
+	list, err := notificationService.List(userId, c.Query("unread") == "true")
+	if err != nil {
+		con.Error(c, nil, "查询通知失败")
+		return
+	}
+	con.Success(c, list)
+}
+
+// MarkRead 标记通知已读
+// @Summary 将当前用户的通知标记为已读
+// @Description 将 ids 中的通知标记为已读，ids 为空时标记全部未读通知
+// @Tags 管理员/用户
+// @Accept json
+// @Produce json
+// @Router /notification/read [post]
+func (con NotificationController) MarkRead(c *gin.Context) {
+	id, _ := c.Get("id")
+	userId, ok := id.(int64)
+	if !ok {
+		con.Error(c, nil, "无效的用户id")
+		return
+	}
+	var req struct {
+		IDs []int64 `json:"ids"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		con.Error(c, nil, "参数格式错误: "+err.Error())
+		return
+	}
+	notificationService := service.NewNotificationService()
+	if err := notificationService.MarkRead(userId, req.IDs); err != nil {
+		con.Error(c, nil, "标记已读失败")
+		return
+	}
+	con.Success(c, nil)
+}
@@ -0,0 +1,223 @@
+package user
+
+import (
+	"github.com/gin-gonic/gin"
+	"mental/controllers/common"
+	"mental/models"
+	"mental/service"
+	"strconv"
+	"time"
+)
+
+// OrgController 处理组织树与班级花名册相关请求
+type OrgController struct {
+	common.BaseController
+}
+
+// CreateOrg 新建组织
+// @Summary 新建组织单元
+// @Description 新建学校（school）、学院（college）、年级（grade）或班级（class）；顶级只能是学校，学校下为学院/年级，学院下为年级/班级，年级下为班级；编码全局唯一，花名册导入时按班级编码匹配；仅管理员或上级组织的负责人可操作，学校只能由管理员新建，否则返回 403
+// @Tags 管理员
+// @Accept json
+// @Produce json
+// @Router /org [post]
+func (con OrgController) CreateOrg(c *gin.Context) {
+	operator, err := currentDataScope(c)
+	if err != nil {
+		con.Error(c, nil, err.Error())
+		return
+	}
+	var org models.OrgUnit
+	if err := c.ShouldBindJSON(&org); err != nil {
+		con.Error(c, nil, "参数格式错误: "+err.Error())
+		return
+	}
+	orgService := service.NewOrgService()
+	if err := orgService.CreateOrg(&org, operator); err != nil {
+		permissionError(c, err, "")
+		return
+	}
+	con.Success(c, org)
+}
+
+// UpdateOrg 修改组织
+// @Summary 修改组织单元
+// @Description 修改组织的名称、编码和排序号，上级和类型不可修改；仅管理员或该组织（含上级组织）的负责人可操作，否则返回 403
+// @Tags 管理员
+// @Accept json
+// @Produce json
+// @Router /org/update [post]
+func (con OrgController) UpdateOrg(c *gin.Context) {
+	operator, err := currentDataScope(c)
+	if err != nil {
+		con.Error(c, nil, err.Error())
+		return
+	}
+	var org models.OrgUnit
+	if err := c.ShouldBindJSON(&org); err != nil {
+		con.Error(c, nil, "参数格式错误: "+err.Error())
+		return
+	}
+	orgService := service.NewOrgService()
+	if err := orgService.UpdateOrg(&org, operator); err != nil {
+		permissionError(c, err, "")
+		return
+	}
+	con.Success(c, nil)
+}
+
+// DeleteOrg 删除组织
+// @Summary 删除组织单元
+// @Description 删除没有下级组织且花名册为空的组织；仅管理员或上级组织的负责人可操作，学校只能由管理员删除，否则返回 403
+// @Tags 管理员
+// @Produce json
+// @Router /org [delete]
+func (con OrgController) DeleteOrg(c *gin.Context) {
+	orgId, err := strconv.ParseInt(c.Query("id"), 10, 64)
+	if err != nil {
+		con.Error(c, nil, "无效的组织id")
+		return
+	}
+	operator, err := currentDataScope(c)
+	if err != nil {
+		con.Error(c, nil, err.Error())
+		return
+	}
+	orgService := service.NewOrgService()
+	if err := orgService.DeleteOrg(orgId, operator); err != nil {
+		permissionError(c, err, "")
+		return
+	}
+	con.Success(c, nil)
+}
+
+// Tree 查询组织树
+// @Summary 查询完整的组织树
+// @Description 返回 学校 → 学院/年级 → 班级 的嵌套组织树，同级按排序号排列
+// @Tags 管理员
+// @Produce json
+// @Router /org/tree [get]
+func (con OrgController) Tree(c *gin.Context) {
+	orgService := service.NewOrgService()
+	tree, err := orgService.Tree()
+	if err != nil {
+		con.Error(c, nil, "查询组织树失败")
+		return
+	}
+	con.Success(c, tree)
+}
+
+// ListMembers 查询花名册
+// @Summary 查询组织的学生花名册
+// @Description 查询指定组织（含全部下级班级）的花名册，按班级、学号排序
+// @Tags 管理员
+// @Produce json
+// @Router /org/members [get]
+func (con OrgController) ListMembers(c *gin.Context) {
+	orgId, err := strconv.ParseInt(c.Query("org_id"), 10, 64)
+	if err != nil {
+		con.Error(c, nil, "无效的组织id")
+		return
+	}
+	orgService := service.NewOrgService()
+	list, err := orgService.ListMembers(orgId)
+	if err != nil {
+		con.Error(c, nil, err.Error())
+		return
+	}
+	con.Success(c, list)
+}
+
+// RemoveMember 移出花名册
+// @Summary 将学生移出花名册
+// @Description 删除指定id的花名册记录，学生的测评记录不受影响；仅管理员或学生所在班级（含上级组织）的负责人可操作，否则返回 403
+// @Tags 管理员
+// @Produce json
+// @Router /org/member [delete]
+func (con OrgController) RemoveMember(c *gin.Context) {
+	memberId, err := strconv.ParseInt(c.Query("id"), 10, 64)
+	if err != nil {
+		con.Error(c, nil, "无效的花名册id")
+		return
+	}
+	operator, err := currentDataScope(c)
+	if err != nil {
+		con.Error(c, nil, err.Error())
+		return
+	}
+	orgService := service.NewOrgService()
+	if err := orgService.RemoveMember(memberId, operator); err != nil {
+		permissionError(c, err, "移出花名册失败: ")
+		return
+	}
+	con.Success(c, nil)
+}
+
+// ImportRoster 导入花名册
+// @Summary 从Excel导入学生花名册
+// @Description 文件先通过 /common/upload 上传，再以 file_id 导入；首个工作表首行为表头，须包含 学号、姓名、性别（男/女 或 1/0）、班级编码 四列；学号已存在时更新其班级（调班），账号与学号一致的用户自动关联；非管理员只能导入（及调出）自己负责的组织中的班级，其余行记为错误行
+// @Tags 管理员
+// @Produce json
+// @Router /org/roster/import [post]
+func (con OrgController) ImportRoster(c *gin.Context) {
+	fileID := c.Query("file_id")
+	if fileID == "" {
+		con.Error(c, nil, "文件id不可为空！")
+		return
+	}
+	operator, err := currentDataScope(c)
+	if err != nil {
+		con.Error(c, nil, err.Error())
+		return
+	}
+	orgService := service.NewOrgService()
+	successNum, errorRows := orgService.ImportRoster(fileID, operator)
+	con.Success(c, gin.H{
+		"success_num": successNum,
+		"error_rows":  errorRows,
+	})
+}
+
+// Stats 查询组织测评汇总
+// @Summary 按组织汇总SCL测评情况
+// @Description 汇总指定组织及其每个直接下级的花名册人数、测评覆盖率、各因子均值、等级分布和阳性检出率；每名学生取 [from, to] 日期范围内最近一次有效测评，日期格式 YYYY-MM-DD，为空时不限；组织不在当前用户的数据范围内时返回 403
+// @Tags 管理员
+// @Produce json
+// @Router /org/stats [get]
+func (con OrgController) Stats(c *gin.Context) {
+	orgId, err := strconv.ParseInt(c.Query("org_id"), 10, 64)
+	if err != nil {
+		con.Error(c, nil, "无效的组织id")
+		return
+	}
+	var from, to *time.Time
+	if fromStr := c.Query("from"); fromStr != "" {
+		t, err := time.ParseInLocation("2006-01-02", fromStr, time.Local)
+		if err != nil {
+			con.Error(c, nil, "无效的开始日期")
+			return
+		}
+		from = &t
+	}
+	if toStr := c.Query("to"); toStr != "" {
+		t, err := time.ParseInLocation("2006-01-02", toStr, time.Local)
+		if err != nil {
+			con.Error(c, nil, "无效的结束日期")
+			return
+		}
+		t = t.AddDate(0, 0, 1)
+		to = &t
+	}
+	scope, err := currentDataScope(c)
+	if err != nil {
+		con.Error(c, nil, err.Error())
+		return
+	}
+	orgService := service.NewOrgService()
+	stats, err := orgService.Stats(orgId, from, to, scope)
+	if err != nil {
+		permissionError(c, err, "")
+		return
+	}
+	con.Success(c, stats)
+}
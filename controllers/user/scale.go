@@ -0,0 +1,170 @@
+package user
+
+import (
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"mental/controllers/common"
+	"mental/models"
+	"mental/service"
+	"mental/vo"
+	"strconv"
+)
+
+// ScaleController 处理通用量表相关请求
+type ScaleController struct {
+	common.BaseController
+}
+
+// ListScales 查询量表列表
+// @Summary 查询量表列表
+// @Description 查询全部量表（含内置的 SCL-90、PHQ-9、GAD-7、SDS、SAS），不含题目
+// @Tags 管理员/用户
+// @Produce json
+// @Router /scale/list [get]
+func (con ScaleController) ListScales(c *gin.Context) {
+	scaleService := service.NewScaleService()
+	list, err := scaleService.ListScales()
+	if err != nil {
+		con.Error(c, nil, "查询量表列表失败")
+		return
+	}
+	con.Success(c, list)
+}
+
+// GetScale 查询量表定义
+// @Summary 查询量表的完整定义
+// @Description 根据 code 查询量表的指导语、题目、选项、分量表、计分公式和界值
+// @Tags 管理员/用户
+// @Produce json
+// @Router /scale [get]
+func (con ScaleController) GetScale(c *gin.Context) {
+	scaleService := service.NewScaleService()
+	scale, err := scaleService.GetScale(c.Query("code"))
+	if err != nil {
+		con.Error(c, nil, err.Error())
+		return
+	}
+	con.Success(c, scale)
+}
+
+// CreateScale 新建量表
+// @Summary 新建量表
+// @Description 以数据定义新的量表：题目、选项、分量表、计分公式、反向计分题、界值和单题预警；仅管理员可操作，否则返回 403
+// @Tags 管理员
+// @Accept json
+// @Produce json
+// @Router /scale [post]
+func (con ScaleController) CreateScale(c *gin.Context) {
+	operator, err := currentDataScope(c)
+	if err != nil {
+		con.Error(c, nil, err.Error())
+		return
+	}
+
+	var form struct {
+		Code        string          `json:"code"`
+		Name        string          `json:"name"`
+		Description string          `json:"description"`
+		Definition  json.RawMessage `json:"definition"` // 量表定义（JSON 对象）
+	}
+	if err := c.ShouldBindJSON(&form); err != nil {
+		con.Error(c, nil, "参数格式错误: "+err.Error())
+		return
+	}
+
+	scale := &models.Scale{
+		Code:        form.Code,
+		Name:        form.Name,
+		Description: form.Description,
+		Definition:  string(form.Definition),
+		CreatedBy:   operator.UserID,
+	}
+	scaleService := service.NewScaleService()
+	if err := scaleService.CreateScale(scale, operator); err != nil {
+		permissionError(c, err, "")
+		return
+	}
+	con.Success(c, scale)
+}
+
+// Submit 提交量表作答
+// @Summary 提交量表作答
+// @Description 按题号顺序提交作答分值，服务端计分并保存；SCL-90 需同时提交姓名、性别、年龄，结果存入 scl 表
+// @Tags 管理员/用户
+// @Accept json
+// @Produce json
+// @Router /scale/submit [post]
+func (con ScaleController) Submit(c *gin.Context) {
+	id, _ := c.Get("id")
+	userId, ok := id.(int64)
+	if !ok {
+		con.Error(c, nil, "无效的用户id")
+		return
+	}
+
+	var submission vo.ScaleSubmission
+	if err := c.ShouldBindJSON(&submission); err != nil {
+		con.Error(c, nil, "参数格式错误: "+err.Error())
+		return
+	}
+	submission.StudentID = &userId
+
+	scaleService := service.NewScaleService()
+	result, err := scaleService.Submit(&submission)
+	if err != nil {
+		con.Error(c, nil, err.Error())
+		return
+	}
+	con.Success(c, result)
+}
+
+// ListResults 查询当前用户的量表结果
+// @Summary 查询当前用户的量表结果
+// @Description 按测评日期倒序查询当前用户的量表结果，code 为空时包含全部量表（含 SCL-90 记录）
+// @Tags 管理员/用户
+// @Produce json
+// @Router /scale/result [get]
+func (con ScaleController) ListResults(c *gin.Context) {
+	scope, err := currentDataScope(c)
+	if err != nil {
+		con.Error(c, nil, err.Error())
+		return
+	}
+	scaleService := service.NewScaleService()
+	results, err := scaleService.ListResults(c.Query("code"), &scope.UserID, scope)
+	if err != nil {
+		con.Error(c, nil, err.Error())
+		return
+	}
+	con.Success(c, results)
+}
+
+// ListAllResults 查询所有用户的量表结果
+// @Summary 查询所有用户的量表结果
+// @Description 按测评日期倒序查询当前用户数据范围内的量表结果，可通过 code 指定量表，student_id 指定学生
+// @Tags 管理员
+// @Produce json
+// @Router /scale/result/all [get]
+func (con ScaleController) ListAllResults(c *gin.Context) {
+	var studentId *int64
+	if idStr := c.Query("student_id"); idStr != "" {
+		id, err := strconv.ParseInt(idStr, 10, 64)
+		if err != nil {
+			con.Error(c, nil, "无效的学生id")
+			return
+		}
+		studentId = &id
+	}
+	scope, err := currentDataScope(c)
+	if err != nil {
+		con.Error(c, nil, err.Error())
+		return
+	}
+	scaleService := service.NewScaleService()
+	results, err := scaleService.ListResults(c.Query("code"), studentId, scope)
+	if err != nil {
+		con.Error(c, nil, err.Error())
+		return
+	}
+	con.Success(c, results)
+}
@@ -1,11 +1,18 @@
 package user
 
 import (
+	"errors"
+	"fmt"
 	"github.com/gin-gonic/gin"
 	"mental/controllers/common"
 	"mental/models"
 	"mental/service"
+	"mental/vo"
+	"net/http"
+	"sort"
 	"strconv"
+	"strings"
+	"time"
 )
 
 // SCLController 处理 SCL 相关请求
@@ -13,6 +20,111 @@ type SCLController struct {
 	common.BaseController
 }
 
+// accessError 返回访问测评记录的错误：记录不存在为 404，无权操作为 403，其余按业务错误返回
+func (con SCLController) accessError(c *gin.Context, err error, prefix string) {
+	switch {
+	case errors.Is(err, service.ErrSCLNotFound):
+		con.ErrorWithStatus(c, http.StatusNotFound, nil, err.Error())
+	case errors.Is(err, service.ErrSCLForbidden):
+		con.ErrorWithStatus(c, http.StatusForbidden, nil, err.Error())
+	default:
+		con.Error(c, nil, prefix+err.Error())
+	}
+}
+
+// parseSCLQuery 解析测评记录的筛选、排序和分页参数：name 为姓名（模糊匹配），gender 为性别，age_min/age_max 为年龄范围，
+// from/to 为测评日期范围（YYYY-MM-DD，含两端），org_id 为组织，level 为总体等级，min_<指标>/max_<指标> 为指标取值范围，
+// sort/order 为排序字段和方向，page/page_size 为页码和每页条数
+func parseSCLQuery(c *gin.Context) (vo.SCLQuery, error) {
+	var query vo.SCLQuery
+	var err error
+	if query.OrgID, err = strconv.ParseInt(c.DefaultQuery("org_id", "0"), 10, 64); err != nil {
+		return query, errors.New("无效的组织id")
+	}
+	query.Name = c.Query("name")
+	if query.Gender, err = optionalInt(c, "gender", "无效的性别"); err != nil {
+		return query, err
+	}
+	if query.AgeMin, err = optionalInt(c, "age_min", "无效的年龄下限"); err != nil {
+		return query, err
+	}
+	if query.AgeMax, err = optionalInt(c, "age_max", "无效的年龄上限"); err != nil {
+		return query, err
+	}
+	if fromStr := c.Query("from"); fromStr != "" {
+		from, err := time.ParseInLocation("2006-01-02", fromStr, time.Local)
+		if err != nil {
+			return query, errors.New("无效的开始日期")
+		}
+		query.From = &from
+	}
+	if toStr := c.Query("to"); toStr != "" {
+		to, err := time.ParseInLocation("2006-01-02", toStr, time.Local)
+		if err != nil {
+			return query, errors.New("无效的结束日期")
+		}
+		to = to.AddDate(0, 0, 1)
+		query.To = &to
+	}
+	query.Level = c.Query("level")
+	// 指标名由服务层按白名单校验，按参数名排序保证条件顺序稳定
+	params := c.Request.URL.Query()
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	thresholds := make(map[string]int) // 指标在 query.Thresholds 中的下标
+	for _, param := range keys {
+		var key string
+		switch {
+		case strings.HasPrefix(param, "min_"):
+			key = strings.TrimPrefix(param, "min_")
+		case strings.HasPrefix(param, "max_"):
+			key = strings.TrimPrefix(param, "max_")
+		default:
+			continue
+		}
+		value, err := strconv.ParseFloat(params.Get(param), 64)
+		if err != nil {
+			return query, fmt.Errorf("无效的 %s", param)
+		}
+		index, ok := thresholds[key]
+		if !ok {
+			index = len(query.Thresholds)
+			thresholds[key] = index
+			query.Thresholds = append(query.Thresholds, vo.SCLThreshold{Key: key})
+		}
+		if strings.HasPrefix(param, "min_") {
+			query.Thresholds[index].Min = &value
+		} else {
+			query.Thresholds[index].Max = &value
+		}
+	}
+	query.Sort = c.Query("sort")
+	query.Order = c.Query("order")
+	if query.Page, err = strconv.Atoi(c.DefaultQuery("page", "1")); err != nil {
+		return query, errors.New("无效的页码")
+	}
+	if query.PageSize, err = strconv.Atoi(c.DefaultQuery("page_size", "20")); err != nil {
+		return query, errors.New("无效的每页条数")
+	}
+	return query, nil
+}
+
+// optionalInt 解析可选的整数参数，未传时返回 nil
+func optionalInt(c *gin.Context, key string, message string) (*int, error) {
+	str := c.Query(key)
+	if str == "" {
+		return nil, nil
+	}
+	value, err := strconv.Atoi(str)
+	if err != nil {
+		return nil, errors.New(message)
+	}
+	return &value, nil
+}
+
 // InsertSCL 处理用户提交 SCL 记录
 // @Summary 处理用户提交 SCL 记录，插入scl到数据库
 // @Description 插入单个scl记录接口
@@ -52,7 +164,7 @@ func (con SCLController) InsertSCL(c *gin.Context) {
 
 // SelectAllByUserId 根据用户id查询用户所有历史评测记录
 // @Summary 根据当前用户id，查询该用户所有的历史记录数据
-// @Description 查询当前用户的历史评测记录列表
+// @Description 分页查询当前用户的历史评测记录列表，可通过 norm 参数指定比较的常模组编码；支持与 /scl/all 相同的筛选（from/to、level、min_<指标>/max_<指标> 等）、排序（sort/order）和分页（page/page_size，默认每页 20 条，最多 100 条）参数，返回 total 总数；整体心理状态按全部记录计算，无效作答的记录默认不计入，include_invalid=true 时计入
 // @Tags 管理员/用户
 // @Produce json
 // @Router /scl [get]
@@ -63,8 +175,13 @@ func (con SCLController) SelectAllByUserId(c *gin.Context) {
 		con.Error(c, nil, "无效的用户id")
 		return
 	}
+	query, err := parseSCLQuery(c)
+	if err != nil {
+		con.Error(c, nil, err.Error())
+		return
+	}
 	sclService := service.NewSCLService()
-	scls, err := sclService.SelectAllByUserId(userId)
+	scls, err := sclService.SelectAllByUserId(userId, query, c.Query("norm"), c.Query("include_invalid") == "true")
 	if err != nil {
 		con.Error(c, nil, err.Error())
 		return
@@ -72,17 +189,64 @@ func (con SCLController) SelectAllByUserId(c *gin.Context) {
 	con.Success(c, scls)
 }
 
+// SelectTrend 查询学生历次测评的变化趋势
+// @Summary 查询学生历次测评的纵向趋势
+// @Description 返回各因子按测评日期排列的时间序列、斜率与趋势方向、相邻两次测评的可靠变化指数（RCI）及有临床意义的恶化标记；student_id 为空时查询当前用户，可通过 norm 参数指定取标准差的常模组编码
+// @Tags 管理员/用户
+// @Produce json
+// @Router /scl/trend [get]
+func (con SCLController) SelectTrend(c *gin.Context) {
+	id, _ := c.Get("id")
+	studentId, ok := id.(int64)
+	if !ok {
+		con.Error(c, nil, "无效的用户id")
+		return
+	}
+	if idStr := c.Query("student_id"); idStr != "" {
+		var err error
+		studentId, err = strconv.ParseInt(idStr, 10, 64)
+		if err != nil {
+			con.Error(c, nil, "无效的学生id")
+			return
+		}
+	}
+
+	scope, err := currentDataScope(c)
+	if err != nil {
+		con.Error(c, nil, err.Error())
+		return
+	}
+
+	sclService := service.NewSCLService()
+	trend, err := sclService.Trend(studentId, c.Query("norm"), scope)
+	if err != nil {
+		con.Error(c, nil, err.Error())
+		return
+	}
+	con.Success(c, trend)
+}
+
 // SelectSCLs 查询所有的scl记录数据
 // @Summary 查询所有用户的scl记录数据
-// @Description 查询所有用户的历史评测记录列表
+// @Description 分页查询当前用户数据范围内学生的历史评测记录列表，可通过 norm 参数指定比较的常模组编码；筛选参数：name（姓名模糊匹配）、gender（0女 1男）、age_min/age_max、from/to（测评日期 YYYY-MM-DD，含两端）、org_id（组织，含下级）、level（normal/mild/moderate/severe）、min_<指标>/max_<指标>（指标为 total_score、positive_items、gsi 或因子字段名，含两端）；sort 可选 id、test_date（默认）、name、age、total_score、positive_items、gsi、psdi、level 或因子字段名，order 为 asc/desc（默认 desc）；page 从 1 开始，page_size 默认 20、最多 100，返回 total 总数
 // @Tags 管理员
 // @Produce json
 // @Router /scl/all [get]
 func (con SCLController) SelectSCLs(c *gin.Context) {
+	query, err := parseSCLQuery(c)
+	if err != nil {
+		con.Error(c, nil, err.Error())
+		return
+	}
+	scope, err := currentDataScope(c)
+	if err != nil {
+		con.Error(c, nil, err.Error())
+		return
+	}
 	sclService := service.NewSCLService()
-	scls, err := sclService.SelectAll()
+	scls, err := sclService.SelectAll(query, c.Query("norm"), scope)
 	if err != nil {
-		con.Error(c, nil, "查询所有数据记录失败")
+		con.Error(c, nil, "查询所有数据记录失败: "+err.Error())
 		return
 	}
 	con.Success(c, scls)
@@ -90,7 +254,7 @@ func (con SCLController) SelectSCLs(c *gin.Context) {
 
 // DeleteSCL 删除指定的SCL数据
 // @Summary 删除指定id的SCL数据
-// @Description 删除指定id的SCL数据
+// @Description 删除指定id的SCL数据，只能删除本人或当前用户数据范围内的记录；记录不存在返回 404，无权删除返回 403
 // @Tags 管理员
 // @Produce json
 // @Router /scl [delete]
@@ -102,18 +266,53 @@ func (con SCLController) DeleteSCL(c *gin.Context) {
 		return
 	}
 
-	sclService := service.NewSCLService()
-	err = sclService.DeleteSCL(sclId)
+	scope, err := currentDataScope(c)
 	if err != nil {
 		con.Error(c, nil, err.Error())
 		return
 	}
+
+	sclService := service.NewSCLService()
+	err = sclService.DeleteSCL(sclId, scope)
+	if err != nil {
+		con.accessError(c, err, "")
+		return
+	}
 	con.Success(c, nil)
 }
 
+// SelectAnswers 查询指定SCL记录的逐题原始作答
+// @Summary 查询指定id的SCL记录的逐题作答
+// @Description 查询指定id的SCL记录的90道题原始作答，用于审核与重新计分；记录不存在返回 404，无权查看返回 403
+// @Tags 管理员
+// @Produce json
+// @Router /scl/answers [get]
+func (con SCLController) SelectAnswers(c *gin.Context) {
+	idStr := c.Query("id")
+	sclId, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		con.Error(c, nil, "无效的SCL记录id")
+		return
+	}
+
+	scope, err := currentDataScope(c)
+	if err != nil {
+		con.Error(c, nil, err.Error())
+		return
+	}
+
+	sclService := service.NewSCLService()
+	answers, err := sclService.SelectAnswers(sclId, scope)
+	if err != nil {
+		con.accessError(c, err, "查询作答记录失败: ")
+		return
+	}
+	con.Success(c, answers)
+}
+
 // UpdateSCL 编辑指定的scl数据
 // @Summary 编辑指定的scl数据
-// @Description 编辑指定的scl数据
+// @Description 编辑指定的scl数据，只能编辑本人或当前用户数据范围内的记录，所属学生（student_id）不可修改；提交 items 时替换原有逐题作答，只提交因子分时删除原有作答并清除有效性标记；记录不存在返回 404，无权修改返回 403
 // @Tags 管理员
 // @Produce json
 // @Router /scl/update [post]
@@ -123,12 +322,50 @@ func (con SCLController) UpdateSCL(c *gin.Context) {
 		con.Error(c, nil, "参数绑定失败")
 		return
 	}
+	scope, err := currentDataScope(c)
+	if err != nil {
+		con.Error(c, nil, err.Error())
+		return
+	}
 	sclService := service.NewSCLService()
-	err := sclService.UpdateSCL(&scl)
+	err = sclService.UpdateSCL(&scl, scope)
 	if err != nil {
-		con.Error(c, nil, "更新数据失败")
+		con.accessError(c, err, "更新数据失败: ")
 		return
 	}
 	con.Success(c, nil)
 
 }
+
+// Export 导出测评记录
+// @Summary 导出SCL测评记录为Excel或CSV
+// @Description 导出当前用户数据范围内的测评记录，format 为 xlsx（默认）或 csv；筛选参数与 /scl/all 相同，排序和分页参数不生效；前 15 列与 Excel 导入格式一致，其后为总分、阳性项目数、健康状况等计算列；按记录ID顺序分批读取并流式写出
+// @Tags 管理员
+// @Produce application/octet-stream
+// @Router /scl/export [get]
+func (con SCLController) Export(c *gin.Context) {
+	query, err := parseSCLQuery(c)
+	if err != nil {
+		con.Error(c, nil, err.Error())
+		return
+	}
+	scope, err := currentDataScope(c)
+	if err != nil {
+		con.Error(c, nil, err.Error())
+		return
+	}
+	sclService := service.NewSCLService()
+	export, err := sclService.NewExport(query, c.Query("format"), scope)
+	if err != nil {
+		con.Error(c, nil, err.Error())
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", export.FileName()))
+	c.Header("Content-Type", export.ContentType())
+	c.Status(http.StatusOK)
+	if err := export.Write(c.Writer); err != nil {
+		// 文件已开始写出，无法再返回错误信息，中断响应
+		fmt.Printf("导出SCL数据失败: %v\n", err)
+		c.Abort()
+	}
+}
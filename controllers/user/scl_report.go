@@ -0,0 +1,89 @@
+package user
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"mental/service"
+	"net/http"
+	"strconv"
+)
+
+// sendReport 返回生成的 PDF 报告：store=true 时保存到 MinIO 并返回下载链接，否则直接下载 PDF
+func (con SCLController) sendReport(c *gin.Context, data []byte, fileName string) {
+	if c.Query("store") != "true" {
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", fileName))
+		c.Data(http.StatusOK, "application/pdf", data)
+		return
+	}
+	reportService := service.NewReportService()
+	url, err := reportService.Store(data)
+	if err != nil {
+		con.Error(c, nil, "保存报告失败: "+err.Error())
+		return
+	}
+	con.Success(c, gin.H{
+		"file_name": fileName,
+		"url":       url,
+	})
+}
+
+// SCLReport 生成单条测评记录的 PDF 报告
+// @Summary 生成指定SCL记录的PDF测评报告
+// @Description 报告包含基本信息、因子剖面图、常模比较（norm 指定常模组编码，为空时使用默认常模）、结果解释和该学生的历次测评；默认直接下载 PDF，store=true 时保存到 MinIO 并返回下载链接；记录不存在返回 404，无权查看返回 403
+// @Tags 管理员
+// @Produce application/pdf
+// @Router /scl/report [get]
+func (con SCLController) SCLReport(c *gin.Context) {
+	sclId, err := strconv.ParseInt(c.Query("id"), 10, 64)
+	if err != nil {
+		con.Error(c, nil, "无效的SCL记录id")
+		return
+	}
+	scope, err := currentDataScope(c)
+	if err != nil {
+		con.Error(c, nil, err.Error())
+		return
+	}
+	reportService := service.NewReportService()
+	data, fileName, err := reportService.SCLReport(sclId, c.Query("norm"), scope)
+	if err != nil {
+		con.accessError(c, err, "生成报告失败: ")
+		return
+	}
+	con.sendReport(c, data, fileName)
+}
+
+// StudentReport 生成学生的 PDF 报告
+// @Summary 生成学生的PDF测评报告
+// @Description 以学生最近一次有效测评为主生成报告（没有有效测评时取最近一次），附历次测评表；student_id 为空时生成当前用户的报告，只包含当前用户数据范围内的记录；默认直接下载 PDF，store=true 时保存到 MinIO 并返回下载链接
+// @Tags 管理员/用户
+// @Produce application/pdf
+// @Router /scl/report/student [get]
+func (con SCLController) StudentReport(c *gin.Context) {
+	id, _ := c.Get("id")
+	studentId, ok := id.(int64)
+	if !ok {
+		con.Error(c, nil, "无效的用户id")
+		return
+	}
+	if idStr := c.Query("student_id"); idStr != "" {
+		var err error
+		studentId, err = strconv.ParseInt(idStr, 10, 64)
+		if err != nil {
+			con.Error(c, nil, "无效的学生id")
+			return
+		}
+	}
+	scope, err := currentDataScope(c)
+	if err != nil {
+		con.Error(c, nil, err.Error())
+		return
+	}
+	reportService := service.NewReportService()
+	data, fileName, err := reportService.StudentReport(studentId, c.Query("norm"), scope)
+	if err != nil {
+		con.Error(c, nil, "生成报告失败: "+err.Error())
+		return
+	}
+	con.sendReport(c, data, fileName)
+}
@@ -0,0 +1,135 @@
+package user
+
+import (
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"mental/controllers/common"
+	"mental/models"
+	"mental/service"
+	"strconv"
+)
+
+// SCLRuleController 处理结果解释规则集相关请求
+type SCLRuleController struct {
+	common.BaseController
+}
+
+// CreateRuleSet 新建规则集
+// @Summary 新建SCL结果解释规则集
+// @Description 新建规则集，版本号在组织内自动递增，新建后需单独启用；仅管理员可操作，否则返回 403
+// @Tags 管理员
+// @Accept json
+// @Produce json
+// @Router /scl/rule [post]
+func (con SCLRuleController) CreateRuleSet(c *gin.Context) {
+	operator, err := currentDataScope(c)
+	if err != nil {
+		con.Error(c, nil, err.Error())
+		return
+	}
+
+	var form struct {
+		Name    string          `json:"name"`
+		OrgID   int64           `json:"org_id"`
+		Content json.RawMessage `json:"content"` // 规则内容（JSON 对象）
+		Remark  string          `json:"remark"`
+	}
+	if err := c.ShouldBindJSON(&form); err != nil {
+		con.Error(c, nil, "参数格式错误: "+err.Error())
+		return
+	}
+
+	ruleSet := &models.SCLRuleSet{
+		Name:      form.Name,
+		OrgID:     form.OrgID,
+		Content:   string(form.Content),
+		Remark:    form.Remark,
+		CreatedBy: operator.UserID,
+	}
+	ruleService := service.NewSCLRuleService()
+	if err := ruleService.CreateRuleSet(ruleSet, operator); err != nil {
+		permissionError(c, err, "")
+		return
+	}
+	con.Success(c, ruleSet)
+}
+
+// ListRuleSets 查询规则集列表
+// @Summary 查询SCL结果解释规则集列表
+// @Description 按版本倒序查询指定组织的规则集，org_id 为空时查询全局规则集；仅管理员或该组织（含上级）负责人可查询，全局规则集仅管理员可查询，否则返回 403
+// @Tags 管理员
+// @Produce json
+// @Router /scl/rule/list [get]
+func (con SCLRuleController) ListRuleSets(c *gin.Context) {
+	orgId, _ := strconv.ParseInt(c.DefaultQuery("org_id", "0"), 10, 64)
+	operator, err := currentDataScope(c)
+	if err != nil {
+		con.Error(c, nil, err.Error())
+		return
+	}
+	ruleService := service.NewSCLRuleService()
+	list, err := ruleService.ListRuleSets(orgId, operator)
+	if err != nil {
+		permissionError(c, err, "查询规则集失败: ")
+		return
+	}
+	con.Success(c, list)
+}
+
+// PreviewRuleSet 规则集试算
+// @Summary 使用规则集对样例数据试算
+// @Description 使用已保存的规则集或未保存的规则内容，对样例数据及数据范围内的已有记录试算结论，不落库；仅管理员可操作，否则返回 403
+// @Tags 管理员
+// @Accept json
+// @Produce json
+// @Router /scl/rule/preview [post]
+func (con SCLRuleController) PreviewRuleSet(c *gin.Context) {
+	var form struct {
+		RuleSetID int64           `json:"rule_set_id"` // 已保存的规则集ID
+		Content   json.RawMessage `json:"content"`     // 未保存的规则内容，优先于 rule_set_id
+		Samples   []models.SCL    `json:"samples"`     // 样例数据（因子分或逐题作答）
+		SCLIDs    []int64         `json:"scl_ids"`     // 已有测评记录ID
+	}
+	if err := c.ShouldBindJSON(&form); err != nil {
+		con.Error(c, nil, "参数格式错误: "+err.Error())
+		return
+	}
+	operator, err := currentDataScope(c)
+	if err != nil {
+		con.Error(c, nil, err.Error())
+		return
+	}
+
+	ruleService := service.NewSCLRuleService()
+	results, err := ruleService.PreviewRuleSet(operator, form.RuleSetID, string(form.Content), form.Samples, form.SCLIDs)
+	if err != nil {
+		permissionError(c, err, "")
+		return
+	}
+	con.Success(c, results)
+}
+
+// ActivateRuleSet 启用规则集
+// @Summary 启用指定id的SCL结果解释规则集
+// @Description 启用规则集，同一组织下其余规则集自动停用；仅管理员可操作，否则返回 403
+// @Tags 管理员
+// @Produce json
+// @Router /scl/rule/activate [post]
+func (con SCLRuleController) ActivateRuleSet(c *gin.Context) {
+	ruleSetId, err := strconv.ParseInt(c.Query("id"), 10, 64)
+	if err != nil {
+		con.Error(c, nil, "无效的规则集id")
+		return
+	}
+	operator, err := currentDataScope(c)
+	if err != nil {
+		con.Error(c, nil, err.Error())
+		return
+	}
+	ruleService := service.NewSCLRuleService()
+	if err := ruleService.ActivateRuleSet(ruleSetId, operator); err != nil {
+		permissionError(c, err, "")
+		return
+	}
+	con.Success(c, nil)
+}
@@ -0,0 +1,138 @@
+package user
+
+import (
+	"github.com/gin-gonic/gin"
+	"mental/controllers/common"
+	"mental/service"
+	"mental/vo"
+	"strconv"
+)
+
+// SessionController 处理在线测评会话相关请求
+type SessionController struct {
+	common.BaseController
+}
+
+// Start 开始在线测评
+// @Summary 开始或恢复在线测评
+// @Description 为指定量表开始在线测评，同一量表有未超时的会话时直接恢复；SCL-90 需提交姓名、性别、年龄
+// @Tags 管理员/用户
+// @Accept json
+// @Produce json
+// @Router /scale/session/start [post]
+func (con SessionController) Start(c *gin.Context) {
+	id, _ := c.Get("id")
+	userId, ok := id.(int64)
+	if !ok {
+		con.Error(c, nil, "无效的用户id")
+		return
+	}
+	var req vo.SessionStartRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		con.Error(c, nil, "参数格式错误: "+err.Error())
+		return
+	}
+	sessionService := service.NewSessionService()
+	session, err := sessionService.Start(userId, &req)
+	if err != nil {
+		con.Error(c, nil, err.Error())
+		return
+	}
+	con.Success(c, session)
+}
+
+// Get 查询在线测评进度
+// @Summary 查询在线测评进度
+// @Description 查询会话的已作答题数、下一道未作答题号和剩余时间
+// @Tags 管理员/用户
+// @Produce json
+// @Router /scale/session [get]
+func (con SessionController) Get(c *gin.Context) {
+	id, _ := c.Get("id")
+	userId, ok := id.(int64)
+	if !ok {
+		con.Error(c, nil, "无效的用户id")
+		return
+	}
+	sessionService := service.NewSessionService()
+	session, err := sessionService.Get(userId, c.Query("id"))
+	if err != nil {
+		con.Error(c, nil, err.Error())
+		return
+	}
+	con.Success(c, session)
+}
+
+// Items 分页获取题目
+// @Summary 分页获取在线测评题目
+// @Description 按 page、size 分页返回题目、选项和已保存的作答
+// @Tags 管理员/用户
+// @Produce json
+// @Router /scale/session/items [get]
+func (con SessionController) Items(c *gin.Context) {
+	id, _ := c.Get("id")
+	userId, ok := id.(int64)
+	if !ok {
+		con.Error(c, nil, "无效的用户id")
+		return
+	}
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	size, _ := strconv.Atoi(c.DefaultQuery("size", "10"))
+	sessionService := service.NewSessionService()
+	items, err := sessionService.Items(userId, c.Query("id"), page, size)
+	if err != nil {
+		con.Error(c, nil, err.Error())
+		return
+	}
+	con.Success(c, items)
+}
+
+// Save 自动保存作答
+// @Summary 自动保存在线测评作答
+// @Description 提交题号到作答分值的映射，只需包含本次变更的题目
+// @Tags 管理员/用户
+// @Accept json
+// @Produce json
+// @Router /scale/session/save [post]
+func (con SessionController) Save(c *gin.Context) {
+	id, _ := c.Get("id")
+	userId, ok := id.(int64)
+	if !ok {
+		con.Error(c, nil, "无效的用户id")
+		return
+	}
+	var req vo.SessionSaveRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		con.Error(c, nil, "参数格式错误: "+err.Error())
+		return
+	}
+	sessionService := service.NewSessionService()
+	session, err := sessionService.Save(userId, &req)
+	if err != nil {
+		con.Error(c, nil, err.Error())
+		return
+	}
+	con.Success(c, session)
+}
+
+// Submit 提交在线测评
+// @Summary 提交在线测评
+// @Description 全部题目作答后提交，服务端计分并保存；SCL-90 生成 SCL 记录
+// @Tags 管理员/用户
+// @Produce json
+// @Router /scale/session/submit [post]
+func (con SessionController) Submit(c *gin.Context) {
+	id, _ := c.Get("id")
+	userId, ok := id.(int64)
+	if !ok {
+		con.Error(c, nil, "无效的用户id")
+		return
+	}
+	sessionService := service.NewSessionService()
+	result, err := sessionService.Submit(userId, c.Query("id"))
+	if err != nil {
+		con.Error(c, nil, err.Error())
+		return
+	}
+	con.Success(c, result)
+}
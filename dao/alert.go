@@ -0,0 +1,160 @@
+package dao
+
+import (
+	"errors"
+	"gorm.io/gorm"
+	"mental/models"
+	"time"
+)
+
+// AlertDao 负责操作 alert、alert_note、alert_rule 表（高风险预警）
+// 设置了数据范围时，预警的查询只作用于范围内学生的预警
+type AlertDao struct {
+	DB    *gorm.DB
+	Scope *DataScope
+}
+
+// NewAlertDao 创建 AlertDao 实例
+func NewAlertDao(db *gorm.DB) *AlertDao {
+	return &AlertDao{DB: db}
+}
+
+// WithScope 返回按数据范围过滤的 AlertDao，scope 为 nil 时不过滤
+func (dao *AlertDao) WithScope(scope *DataScope) *AlertDao {
+	return &AlertDao{DB: dao.DB, Scope: scope}
+}
+
+// scoped 返回追加了数据范围条件的预警查询
+func (dao *AlertDao) scoped() *gorm.DB {
+	return dao.Scope.Apply(dao.DB.Model(&models.Alert{}), "alert.student_id")
+}
+
+// AlertFilter 预警列表筛选条件，零值表示不限
+type AlertFilter struct {
+	Statuses   []string   // 状态
+	Level      string     // 预警等级
+	StudentID  *int64     // 学生ID
+	AssigneeID *int64     // 负责人ID
+	From       *time.Time // 创建时间下限（含）
+	To         *time.Time // 创建时间上限（不含）
+}
+
+// Save 插入一条预警
+func (dao *AlertDao) Save(alert *models.Alert) error {
+	return dao.DB.Create(alert).Error
+}
+
+// FindByID 根据 ID 查询（数据范围内的）预警，不存在时返回 nil
+func (dao *AlertDao) FindByID(id int64) (*models.Alert, error) {
+	var alert models.Alert
+	err := dao.scoped().First(&alert, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &alert, nil
+}
+
+// FindOpenBySCLID 查询测评记录未关闭的预警，不存在时返回 nil
+func (dao *AlertDao) FindOpenBySCLID(sclId int64) (*models.Alert, error) {
+	var alert models.Alert
+	err := dao.DB.Where("scl_id = ? AND status <> ?", sclId, models.AlertStatusClosed).First(&alert).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &alert, nil
+}
+
+// ListOpenBySCLIDs 查询一组测评记录未关闭的预警
+func (dao *AlertDao) ListOpenBySCLIDs(sclIds []int64) ([]models.Alert, error) {
+	var list []models.Alert
+	if len(sclIds) == 0 {
+		return list, nil
+	}
+	if err := dao.DB.Where("scl_id IN ? AND status <> ?", sclIds, models.AlertStatusClosed).Order("id asc").Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// Update 更新预警的全部字段
+func (dao *AlertDao) Update(alert *models.Alert) error {
+	return dao.DB.Save(alert).Error
+}
+
+// List 按筛选条件查询（数据范围内的）预警，按创建时间倒序
+func (dao *AlertDao) List(filter AlertFilter) ([]models.Alert, error) {
+	var list []models.Alert
+	query := dao.scoped()
+	if len(filter.Statuses) > 0 {
+		query = query.Where("status IN ?", filter.Statuses)
+	}
+	if filter.Level != "" {
+		query = query.Where("level = ?", filter.Level)
+	}
+	if filter.StudentID != nil {
+		query = query.Where("student_id = ?", *filter.StudentID)
+	}
+	if filter.AssigneeID != nil {
+		query = query.Where("assignee_id = ?", *filter.AssigneeID)
+	}
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("created_at < ?", *filter.To)
+	}
+	if err := query.Order("created_at desc").Order("id desc").Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// SaveNote 插入一条处理备注
+func (dao *AlertDao) SaveNote(note *models.AlertNote) error {
+	return dao.DB.Create(note).Error
+}
+
+// ListNotes 查询预警的全部处理备注，按时间正序
+func (dao *AlertDao) ListNotes(alertId int64) ([]models.AlertNote, error) {
+	var list []models.AlertNote
+	if err := dao.DB.Where("alert_id = ?", alertId).Order("id asc").Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// SaveRule 插入一条预警规则
+func (dao *AlertDao) SaveRule(rule *models.AlertRule) error {
+	return dao.DB.Create(rule).Error
+}
+
+// ListRules 查询预警规则，enabledOnly 为 true 时只返回启用的规则
+func (dao *AlertDao) ListRules(enabledOnly bool) ([]models.AlertRule, error) {
+	var list []models.AlertRule
+	query := dao.DB.Model(&models.AlertRule{})
+	if enabledOnly {
+		query = query.Where("enabled = ?", true)
+	}
+	if err := query.Order("id asc").Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// SetRuleEnabled 启用或停用预警规则
+func (dao *AlertDao) SetRuleEnabled(id int64, enabled bool) error {
+	var count int64
+	if err := dao.DB.Model(&models.AlertRule{}).Where("id = ?", id).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 { // 值未变化时 RowsAffected 为 0，需单独判断是否存在
+		return gorm.ErrRecordNotFound
+	}
+	return dao.DB.Model(&models.AlertRule{}).Where("id = ?", id).Update("enabled", enabled).Error
+}
@@ -0,0 +1,163 @@
+package dao
+
+import (
+	"errors"
+	"gorm.io/gorm"
+	"mental/models"
+	"time"
+)
+
+// AppointmentDao 负责操作 appointment_slot、appointment 表（心理咨询预约）
+type AppointmentDao struct {
+	DB *gorm.DB
+}
+
+// NewAppointmentDao 创建 AppointmentDao 实例
+func NewAppointmentDao(db *gorm.DB) *AppointmentDao {
+	return &AppointmentDao{DB: db}
+}
+
+// SlotFilter 咨询时段筛选条件，零值表示不限
+type SlotFilter struct {
+	CounselorID *int64    // 辅导员ID
+	Statuses    []string  // 状态
+	From        time.Time // 开始时间下限（含）
+	To          time.Time // 开始时间上限（不含）
+}
+
+// SaveSlots 批量插入咨询时段
+func (dao *AppointmentDao) SaveSlots(slots []models.AppointmentSlot) error {
+	if len(slots) == 0 {
+		return nil
+	}
+	return dao.DB.Create(&slots).Error
+}
+
+// FindSlotByID 根据 ID 查询咨询时段，不存在时返回 nil
+func (dao *AppointmentDao) FindSlotByID(id int64) (*models.AppointmentSlot, error) {
+	var slot models.AppointmentSlot
+	err := dao.DB.First(&slot, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &slot, nil
+}
+
+// UpdateSlotStatus 在时段当前状态为 from 时将其变更为 to，返回是否变更成功
+func (dao *AppointmentDao) UpdateSlotStatus(id int64, from string, to string) (bool, error) {
+	res := dao.DB.Model(&models.AppointmentSlot{}).Where("id = ? AND status = ?", id, from).Update("status", to)
+	return res.RowsAffected > 0, res.Error
+}
+
+// CountOverlappingSlots 统计辅导员在 [start, end) 内未关闭的时段数量
+func (dao *AppointmentDao) CountOverlappingSlots(counselorId int64, start time.Time, end time.Time) (int64, error) {
+	var count int64
+	err := dao.DB.Model(&models.AppointmentSlot{}).
+		Where("counselor_id = ? AND status <> ? AND start_at < ? AND end_at > ?", counselorId, models.SlotStatusClosed, end, start).
+		Count(&count).Error
+	return count, err
+}
+
+// ListSlots 按筛选条件查询咨询时段，按开始时间正序
+func (dao *AppointmentDao) ListSlots(filter SlotFilter) ([]models.AppointmentSlot, error) {
+	var list []models.AppointmentSlot
+	query := dao.DB.Model(&models.AppointmentSlot{})
+	if filter.CounselorID != nil {
+		query = query.Where("counselor_id = ?", *filter.CounselorID)
+	}
+	if len(filter.Statuses) > 0 {
+		query = query.Where("status IN ?", filter.Statuses)
+	}
+	if !filter.From.IsZero() {
+		query = query.Where("start_at >= ?", filter.From)
+	}
+	if !filter.To.IsZero() {
+		query = query.Where("start_at < ?", filter.To)
+	}
+	if err := query.Order("start_at asc").Order("id asc").Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// SaveAppointment 插入一条预约
+func (dao *AppointmentDao) SaveAppointment(appointment *models.Appointment) error {
+	return dao.DB.Create(appointment).Error
+}
+
+// FindAppointmentByID 根据 ID 查询预约，不存在时返回 nil
+func (dao *AppointmentDao) FindAppointmentByID(id int64) (*models.Appointment, error) {
+	var appointment models.Appointment
+	err := dao.DB.First(&appointment, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &appointment, nil
+}
+
+// UpdateAppointment 更新预约的全部字段
+func (dao *AppointmentDao) UpdateAppointment(appointment *models.Appointment) error {
+	return dao.DB.Save(appointment).Error
+}
+
+// CountStudentOverlapping 统计学生在 [start, end) 内仍有效的预约数量
+func (dao *AppointmentDao) CountStudentOverlapping(studentId int64, start time.Time, end time.Time) (int64, error) {
+	var count int64
+	err := dao.DB.Model(&models.Appointment{}).
+		Where("student_id = ? AND status = ? AND start_at < ? AND end_at > ?", studentId, models.AppointmentStatusBooked, end, start).
+		Count(&count).Error
+	return count, err
+}
+
+// ListByStudent 查询学生的全部预约，按开始时间倒序
+func (dao *AppointmentDao) ListByStudent(studentId int64) ([]models.Appointment, error) {
+	var list []models.Appointment
+	if err := dao.DB.Where("student_id = ?", studentId).Order("start_at desc").Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// ListBySlotIDs 查询指定时段上未取消的预约
+func (dao *AppointmentDao) ListBySlotIDs(slotIds []int64) ([]models.Appointment, error) {
+	var list []models.Appointment
+	if len(slotIds) == 0 {
+		return list, nil
+	}
+	if err := dao.DB.Where("slot_id IN ? AND status <> ?", slotIds, models.AppointmentStatusCancelled).Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// ListByCounselor 查询辅导员开始时间不早于 from 的预约，按开始时间正序
+func (dao *AppointmentDao) ListByCounselor(counselorId int64, from time.Time, statuses []string) ([]models.Appointment, error) {
+	var list []models.Appointment
+	if err := dao.DB.Where("counselor_id = ? AND start_at >= ? AND status IN ?", counselorId, from, statuses).
+		Order("start_at asc").Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// ListDueReminders 查询在 before 之前开始、尚未发送提醒的有效预约
+func (dao *AppointmentDao) ListDueReminders(now time.Time, before time.Time) ([]models.Appointment, error) {
+	var list []models.Appointment
+	if err := dao.DB.Where("status = ? AND reminder_sent_at IS NULL AND start_at > ? AND start_at <= ?", models.AppointmentStatusBooked, now, before).
+		Order("start_at asc").Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// MarkReminderSent 标记预约已发送提醒，返回是否由本次调用标记（已被标记时返回 false）
+func (dao *AppointmentDao) MarkReminderSent(id int64, sentAt time.Time) (bool, error) {
+	res := dao.DB.Model(&models.Appointment{}).Where("id = ? AND reminder_sent_at IS NULL", id).Update("reminder_sent_at", sentAt)
+	return res.RowsAffected > 0, res.Error
+}
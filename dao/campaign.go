@@ -0,0 +1,97 @@
+package dao
+
+import (
+	"errors"
+	"gorm.io/gorm"
+	"mental/models"
+	"time"
+)
+
+// CampaignDao 负责操作 campaign、campaign_target 表（筛查批次及其目标组织）
+type CampaignDao struct {
+	DB *gorm.DB
+}
+
+// NewCampaignDao 创建 CampaignDao 实例
+func NewCampaignDao(db *gorm.DB) *CampaignDao {
+	return &CampaignDao{DB: db}
+}
+
+// Save 插入筛查批次及其目标组织
+func (dao *CampaignDao) Save(campaign *models.Campaign) error {
+	return dao.DB.Create(campaign).Error
+}
+
+// FindByID 根据 ID 查询筛查批次及其目标组织，不存在时返回 nil
+func (dao *CampaignDao) FindByID(id int64) (*models.Campaign, error) {
+	var campaign models.Campaign
+	err := dao.DB.Preload("Targets").First(&campaign, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &campaign, nil
+}
+
+// FindByIDs 根据 ID 批量查询筛查批次及其目标组织
+func (dao *CampaignDao) FindByIDs(ids []int64) ([]models.Campaign, error) {
+	var list []models.Campaign
+	if len(ids) == 0 {
+		return list, nil
+	}
+	if err := dao.DB.Preload("Targets").Where("id IN ?", ids).Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// Update 更新筛查批次的名称、量表、开放窗口和备注
+func (dao *CampaignDao) Update(campaign *models.Campaign) error {
+	return dao.DB.Model(&models.Campaign{}).Where("id = ?", campaign.ID).Updates(map[string]interface{}{
+		"name":       campaign.Name,
+		"scale_code": campaign.ScaleCode,
+		"open_at":    campaign.OpenAt,
+		"close_at":   campaign.CloseAt,
+		"remark":     campaign.Remark,
+	}).Error
+}
+
+// ReplaceTargets 以 targets 替换筛查批次的全部目标组织
+func (dao *CampaignDao) ReplaceTargets(campaignId int64, targets []models.CampaignTarget) error {
+	if err := dao.DB.Where("campaign_id = ?", campaignId).Delete(&models.CampaignTarget{}).Error; err != nil {
+		return err
+	}
+	if len(targets) == 0 {
+		return nil
+	}
+	return dao.DB.Create(&targets).Error
+}
+
+// List 查询筛查批次及其目标组织，按开放时间倒序；scaleCode 为空时不限量表
+func (dao *CampaignDao) List(scaleCode string) ([]models.Campaign, error) {
+	var list []models.Campaign
+	query := dao.DB.Preload("Targets")
+	if scaleCode != "" {
+		query = query.Where("scale_code = ?", scaleCode)
+	}
+	if err := query.Order("open_at desc").Order("id desc").Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// ListOpenOn 查询使用指定量表、开放窗口覆盖 date 当天的筛查批次，按开放时间倒序
+func (dao *CampaignDao) ListOpenOn(scaleCode string, date time.Time) ([]models.Campaign, error) {
+	var list []models.Campaign
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
+	err := dao.DB.Preload("Targets").
+		Where("scale_code = ? AND open_at < ? AND close_at >= ?", scaleCode, day.AddDate(0, 0, 1), day).
+		Order("open_at desc").Order("id desc").
+		Find(&list).Error
+	if err != nil {
+		return nil, err
+	}
+	return list, nil
+}
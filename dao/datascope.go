@@ -0,0 +1,153 @@
+package dao
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"mental/config"
+	"mental/models"
+	"strings"
+)
+
+// DataScope 当前用户解析后的数据范围，用于在 DAO 查询中按学生过滤测评数据
+// All 为 true 时不过滤；否则只能访问本人及 OrgPaths 子树中花名册学生的数据
+type DataScope struct {
+	Scope    string           `json:"scope"`    // 生效的数据范围 self/class/department/all
+	UserID   int64            `json:"user_id"`  // 当前用户ID
+	All      bool             `json:"all"`      // 是否可访问全部数据
+	Orgs     []models.OrgUnit `json:"orgs"`     // 可访问的组织
+	OrgPaths []string         `json:"-"`        // 可访问组织的 ID 路径
+	RoleIDs  []string         `json:"role_ids"` // 当前用户的角色ID
+}
+
+// HasRole 当前用户是否拥有指定角色
+func (s *DataScope) HasRole(roleId string) bool {
+	if s == nil {
+		return false
+	}
+	for _, id := range s.RoleIDs {
+		if id == roleId {
+			return true
+		}
+	}
+	return false
+}
+
+// IsAdmin 当前用户是否拥有配置的管理员角色；数据范围为 all 的其他角色不是管理员
+func (s *DataScope) IsAdmin() bool {
+	return s.HasRole(config.DataScopeSettings.AdminRoleID)
+}
+
+// CoversOrg 数据范围是否包含整个组织子树（组织 ID 路径为 path），scope 为 nil 时不限
+func (s *DataScope) CoversOrg(path string) bool {
+	if s == nil || s.All {
+		return true
+	}
+	for _, orgPath := range s.OrgPaths {
+		if strings.HasPrefix(path, orgPath) {
+			return true
+		}
+	}
+	return false
+}
+
+// ContainsStudent 学生是否在数据范围内：本人，或花名册中属于可访问组织的学生
+func (s *DataScope) ContainsStudent(db *gorm.DB, studentId int64) (bool, error) {
+	if s == nil || s.All || s.UserID == studentId {
+		return true, nil
+	}
+	if len(s.OrgPaths) == 0 {
+		return false, nil
+	}
+	var count int64
+	err := s.Apply(db.Model(&models.OrgMember{}).Where("org_member.user_id = ?", studentId), "org_member.user_id").Count(&count).Error
+	return count > 0, err
+}
+
+// Apply 在查询上追加数据范围条件，column 为学生ID列名
+func (s *DataScope) Apply(db *gorm.DB, column string) *gorm.DB {
+	if s == nil || s.All {
+		return db
+	}
+	if len(s.OrgPaths) == 0 {
+		return db.Where(column+" = ?", s.UserID)
+	}
+	conditions := make([]string, 0, len(s.OrgPaths))
+	args := make([]interface{}, 0, len(s.OrgPaths))
+	for _, path := range s.OrgPaths {
+		conditions = append(conditions, "org_unit.path LIKE ?")
+		args = append(args, path+"%")
+	}
+	members := db.Session(&gorm.Session{NewDB: true}).Model(&models.OrgMember{}).
+		Select("org_member.user_id").
+		Joins("JOIN org_unit ON org_unit.id = org_member.org_id").
+		Where("org_member.user_id IS NOT NULL").
+		Where(strings.Join(conditions, " OR "), args...)
+	return db.Where(column+" = ? OR "+column+" IN (?)", s.UserID, members)
+}
+
+// DataScopeDao 负责操作 role_data_scope、org_manager 表（角色数据范围与组织负责人）
+type DataScopeDao struct {
+	DB *gorm.DB
+}
+
+// NewDataScopeDao 创建 DataScopeDao 实例
+func NewDataScopeDao(db *gorm.DB) *DataScopeDao {
+	return &DataScopeDao{DB: db}
+}
+
+// SaveRoleScope 设置角色的数据范围，已配置时覆盖
+func (dao *DataScopeDao) SaveRoleScope(roleScope *models.RoleDataScope) error {
+	return dao.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "role_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"scope", "updated_by", "updated_at"}),
+	}).Create(roleScope).Error
+}
+
+// ListRoleScopes 查询角色的数据范围配置，roleIds 为空时查询全部
+func (dao *DataScopeDao) ListRoleScopes(roleIds []string) ([]models.RoleDataScope, error) {
+	var list []models.RoleDataScope
+	query := dao.DB.Order("role_id")
+	if roleIds != nil {
+		if len(roleIds) == 0 {
+			return list, nil
+		}
+		query = query.Where("role_id IN ?", roleIds)
+	}
+	if err := query.Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// AddManager 指定组织负责人，已是负责人时忽略
+func (dao *DataScopeDao) AddManager(manager *models.OrgManager) error {
+	return dao.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(manager).Error
+}
+
+// RemoveManager 取消组织负责人
+func (dao *DataScopeDao) RemoveManager(userId int64, orgId int64) error {
+	return dao.DB.Where("user_id = ? AND org_id = ?", userId, orgId).Delete(&models.OrgManager{}).Error
+}
+
+// ListManagers 查询组织的负责人
+func (dao *DataScopeDao) ListManagers(orgId int64) ([]models.OrgManager, error) {
+	var list []models.OrgManager
+	if err := dao.DB.Where("org_id = ?", orgId).Order("id").Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// ListManagedOrgs 查询用户负责的全部组织
+func (dao *DataScopeDao) ListManagedOrgs(userId int64) ([]models.OrgUnit, error) {
+	var list []models.OrgUnit
+	err := dao.DB.Model(&models.OrgUnit{}).
+		Joins("JOIN org_manager ON org_manager.org_id = org_unit.id").
+		Where("org_manager.user_id = ?", userId).
+		Order("org_unit.path").
+		Find(&list).Error
+	if err != nil {
+		return nil, err
+	}
+	return list, nil
+}
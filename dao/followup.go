@@ -0,0 +1,93 @@
+package dao
+
+import (
+	"errors"
+	"gorm.io/gorm"
+	"mental/models"
+	"time"
+)
+
+// FollowUpDao 负责操作 follow_up、follow_up_attachment 表（辅导员跟进记录）
+// 设置了数据范围时，待跟进列表只包含范围内学生的记录
+type FollowUpDao struct {
+	DB    *gorm.DB
+	Scope *DataScope
+}
+
+// NewFollowUpDao 创建 FollowUpDao 实例
+func NewFollowUpDao(db *gorm.DB) *FollowUpDao {
+	return &FollowUpDao{DB: db}
+}
+
+// WithScope 返回按数据范围过滤的 FollowUpDao，scope 为 nil 时不过滤
+func (dao *FollowUpDao) WithScope(scope *DataScope) *FollowUpDao {
+	return &FollowUpDao{DB: dao.DB, Scope: scope}
+}
+
+// Save 插入一条跟进记录，关联的附件一并插入
+func (dao *FollowUpDao) Save(followUp *models.FollowUp) error {
+	return dao.DB.Create(followUp).Error
+}
+
+// FindByID 根据 ID 查询跟进记录（含附件），不存在时返回 nil
+func (dao *FollowUpDao) FindByID(id int64) (*models.FollowUp, error) {
+	var followUp models.FollowUp
+	err := dao.DB.Preload("Attachments").First(&followUp, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &followUp, nil
+}
+
+// Update 更新跟进记录的内容字段（不含附件）
+func (dao *FollowUpDao) Update(followUp *models.FollowUp) error {
+	return dao.DB.Model(&models.FollowUp{}).Where("id = ?", followUp.ID).Updates(map[string]interface{}{
+		"scl_id":              followUp.SCLID,
+		"alert_id":            followUp.AlertID,
+		"intervention_type":   followUp.InterventionType,
+		"follow_up_date":      followUp.FollowUpDate,
+		"notes":               followUp.Notes,
+		"outcome":             followUp.Outcome,
+		"next_follow_up_date": followUp.NextFollowUpDate,
+	}).Error
+}
+
+// ReplaceAttachments 删除跟进记录原有附件并插入新附件
+func (dao *FollowUpDao) ReplaceAttachments(followUpId int64, attachments []models.FollowUpAttachment) error {
+	if err := dao.DB.Where("follow_up_id = ?", followUpId).Delete(&models.FollowUpAttachment{}).Error; err != nil {
+		return err
+	}
+	if len(attachments) == 0 {
+		return nil
+	}
+	return dao.DB.Create(&attachments).Error
+}
+
+// ListByStudent 查询学生的全部跟进记录（含附件），按跟进日期倒序
+func (dao *FollowUpDao) ListByStudent(studentId int64) ([]models.FollowUp, error) {
+	var list []models.FollowUp
+	if err := dao.DB.Preload("Attachments").Where("student_id = ?", studentId).
+		Order("follow_up_date desc").Order("id desc").Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// ListDue 查询（数据范围内学生的）下次跟进日期早于 before 的记录，按下次跟进日期正序；counselorId 为 nil 时不限记录人
+// 同一学生只取最近一次跟进记录的下次跟进日期，之后已有新跟进的不再列出
+func (dao *FollowUpDao) ListDue(before time.Time, counselorId *int64) ([]models.FollowUp, error) {
+	var list []models.FollowUp
+	latest := dao.DB.Model(&models.FollowUp{}).Select("MAX(id)").Group("student_id")
+	query := dao.Scope.Apply(dao.DB.Model(&models.FollowUp{}), "follow_up.student_id").Where("id IN (?)", latest).
+		Where("next_follow_up_date IS NOT NULL AND next_follow_up_date < ?", before)
+	if counselorId != nil {
+		query = query.Where("counselor_id = ?", *counselorId)
+	}
+	if err := query.Order("next_follow_up_date asc").Order("id asc").Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
@@ -0,0 +1,83 @@
+package dao
+
+import (
+	"errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"mental/models"
+)
+
+// ImportBatchDao 负责操作 import_batch 表（Excel 导入批次）
+type ImportBatchDao struct {
+	DB *gorm.DB
+}
+
+// NewImportBatchDao 创建 ImportBatchDao 实例
+func NewImportBatchDao(db *gorm.DB) *ImportBatchDao {
+	return &ImportBatchDao{DB: db}
+}
+
+// Save 插入一个导入批次
+func (dao *ImportBatchDao) Save(batch *models.ImportBatch) error {
+	return dao.DB.Create(batch).Error
+}
+
+// FindByID 根据 ID 查询导入批次，不存在时返回 nil
+func (dao *ImportBatchDao) FindByID(id int64) (*models.ImportBatch, error) {
+	var batch models.ImportBatch
+	err := dao.DB.First(&batch, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &batch, nil
+}
+
+// FindByIDForUpdate 在事务中根据 ID 查询导入批次并加行锁（SELECT ... FOR UPDATE），不存在时返回 nil
+func (dao *ImportBatchDao) FindByIDForUpdate(id int64) (*models.ImportBatch, error) {
+	var batch models.ImportBatch
+	err := dao.DB.Clauses(clause.Locking{Strength: "UPDATE"}).First(&batch, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &batch, nil
+}
+
+// List 按导入时间倒序列出导入批次，fileId 不为空时只查询该文件的批次，createdBy 不为 0 时只查询该用户导入的批次
+func (dao *ImportBatchDao) List(fileId string, createdBy int64) ([]models.ImportBatch, error) {
+	var list []models.ImportBatch
+	query := dao.DB.Order("id desc")
+	if fileId != "" {
+		query = query.Where("file_id = ?", fileId)
+	}
+	if createdBy != 0 {
+		query = query.Where("created_by = ?", createdBy)
+	}
+	if err := query.Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// UpdateResult 更新导入批次的状态和成功、失败行数
+func (dao *ImportBatchDao) UpdateResult(batch *models.ImportBatch) error {
+	return dao.DB.Model(&models.ImportBatch{}).Where("id = ?", batch.ID).Updates(map[string]interface{}{
+		"status":        batch.Status,
+		"success_count": batch.SuccessCount,
+		"error_count":   batch.ErrorCount,
+	}).Error
+}
+
+// MarkRolledBack 将导入批次标记为已回滚
+func (dao *ImportBatchDao) MarkRolledBack(batch *models.ImportBatch) error {
+	return dao.DB.Model(&models.ImportBatch{}).Where("id = ?", batch.ID).Updates(map[string]interface{}{
+		"status":         models.ImportBatchRolledBack,
+		"rolled_back_by": batch.RolledBackBy,
+		"rolled_back_at": batch.RolledBackAt,
+	}).Error
+}
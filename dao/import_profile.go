@@ -0,0 +1,82 @@
+package dao
+
+import (
+	"errors"
+	"gorm.io/gorm"
+	"mental/models"
+	"time"
+)
+
+// ImportProfileDao 负责操作 import_profile 表（Excel 导入列映射方案）
+type ImportProfileDao struct {
+	DB *gorm.DB
+}
+
+// NewImportProfileDao 创建 ImportProfileDao 实例
+func NewImportProfileDao(db *gorm.DB) *ImportProfileDao {
+	return &ImportProfileDao{DB: db}
+}
+
+// Save 插入一个映射方案
+func (dao *ImportProfileDao) Save(profile *models.ImportProfile) error {
+	return dao.DB.Create(profile).Error
+}
+
+// FindByID 根据 ID 查询映射方案，不存在时返回 nil
+func (dao *ImportProfileDao) FindByID(id int64) (*models.ImportProfile, error) {
+	var profile models.ImportProfile
+	err := dao.DB.First(&profile, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &profile, nil
+}
+
+// FindActive 查询指定组织当前启用的映射方案，不存在时返回 nil
+func (dao *ImportProfileDao) FindActive(orgId int64) (*models.ImportProfile, error) {
+	var profile models.ImportProfile
+	err := dao.DB.Where("org_id = ? AND active = ?", orgId, true).First(&profile).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &profile, nil
+}
+
+// ListByOrg 按创建时间倒序列出指定组织的全部映射方案
+func (dao *ImportProfileDao) ListByOrg(orgId int64) ([]models.ImportProfile, error) {
+	var list []models.ImportProfile
+	if err := dao.DB.Where("org_id = ?", orgId).Order("id desc").Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// Update 更新映射方案的名称、内容和备注，所属组织和启用状态不变
+func (dao *ImportProfileDao) Update(profile *models.ImportProfile) error {
+	return dao.DB.Model(&models.ImportProfile{}).Where("id = ?", profile.ID).Updates(map[string]interface{}{
+		"name":    profile.Name,
+		"content": profile.Content,
+		"remark":  profile.Remark,
+	}).Error
+}
+
+// Activate 启用指定映射方案，同一组织下其余方案全部停用
+func (dao *ImportProfileDao) Activate(profile *models.ImportProfile) error {
+	return dao.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.ImportProfile{}).
+			Where("org_id = ? AND active = ?", profile.OrgID, true).
+			Update("active", false).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.ImportProfile{}).Where("id = ?", profile.ID).Updates(map[string]interface{}{
+			"active":       true,
+			"activated_at": time.Now(),
+		}).Error
+	})
+}
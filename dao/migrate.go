@@ -0,0 +1,36 @@
+package dao
+
+import (
+	"gorm.io/gorm"
+	"mental/models"
+)
+
+// AutoMigrate 自动建表/补充字段，启动时调用
+func AutoMigrate(db *gorm.DB) error {
+	return db.AutoMigrate(
+		&models.SCL{},
+		&models.SCLAnswer{},
+		&models.SCLRuleSet{},
+		&models.NormGroup{},
+		&models.NormEntry{},
+		&models.Scale{},
+		&models.ScaleResponse{},
+		&models.ScaleAnswer{},
+		&models.AlertRule{},
+		&models.Alert{},
+		&models.AlertNote{},
+		&models.FollowUp{},
+		&models.FollowUpAttachment{},
+		&models.AppointmentSlot{},
+		&models.Appointment{},
+		&models.Notification{},
+		&models.OrgUnit{},
+		&models.OrgMember{},
+		&models.RoleDataScope{},
+		&models.OrgManager{},
+		&models.Campaign{},
+		&models.CampaignTarget{},
+		&models.ImportProfile{},
+		&models.ImportBatch{},
+	)
+}
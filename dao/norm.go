@@ -0,0 +1,76 @@
+package dao
+
+import (
+	"errors"
+	"gorm.io/gorm"
+	"mental/models"
+)
+
+// NormDao 负责操作 norm_group、norm_entry 表（常模）
+type NormDao struct {
+	DB *gorm.DB
+}
+
+// NewNormDao 创建 NormDao 实例
+func NewNormDao(db *gorm.DB) *NormDao {
+	return &NormDao{DB: db}
+}
+
+// SaveGroup 在同一事务中保存常模组及其全部条目
+func (dao *NormDao) SaveGroup(group *models.NormGroup) error {
+	return dao.DB.Transaction(func(tx *gorm.DB) error {
+		return tx.Create(group).Error // 关联的 Entries 一并插入
+	})
+}
+
+// FindByCode 根据编码查询常模组（含条目），不存在时返回 nil
+func (dao *NormDao) FindByCode(code string) (*models.NormGroup, error) {
+	var group models.NormGroup
+	err := dao.DB.Preload("Entries").Where("code = ?", code).First(&group).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &group, nil
+}
+
+// FindDefault 查询默认常模组（含条目），不存在时返回 nil
+func (dao *NormDao) FindDefault() (*models.NormGroup, error) {
+	var group models.NormGroup
+	err := dao.DB.Preload("Entries").Where("is_default = ?", true).First(&group).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &group, nil
+}
+
+// ListGroups 列出全部常模组（不含条目）
+func (dao *NormDao) ListGroups() ([]models.NormGroup, error) {
+	var list []models.NormGroup
+	if err := dao.DB.Order("id asc").Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// SetDefault 将指定常模组设为默认，其余取消默认
+func (dao *NormDao) SetDefault(id int64) error {
+	return dao.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.NormGroup{}).Where("is_default = ?", true).Update("is_default", false).Error; err != nil {
+			return err
+		}
+		res := tx.Model(&models.NormGroup{}).Where("id = ?", id).Update("is_default", true)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+}
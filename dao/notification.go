@@ -0,0 +1,47 @@
+package dao
+
+import (
+	"gorm.io/gorm"
+	"mental/models"
+	"time"
+)
+
+// NotificationDao 负责操作 notification 表（站内通知）
+type NotificationDao struct {
+	DB *gorm.DB
+}
+
+// NewNotificationDao 创建 NotificationDao 实例
+func NewNotificationDao(db *gorm.DB) *NotificationDao {
+	return &NotificationDao{DB: db}
+}
+
+// SaveBatch 批量插入通知
+func (dao *NotificationDao) SaveBatch(notifications []models.Notification) error {
+	if len(notifications) == 0 {
+		return nil
+	}
+	return dao.DB.Create(&notifications).Error
+}
+
+// ListByUser 查询用户的通知，按创建时间倒序；unreadOnly 为 true 时只返回未读通知
+func (dao *NotificationDao) ListByUser(userId int64, unreadOnly bool) ([]models.Notification, error) {
+	var list []models.Notification
+	query := dao.DB.Where("user_id = ?", userId)
+	if unreadOnly {
+		query = query.Where("read_at IS NULL")
+	}
+	if err := query.Order("created_at desc").Order("id desc").Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// MarkRead 将用户的通知标记为已读，ids 为空时标记全部
+func (dao *NotificationDao) MarkRead(userId int64, ids []int64) error {
+	query := dao.DB.Model(&models.Notification{}).Where("user_id = ? AND read_at IS NULL", userId)
+	if len(ids) > 0 {
+		query = query.Where("id IN ?", ids)
+	}
+	return query.Update("read_at", time.Now()).Error
+}
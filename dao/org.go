@@ -0,0 +1,171 @@
+package dao
+
+import (
+	"errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"mental/models"
+)
+
+// OrgDao 负责操作 org_unit、org_member 表（组织树与班级花名册）
+type OrgDao struct {
+	DB *gorm.DB
+}
+
+// NewOrgDao 创建 OrgDao 实例
+func NewOrgDao(db *gorm.DB) *OrgDao {
+	return &OrgDao{DB: db}
+}
+
+// Save 插入一个组织单元
+func (dao *OrgDao) Save(org *models.OrgUnit) error {
+	return dao.DB.Create(org).Error
+}
+
+// UpdatePath 更新组织单元的 ID 路径（插入后才能得到自身 ID）
+func (dao *OrgDao) UpdatePath(id int64, path string) error {
+	return dao.DB.Model(&models.OrgUnit{}).Where("id = ?", id).Update("path", path).Error
+}
+
+// FindByID 根据 ID 查询组织单元，不存在时返回 nil
+func (dao *OrgDao) FindByID(id int64) (*models.OrgUnit, error) {
+	var org models.OrgUnit
+	err := dao.DB.First(&org, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &org, nil
+}
+
+// FindByCode 根据编码查询组织单元，不存在时返回 nil
+func (dao *OrgDao) FindByCode(code string) (*models.OrgUnit, error) {
+	var org models.OrgUnit
+	err := dao.DB.Where("code = ?", code).First(&org).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &org, nil
+}
+
+// Update 更新组织单元的名称、编码和排序
+func (dao *OrgDao) Update(org *models.OrgUnit) error {
+	return dao.DB.Model(&models.OrgUnit{}).Where("id = ?", org.ID).Updates(map[string]interface{}{
+		"name": org.Name,
+		"code": org.Code,
+		"sort": org.Sort,
+	}).Error
+}
+
+// DeleteByID 删除组织单元
+func (dao *OrgDao) DeleteByID(id int64) error {
+	return dao.DB.Delete(&models.OrgUnit{}, id).Error
+}
+
+// CountChildren 统计直接下级组织数量
+func (dao *OrgDao) CountChildren(id int64) (int64, error) {
+	var count int64
+	err := dao.DB.Model(&models.OrgUnit{}).Where("parent_id = ?", id).Count(&count).Error
+	return count, err
+}
+
+// ListSubtree 查询 ID 路径以 path 开头的全部组织单元（含自身），path 为空时查询整棵树
+func (dao *OrgDao) ListSubtree(path string) ([]models.OrgUnit, error) {
+	var list []models.OrgUnit
+	if err := dao.DB.Where("path LIKE ?", path+"%").Order("sort, id").Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// FindByIDs 根据 ID 批量查询组织单元
+func (dao *OrgDao) FindByIDs(ids []int64) ([]models.OrgUnit, error) {
+	var list []models.OrgUnit
+	if len(ids) == 0 {
+		return list, nil
+	}
+	if err := dao.DB.Where("id IN ?", ids).Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// CountMembers 统计班级的花名册人数
+func (dao *OrgDao) CountMembers(orgId int64) (int64, error) {
+	var count int64
+	err := dao.DB.Model(&models.OrgMember{}).Where("org_id = ?", orgId).Count(&count).Error
+	return count, err
+}
+
+// ListMembers 查询子树（ID 路径以 path 开头）中全部班级的花名册，按班级、学号排序
+func (dao *OrgDao) ListMembers(path string) ([]models.OrgMember, error) {
+	var list []models.OrgMember
+	err := dao.DB.Model(&models.OrgMember{}).
+		Joins("JOIN org_unit ON org_unit.id = org_member.org_id").
+		Where("org_unit.path LIKE ?", path+"%").
+		Order("org_member.org_id, org_member.student_no").
+		Find(&list).Error
+	if err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// FindMemberByUserID 查询用户所在班级的花名册记录，不在任何班级时返回 nil
+func (dao *OrgDao) FindMemberByUserID(userId int64) (*models.OrgMember, error) {
+	var member models.OrgMember
+	err := dao.DB.Where("user_id = ?", userId).First(&member).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
+
+// FindMemberByID 根据 ID 查询花名册记录，不存在时返回 nil
+func (dao *OrgDao) FindMemberByID(id int64) (*models.OrgMember, error) {
+	var member models.OrgMember
+	err := dao.DB.First(&member, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
+
+// ListMembersByStudentNos 按学号批量查询花名册记录
+func (dao *OrgDao) ListMembersByStudentNos(studentNos []string) ([]models.OrgMember, error) {
+	var list []models.OrgMember
+	if len(studentNos) == 0 {
+		return list, nil
+	}
+	if err := dao.DB.Where("student_no IN ?", studentNos).Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// UpsertMembers 按学号批量写入花名册：学号已存在时更新班级、姓名、性别和关联用户（即调班）
+func (dao *OrgDao) UpsertMembers(members []models.OrgMember) error {
+	if len(members) == 0 {
+		return nil
+	}
+	return dao.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "student_no"}},
+		DoUpdates: clause.AssignmentColumns([]string{"org_id", "name", "gender", "user_id", "updated_at"}),
+	}).Create(&members).Error
+}
+
+// DeleteMember 将学生移出花名册
+func (dao *OrgDao) DeleteMember(id int64) error {
+	return dao.DB.Delete(&models.OrgMember{}, id).Error
+}
@@ -0,0 +1,98 @@
+package dao
+
+import (
+	"errors"
+	"gorm.io/gorm"
+	"mental/models"
+)
+
+// ScaleDao 负责操作 scale、scale_response、scale_answer 表（通用量表）
+// 设置了数据范围时，作答记录的查询只作用于范围内学生的记录
+type ScaleDao struct {
+	DB    *gorm.DB
+	Scope *DataScope
+}
+
+// NewScaleDao 创建 ScaleDao 实例
+func NewScaleDao(db *gorm.DB) *ScaleDao {
+	return &ScaleDao{DB: db}
+}
+
+// WithScope 返回按数据范围过滤的 ScaleDao，scope 为 nil 时不过滤
+func (dao *ScaleDao) WithScope(scope *DataScope) *ScaleDao {
+	return &ScaleDao{DB: dao.DB, Scope: scope}
+}
+
+// SaveScale 插入一个量表定义
+func (dao *ScaleDao) SaveScale(scale *models.Scale) error {
+	return dao.DB.Create(scale).Error
+}
+
+// FindScaleByCode 根据编码查询量表定义，不存在时返回 nil
+func (dao *ScaleDao) FindScaleByCode(code string) (*models.Scale, error) {
+	var scale models.Scale
+	err := dao.DB.Where("code = ?", code).First(&scale).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &scale, nil
+}
+
+// ListScales 列出全部量表定义
+func (dao *ScaleDao) ListScales() ([]models.Scale, error) {
+	var list []models.Scale
+	if err := dao.DB.Order("id asc").Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// SaveResponse 插入一条作答记录
+func (dao *ScaleDao) SaveResponse(response *models.ScaleResponse) error {
+	return dao.DB.Create(response).Error
+}
+
+// SaveAnswers 批量插入某条作答记录的全部逐题作答
+func (dao *ScaleDao) SaveAnswers(answers []models.ScaleAnswer) error {
+	if len(answers) == 0 {
+		return nil
+	}
+	return dao.DB.Create(&answers).Error
+}
+
+// FindAnswersByResponseID 根据作答记录 ID 查询逐题作答，按题号排序
+func (dao *ScaleDao) FindAnswersByResponseID(responseId int64) ([]models.ScaleAnswer, error) {
+	var list []models.ScaleAnswer
+	if err := dao.DB.Where("response_id = ?", responseId).Order("item_no asc").Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// ListResponses 查询（数据范围内的）作答记录，按测评日期倒序；code 为空时不限量表，studentId 为 nil 时不限学生
+func (dao *ScaleDao) ListResponses(code string, studentId *int64) ([]models.ScaleResponse, error) {
+	var list []models.ScaleResponse
+	query := dao.Scope.Apply(dao.DB.Model(&models.ScaleResponse{}), "scale_response.student_id")
+	if code != "" {
+		query = query.Where("scale_code = ?", code)
+	}
+	if studentId != nil {
+		query = query.Where("student_id = ?", *studentId)
+	}
+	if err := query.Order("test_date desc").Order("id desc").Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// ListResponsesByCampaign 查询关联到指定筛查批次的作答记录，按测评日期倒序
+func (dao *ScaleDao) ListResponsesByCampaign(campaignId int64) ([]models.ScaleResponse, error) {
+	var list []models.ScaleResponse
+	if err := dao.DB.Where("campaign_id = ?", campaignId).Order("test_date desc").Order("id desc").Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
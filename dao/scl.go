@@ -3,11 +3,89 @@ package dao
 import (
 	"gorm.io/gorm"
 	"mental/models"
+	"time"
 )
 
+// SCLFilter scl 记录的查询条件，零值表示不限
+type SCLFilter struct {
+	StudentID *int64     // 学生ID
+	Name      string     // 姓名（模糊匹配）
+	Gender    *int       // 性别 0女 1男
+	AgeMin    *int       // 年龄下限（含）
+	AgeMax    *int       // 年龄上限（含）
+	From      *time.Time // 测评日期下限（含）
+	To        *time.Time // 测评日期上限（不含）
+	OrgPath   string     // 只查询该组织子树（ID 路径以 OrgPath 开头）花名册学生的记录
+	Level     string     // 总体等级
+	Ranges    []SCLRange // 指标取值范围
+	ValidOnly bool       // 排除无效作答的记录
+}
+
+// SCLRange 指标列的取值范围（含两端），Column 须由调用方按白名单给出
+type SCLRange struct {
+	Column string
+	Min    *float64
+	Max    *float64
+}
+
+// SCLSort 排序条件，Expr 须由调用方按白名单给出；排序相同时按 ID 排序，保证分页稳定
+type SCLSort struct {
+	Expr string
+	Desc bool
+}
+
+// apply 在查询上追加过滤条件
+func (f SCLFilter) apply(db *gorm.DB) *gorm.DB {
+	if f.StudentID != nil {
+		db = db.Where("scl.student_id = ?", *f.StudentID)
+	}
+	if f.Name != "" {
+		db = db.Where("scl.name LIKE ?", "%"+f.Name+"%")
+	}
+	if f.Gender != nil {
+		db = db.Where("scl.gender = ?", *f.Gender)
+	}
+	if f.AgeMin != nil {
+		db = db.Where("scl.age >= ?", *f.AgeMin)
+	}
+	if f.AgeMax != nil {
+		db = db.Where("scl.age <= ?", *f.AgeMax)
+	}
+	if f.Level != "" {
+		db = db.Where("scl.level = ?", f.Level)
+	}
+	if f.ValidOnly {
+		db = db.Where("scl.invalid = ?", false)
+	}
+	for _, r := range f.Ranges {
+		if r.Min != nil {
+			db = db.Where("scl."+r.Column+" >= ?", *r.Min)
+		}
+		if r.Max != nil {
+			db = db.Where("scl."+r.Column+" <= ?", *r.Max)
+		}
+	}
+	if f.From != nil {
+		db = db.Where("scl.test_date >= ?", *f.From)
+	}
+	if f.To != nil {
+		db = db.Where("scl.test_date < ?", *f.To)
+	}
+	if f.OrgPath != "" {
+		members := db.Session(&gorm.Session{NewDB: true}).Model(&models.OrgMember{}).
+			Select("org_member.user_id").
+			Joins("JOIN org_unit ON org_unit.id = org_member.org_id").
+			Where("org_unit.path LIKE ? AND org_member.user_id IS NOT NULL", f.OrgPath+"%")
+		db = db.Where("scl.student_id IN (?)", members)
+	}
+	return db
+}
+
 // SCLDao 负责操作 scl 表（心理测评数据）
+// 设置了数据范围时，查询、更新和删除只作用于范围内学生的记录
 type SCLDao struct {
-	DB *gorm.DB
+	DB    *gorm.DB
+	Scope *DataScope
 }
 
 // NewSCLDao 创建 SCLDao 实例
@@ -15,6 +93,16 @@ func NewSCLDao(db *gorm.DB) *SCLDao {
 	return &SCLDao{DB: db}
 }
 
+// WithScope 返回按数据范围过滤的 SCLDao，scope 为 nil 时不过滤
+func (dao *SCLDao) WithScope(scope *DataScope) *SCLDao {
+	return &SCLDao{DB: dao.DB, Scope: scope}
+}
+
+// scoped 返回追加了数据范围条件的查询
+func (dao *SCLDao) scoped() *gorm.DB {
+	return dao.Scope.Apply(dao.DB, "scl.student_id")
+}
+
 // Save 插入一条 SCL 记录 *
 func (dao *SCLDao) Save(scl *models.SCL) error {
 	result := dao.DB.Create(scl)
@@ -24,7 +112,7 @@ func (dao *SCLDao) Save(scl *models.SCL) error {
 // FindByID 根据 ID 查询一条记录
 func (dao *SCLDao) FindByID(id int64) (*models.SCL, error) {
 	var scl models.SCL
-	if err := dao.DB.First(&scl, id).Error; err != nil {
+	if err := dao.scoped().First(&scl, id).Error; err != nil {
 		return nil, err
 	}
 	return &scl, nil
@@ -33,7 +121,7 @@ func (dao *SCLDao) FindByID(id int64) (*models.SCL, error) {
 // List 列出所有记录（可分页后续扩展）
 func (dao *SCLDao) List() ([]models.SCL, error) {
 	var list []models.SCL
-	if err := dao.DB.Order("test_date desc").Find(&list).Error; err != nil {
+	if err := dao.scoped().Order("test_date desc").Find(&list).Error; err != nil {
 		return nil, err
 	}
 	return list, nil
@@ -41,13 +129,13 @@ func (dao *SCLDao) List() ([]models.SCL, error) {
 
 // DeleteByID 根据 ID 删除
 func (dao *SCLDao) DeleteByID(id int64) error {
-	return dao.DB.Delete(&models.SCL{}, id).Error
+	return dao.scoped().Delete(&models.SCL{}, id).Error
 }
 
 // SelectAllByUserId 根据用户id，和时间排序，查找该用户的历史测量数据 TODO 2025-5-22
 func (dao *SCLDao) SelectAllByUserId(userId int64) ([]models.SCL, error) {
 	var list []models.SCL
-	if err := dao.DB.Where("student_id = ?", userId).Order("test_date desc").Find(&list).Error; err != nil {
+	if err := dao.scoped().Where("student_id = ?", userId).Order("test_date desc").Find(&list).Error; err != nil {
 		return nil, err
 	}
 	return list, nil
@@ -56,29 +144,144 @@ func (dao *SCLDao) SelectAllByUserId(userId int64) ([]models.SCL, error) {
 // SelectAll 查找所有的scl记录数据
 func (dao *SCLDao) SelectAll() ([]models.SCL, error) {
 	var list []models.SCL
-	if err := dao.DB.Order("test_date desc").Find(&list).Error; err != nil {
+	if err := dao.scoped().Order("test_date desc").Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// SelectAllByOrgPath 查找子树（组织 ID 路径以 path 开头）中花名册学生的全部 scl 记录
+func (dao *SCLDao) SelectAllByOrgPath(path string) ([]models.SCL, error) {
+	var list []models.SCL
+	if err := (SCLFilter{OrgPath: path}).apply(dao.scoped()).Order("test_date desc").Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// SelectByCampaign 查找关联到指定筛查批次的全部 scl 记录，按测评日期倒序
+func (dao *SCLDao) SelectByCampaign(campaignId int64) ([]models.SCL, error) {
+	var list []models.SCL
+	if err := dao.scoped().Where("campaign_id = ?", campaignId).Order("test_date desc").Order("id desc").Find(&list).Error; err != nil {
 		return nil, err
 	}
 	return list, nil
 }
 
-// UpdateByID 根据 ID 更新指定字段
+// ExistsByStudentDate 查询学生在指定测评日期是否已有记录
+func (dao *SCLDao) ExistsByStudentDate(studentId int64, testDate time.Time) (bool, error) {
+	var count int64
+	err := dao.DB.Model(&models.SCL{}).
+		Where("student_id = ? AND test_date = ?", studentId, testDate.Format("2006-01-02")).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// CountByImportBatch 统计导入批次中（数据范围内）未删除的记录数
+func (dao *SCLDao) CountByImportBatch(batchId int64) (int64, error) {
+	var count int64
+	err := dao.scoped().Model(&models.SCL{}).Where("scl.import_batch_id = ?", batchId).Count(&count).Error
+	return count, err
+}
+
+// ListIDsByImportBatch 查询导入批次中（数据范围内）未删除的记录ID
+func (dao *SCLDao) ListIDsByImportBatch(batchId int64) ([]int64, error) {
+	var ids []int64
+	err := dao.scoped().Model(&models.SCL{}).Where("scl.import_batch_id = ?", batchId).Pluck("scl.id", &ids).Error
+	return ids, err
+}
+
+// DeleteByImportBatch 软删除导入批次中（数据范围内）的全部记录，返回删除的记录数
+func (dao *SCLDao) DeleteByImportBatch(batchId int64) (int64, error) {
+	result := dao.scoped().Where("scl.import_batch_id = ?", batchId).Delete(&models.SCL{})
+	return result.RowsAffected, result.Error
+}
+
+// Page 分页查询符合条件的记录，返回当页记录和符合条件的总数；page 从 1 开始
+func (dao *SCLDao) Page(filter SCLFilter, sort SCLSort, page int, size int) ([]models.SCL, int64, error) {
+	var (
+		list  []models.SCL
+		total int64
+	)
+	query := filter.apply(dao.scoped().Model(&models.SCL{})).Session(&gorm.Session{}) // 计数和查询共用条件
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	direction := " asc"
+	if sort.Desc {
+		direction = " desc"
+	}
+	err := query.Order(sort.Expr + direction).Order("scl.id" + direction).
+		Offset((page - 1) * size).Limit(size).
+		Find(&list).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return list, total, nil
+}
+
+// EachBatch 按 ID 顺序分批查询符合条件的记录，每批最多 size 条，逐批交给 fn 处理，避免一次载入全部记录
+func (dao *SCLDao) EachBatch(filter SCLFilter, size int, fn func([]models.SCL) error) error {
+	var batch []models.SCL
+	return filter.apply(dao.scoped().Model(&models.SCL{})).FindInBatches(&batch, size, func(tx *gorm.DB, _ int) error {
+		return fn(batch)
+	}).Error
+}
+
+// UpdateByID 根据 ID 更新指定字段，所属学生（student_id）不在更新范围内
 func (dao *SCLDao) UpdateByID(id int64, scl *models.SCL) error {
+	return dao.scoped().Model(&models.SCL{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"name":              scl.Name,
+		"gender":            scl.Gender,
+		"age":               scl.Age,
+		"test_date":         scl.TestDate,
+		"somatization":      scl.Somatization,
+		"obsession":         scl.Obsession,
+		"interpersonal":     scl.Interpersonal,
+		"depression":        scl.Depression,
+		"anxiety":           scl.Anxiety,
+		"hostility":         scl.Hostility,
+		"phobia":            scl.Phobia,
+		"paranoia":          scl.Paranoia,
+		"psychoticism":      scl.Psychoticism,
+		"other":             scl.Other,
+		"total_score":       scl.TotalScore,
+		"positive_items":    scl.PositiveItems,
+		"indices_estimated": scl.IndicesEstimated,
+		"negative_items":    scl.NegativeItems,
+		"gsi":               scl.GSI,
+		"psdi":              scl.PSDI,
+		"rule_set_id":       scl.RuleSetID,
+		"level":             scl.Level,
+		"campaign_id":       scl.CampaignID,
+	}).Error
+}
+
+// UpdateValidity 更新记录的作答有效性指标
+func (dao *SCLDao) UpdateValidity(id int64, invalid bool, validity string) error {
 	return dao.DB.Model(&models.SCL{}).Where("id = ?", id).Updates(map[string]interface{}{
-		"student_id":    scl.StudentID,
-		"name":          scl.Name,
-		"gender":        scl.Gender,
-		"age":           scl.Age,
-		"test_date":     scl.TestDate,
-		"somatization":  scl.Somatization,
-		"obsession":     scl.Obsession,
-		"interpersonal": scl.Interpersonal,
-		"depression":    scl.Depression,
-		"anxiety":       scl.Anxiety,
-		"hostility":     scl.Hostility,
-		"phobia":        scl.Phobia,
-		"paranoia":      scl.Paranoia,
-		"psychoticism":  scl.Psychoticism,
-		"other":         scl.Other,
+		"invalid":  invalid,
+		"validity": validity,
 	}).Error
 }
+
+// SelectWithoutLevel 按 ID 顺序查询 afterId 之后尚未计算总体等级的记录，最多 limit 条
+func (dao *SCLDao) SelectWithoutLevel(afterId int64, limit int) ([]models.SCL, error) {
+	var list []models.SCL
+	if err := dao.DB.Where("id > ? AND level = ''", afterId).Order("id").Limit(limit).Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// MarkEstimatedWithoutAnswers 将没有逐题作答的历史记录标记为全局指数由因子分估算，返回更新的记录数
+func (dao *SCLDao) MarkEstimatedWithoutAnswers() (int64, error) {
+	answers := dao.DB.Session(&gorm.Session{NewDB: true}).Model(&models.SCLAnswer{}).Select("1").Where("scl_answer.scl_id = scl.id")
+	result := dao.DB.Model(&models.SCL{}).Where("indices_estimated = ? AND NOT EXISTS (?)", false, answers).Update("indices_estimated", true)
+	return result.RowsAffected, result.Error
+}
+
+// UpdateLevel 更新记录的总体等级
+func (dao *SCLDao) UpdateLevel(id int64, level string) error {
+	return dao.DB.Model(&models.SCL{}).Where("id = ?", id).Update("level", level).Error
+}
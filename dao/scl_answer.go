@@ -0,0 +1,38 @@
+package dao
+
+import (
+	"gorm.io/gorm"
+	"mental/models"
+)
+
+// SCLAnswerDao 负责操作 scl_answer 表（SCL-90 逐题作答）
+type SCLAnswerDao struct {
+	DB *gorm.DB
+}
+
+// NewSCLAnswerDao 创建 SCLAnswerDao 实例
+func NewSCLAnswerDao(db *gorm.DB) *SCLAnswerDao {
+	return &SCLAnswerDao{DB: db}
+}
+
+// SaveBatch 批量插入某条测评记录的全部作答
+func (dao *SCLAnswerDao) SaveBatch(answers []models.SCLAnswer) error {
+	if len(answers) == 0 {
+		return nil
+	}
+	return dao.DB.Create(&answers).Error
+}
+
+// FindBySCLID 根据测评记录 ID 查询全部作答，按题号排序
+func (dao *SCLAnswerDao) FindBySCLID(sclId int64) ([]models.SCLAnswer, error) {
+	var list []models.SCLAnswer
+	if err := dao.DB.Where("scl_id = ?", sclId).Order("item_no asc").Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// DeleteBySCLID 删除某条测评记录的全部作答
+func (dao *SCLAnswerDao) DeleteBySCLID(sclId int64) error {
+	return dao.DB.Where("scl_id = ?", sclId).Delete(&models.SCLAnswer{}).Error
+}
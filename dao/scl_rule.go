@@ -0,0 +1,79 @@
+package dao
+
+import (
+	"errors"
+	"gorm.io/gorm"
+	"mental/models"
+	"time"
+)
+
+// SCLRuleSetDao 负责操作 scl_rule_set 表（结果解释规则集）
+type SCLRuleSetDao struct {
+	DB *gorm.DB
+}
+
+// NewSCLRuleSetDao 创建 SCLRuleSetDao 实例
+func NewSCLRuleSetDao(db *gorm.DB) *SCLRuleSetDao {
+	return &SCLRuleSetDao{DB: db}
+}
+
+// Save 插入一个规则集
+func (dao *SCLRuleSetDao) Save(ruleSet *models.SCLRuleSet) error {
+	return dao.DB.Create(ruleSet).Error
+}
+
+// FindByID 根据 ID 查询规则集
+func (dao *SCLRuleSetDao) FindByID(id int64) (*models.SCLRuleSet, error) {
+	var ruleSet models.SCLRuleSet
+	if err := dao.DB.First(&ruleSet, id).Error; err != nil {
+		return nil, err
+	}
+	return &ruleSet, nil
+}
+
+// FindActive 查询指定组织当前启用的规则集，不存在时返回 nil
+func (dao *SCLRuleSetDao) FindActive(orgId int64) (*models.SCLRuleSet, error) {
+	var ruleSet models.SCLRuleSet
+	err := dao.DB.Where("org_id = ? AND active = ?", orgId, true).First(&ruleSet).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &ruleSet, nil
+}
+
+// ListByOrg 按版本倒序列出指定组织的全部规则集
+func (dao *SCLRuleSetDao) ListByOrg(orgId int64) ([]models.SCLRuleSet, error) {
+	var list []models.SCLRuleSet
+	if err := dao.DB.Where("org_id = ?", orgId).Order("version desc").Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// MaxVersion 查询指定组织已有的最大版本号，没有规则集时返回 0
+func (dao *SCLRuleSetDao) MaxVersion(orgId int64) (int, error) {
+	var version int
+	err := dao.DB.Model(&models.SCLRuleSet{}).
+		Where("org_id = ?", orgId).
+		Select("COALESCE(MAX(version), 0)").
+		Scan(&version).Error
+	return version, err
+}
+
+// Activate 启用指定规则集，同一组织下其余规则集全部停用
+func (dao *SCLRuleSetDao) Activate(ruleSet *models.SCLRuleSet) error {
+	return dao.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.SCLRuleSet{}).
+			Where("org_id = ? AND active = ?", ruleSet.OrgID, true).
+			Update("active", false).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.SCLRuleSet{}).Where("id = ?", ruleSet.ID).Updates(map[string]interface{}{
+			"active":       true,
+			"activated_at": time.Now(),
+		}).Error
+	})
+}
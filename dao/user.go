@@ -75,3 +75,15 @@ func (dao *UserDao) UpdatePassword(userId int64, hashedPwd string) error {
 		"update_time": time.Now(),
 	}).Error
 }
+
+// ListByAccounts 根据账号批量查询用户
+func (dao *UserDao) ListByAccounts(accounts []string) ([]models.User, error) {
+	var list []models.User
+	if len(accounts) == 0 {
+		return list, nil
+	}
+	if err := dao.Where("account IN ?", accounts).Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
@@ -7,7 +7,9 @@ import (
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 	"mental/config"
+	"mental/dao"
 	"mental/routers"
+	"mental/service"
 	"mental/utils"
 	"time"
 )
@@ -20,6 +22,20 @@ import (
 func main() {
 	config.InitAll() // 初始化所有配置
 
+	// 自动建表/补充字段
+	if err := dao.AutoMigrate(config.DB); err != nil {
+		fmt.Printf("数据表迁移失败: %v\n", err)
+		return
+	}
+	// 标记没有逐题作答的历史测评记录，其总分、阳性项目数等为按因子分估算的值
+	if err := service.BackfillSCLEstimated(); err != nil {
+		fmt.Printf("标记估算测评记录失败: %v\n", err)
+	}
+	// 为历史测评记录补算总体等级，用于按等级筛选和排序
+	if err := service.BackfillSCLLevels(); err != nil {
+		fmt.Printf("补算测评等级失败: %v\n", err)
+	}
+
 	// 创建 Gin 实例
 	r := gin.Default()
 
@@ -56,6 +72,15 @@ func main() {
 	routers.InitUserRouter(r)
 	routers.InitCommonRouter(r)
 	routers.InitSCLRouter(r)
+	routers.InitNormRouter(r)
+	routers.InitScaleRouter(r)
+	routers.InitAlertRouter(r)
+	routers.InitFollowUpRouter(r)
+	routers.InitAppointmentRouter(r)
+	routers.InitNotificationRouter(r)
+	routers.InitOrgRouter(r)
+	routers.InitDataScopeRouter(r)
+	routers.InitCampaignRouter(r)
 
 	// 自动生成 swagger 文档
 	err := utils.RunSwagInit()
@@ -78,6 +103,9 @@ func main() {
 		fmt.Printf("缓存接口权限失败: %v\n", err)
 	}
 
+	// 启动预约提醒后台任务
+	service.StartAppointmentReminder()
+
 	// 启动服务
 	r.Run()
 }
@@ -70,8 +70,10 @@ func JWTMiddleWare() gin.HandlerFunc { // gin.HandlerFunc用于定义中间件
 
 		// 获取用户权限列表
 		var permissions []string
+		roleIDs := make([]string, 0, len(roles))
 		for _, role := range roles {
 			roleID := fmt.Sprintf("%v", role)
+			roleIDs = append(roleIDs, roleID)
 
 			// 先查询 Redis 是否有缓存的权限列表
 			rolePermissions, err := utils.SMembers(constant.RolePermissionPrefix + roleID)
@@ -165,6 +167,7 @@ func JWTMiddleWare() gin.HandlerFunc { // gin.HandlerFunc用于定义中间件
 		// 令牌校验成功，将必要信息存入gin上下文中
 		c.Set("id", userId)
 		c.Set("account", claims["account"].(string))
+		c.Set("roles", roleIDs) // 角色id列表，用于解析数据范围
 
 		// 放行
 		c.Next()
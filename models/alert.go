@@ -0,0 +1,81 @@
+package models
+
+import "time"
+
+// 预警状态
+const (
+	AlertStatusNew          = "new"          // 新建，待处理
+	AlertStatusAcknowledged = "acknowledged" // 已确认
+	AlertStatusFollowUp     = "follow_up"    // 跟进中
+	AlertStatusClosed       = "closed"       // 已关闭
+)
+
+// AlertMetricLevel 预警规则按结构化分析的总体等级判断时使用的指标名，值为等级严重程度（轻度 1、中度 2、重度 3）
+const AlertMetricLevel = "level"
+
+// AlertRule 表示 alert_rule 表的结构体，记录高风险预警的触发阈值
+type AlertRule struct {
+	ID        int64     `json:"id" gorm:"primaryKey;autoIncrement;comment:主键ID"`
+	Name      string    `json:"name" gorm:"type:varchar(50);not null;comment:规则名称"`
+	Metric    string    `json:"metric" gorm:"type:varchar(30);not null;comment:指标（因子字段名、total_score/positive_items/gsi 或 level）"`
+	Min       float64   `json:"min" gorm:"type:decimal(8,2);not null;comment:阈值（含）"`
+	Level     string    `json:"level" gorm:"type:varchar(20);not null;comment:预警等级 mild/moderate/severe"`
+	Enabled   bool      `json:"enabled" gorm:"not null;default:true;comment:是否启用"`
+	CreatedBy int64     `json:"created_by" gorm:"comment:创建人ID"`
+	CreatedAt time.Time `json:"created_at" gorm:"type:timestamp;autoCreateTime;comment:创建时间"`
+}
+
+// TableName 指定表名为 alert_rule
+func (AlertRule) TableName() string {
+	return "alert_rule"
+}
+
+// Alert 表示 alert 表的结构体，记录一条高风险预警及其处理状态
+// 同一测评记录最多有一条未关闭的预警，记录更新后重新评估时更新该预警；通用量表的单题预警（如 PHQ-9 第 9 题）关联作答记录，SCLID 为 0
+type Alert struct {
+	ID             int64      `json:"id" gorm:"primaryKey;autoIncrement;comment:主键ID"`
+	SCLID          int64      `json:"scl_id" gorm:"column:scl_id;not null;index;comment:触发预警的测评记录ID，通用量表的预警为 0"`
+	ResponseID     *int64     `json:"response_id,omitempty" gorm:"column:response_id;index;comment:触发预警的通用量表作答记录ID"`
+	StudentID      *int64     `json:"student_id,omitempty" gorm:"column:student_id;index;comment:学生ID"`
+	StudentName    string     `json:"student_name" gorm:"type:varchar(50);comment:学生姓名"`
+	TestDate       CustomTime `json:"test_date" gorm:"type:date;comment:测评日期"`
+	Level          string     `json:"level" gorm:"type:varchar(20);not null;index;comment:预警等级"`
+	Reasons        string     `json:"-" gorm:"type:text;not null;comment:触发原因（JSON）"`
+	Status         string     `json:"status" gorm:"type:varchar(20);not null;index;comment:状态 new/acknowledged/follow_up/closed"`
+	AssigneeRoleID int        `json:"assignee_role_id" gorm:"not null;comment:负责的角色ID（辅导员）"`
+	AssigneeID     *int64     `json:"assignee_id,omitempty" gorm:"index;comment:负责人ID"`
+	CreatedAt      time.Time  `json:"created_at" gorm:"type:timestamp;autoCreateTime;comment:创建时间"`
+	UpdatedAt      time.Time  `json:"updated_at" gorm:"type:timestamp;autoUpdateTime;comment:更新时间"`
+	ClosedAt       *time.Time `json:"closed_at,omitempty" gorm:"type:timestamp;comment:关闭时间"`
+}
+
+// TableName 指定表名为 alert
+func (Alert) TableName() string {
+	return "alert"
+}
+
+// AlertReason 预警触发原因，存于 Alert.Reasons
+type AlertReason struct {
+	RuleID  int64   `json:"rule_id"` // 规则ID，0 为内置规则
+	Metric  string  `json:"metric"`  // 指标
+	Min     float64 `json:"min"`     // 阈值
+	Value   float64 `json:"value"`   // 实际值
+	Level   string  `json:"level"`   // 预警等级
+	Message string  `json:"message"` // 说明
+}
+
+// AlertNote 表示 alert_note 表的结构体，记录预警的处理备注与状态变更
+type AlertNote struct {
+	ID         int64     `json:"id" gorm:"primaryKey;autoIncrement;comment:主键ID"`
+	AlertID    int64     `json:"alert_id" gorm:"column:alert_id;not null;index;comment:所属预警ID"`
+	AuthorID   int64     `json:"author_id" gorm:"not null;comment:记录人ID"`
+	FromStatus string    `json:"from_status" gorm:"type:varchar(20);comment:变更前状态（仅状态变更）"`
+	ToStatus   string    `json:"to_status" gorm:"type:varchar(20);comment:变更后状态（仅状态变更）"`
+	Content    string    `json:"content" gorm:"type:text;comment:备注内容"`
+	CreatedAt  time.Time `json:"created_at" gorm:"type:timestamp;autoCreateTime;comment:创建时间"`
+}
+
+// TableName 指定表名为 alert_note
+func (AlertNote) TableName() string {
+	return "alert_note"
+}
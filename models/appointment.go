@@ -0,0 +1,62 @@
+package models
+
+import "time"
+
+// 咨询时段状态
+const (
+	SlotStatusOpen   = "open"   // 可预约
+	SlotStatusBooked = "booked" // 已被预约
+	SlotStatusClosed = "closed" // 已关闭
+)
+
+// 预约状态
+const (
+	AppointmentStatusBooked    = "booked"    // 已预约
+	AppointmentStatusCancelled = "cancelled" // 已取消
+	AppointmentStatusCompleted = "completed" // 已完成
+	AppointmentStatusNoShow    = "no_show"   // 学生未到
+)
+
+// AppointmentSlot 表示 appointment_slot 表的结构体，记录辅导员发布的可预约咨询时段
+// 每个时段只接受一个预约，预约取消后时段重新开放
+type AppointmentSlot struct {
+	ID          int64     `json:"id" gorm:"primaryKey;autoIncrement;comment:主键ID"`
+	CounselorID int64     `json:"counselor_id" gorm:"not null;index;comment:辅导员ID"`
+	StartAt     time.Time `json:"start_at" gorm:"type:datetime;not null;index;comment:开始时间"`
+	EndAt       time.Time `json:"end_at" gorm:"type:datetime;not null;comment:结束时间"`
+	Location    string    `json:"location" gorm:"type:varchar(100);comment:咨询地点"`
+	Remark      string    `json:"remark" gorm:"type:varchar(255);comment:备注"`
+	Status      string    `json:"status" gorm:"type:varchar(20);not null;index;comment:状态 open/booked/closed"`
+	CreatedAt   time.Time `json:"created_at" gorm:"type:timestamp;autoCreateTime;comment:创建时间"`
+}
+
+// TableName 指定表名为 appointment_slot
+func (AppointmentSlot) TableName() string {
+	return "appointment_slot"
+}
+
+// Appointment 表示 appointment 表的结构体，记录学生对咨询时段的预约
+// 时间、地点与姓名在预约时从时段和用户信息复制，便于日历与导出
+type Appointment struct {
+	ID             int64      `json:"id" gorm:"primaryKey;autoIncrement;comment:主键ID"`
+	SlotID         int64      `json:"slot_id" gorm:"column:slot_id;not null;index;comment:咨询时段ID"`
+	StudentID      int64      `json:"student_id" gorm:"column:student_id;not null;index;comment:学生ID"`
+	StudentName    string     `json:"student_name" gorm:"type:varchar(50);comment:学生姓名"`
+	CounselorID    int64      `json:"counselor_id" gorm:"not null;index;comment:辅导员ID"`
+	CounselorName  string     `json:"counselor_name" gorm:"type:varchar(50);comment:辅导员姓名"`
+	StartAt        time.Time  `json:"start_at" gorm:"type:datetime;not null;index;comment:开始时间"`
+	EndAt          time.Time  `json:"end_at" gorm:"type:datetime;not null;comment:结束时间"`
+	Location       string     `json:"location" gorm:"type:varchar(100);comment:咨询地点"`
+	Reason         string     `json:"reason" gorm:"type:varchar(500);comment:预约事由"`
+	Status         string     `json:"status" gorm:"type:varchar(20);not null;index;comment:状态 booked/cancelled/completed/no_show"`
+	CancelReason   string     `json:"cancel_reason,omitempty" gorm:"type:varchar(255);comment:取消原因"`
+	CancelledBy    *int64     `json:"cancelled_by,omitempty" gorm:"comment:取消人ID"`
+	ReminderSentAt *time.Time `json:"reminder_sent_at,omitempty" gorm:"type:timestamp;comment:提醒发送时间"`
+	CreatedAt      time.Time  `json:"created_at" gorm:"type:timestamp;autoCreateTime;comment:创建时间"`
+	UpdatedAt      time.Time  `json:"updated_at" gorm:"type:timestamp;autoUpdateTime;comment:更新时间"`
+}
+
+// TableName 指定表名为 appointment
+func (Appointment) TableName() string {
+	return "appointment"
+}
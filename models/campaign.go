@@ -0,0 +1,55 @@
+package models
+
+import "time"
+
+// 筛查批次状态，由当前时间与开放窗口计算得出，不落库
+const (
+	CampaignStatusPending = "pending" // 未开始
+	CampaignStatusOpen    = "open"    // 进行中
+	CampaignStatusClosed  = "closed"  // 已结束
+)
+
+// Campaign 表示 campaign 表的结构体，记录一次批量筛查（如每年 9 月的新生筛查）
+// 学生在开放窗口内完成该量表的测评时，记录自动关联到其班级所属的筛查批次
+type Campaign struct {
+	ID        int64     `json:"id" gorm:"primaryKey;autoIncrement;comment:主键ID"`
+	Name      string    `json:"name" gorm:"type:varchar(100);not null;comment:批次名称"`
+	ScaleCode string    `json:"scale_code" gorm:"type:varchar(30);not null;index;comment:使用的量表编码"`
+	OpenAt    time.Time `json:"open_at" gorm:"type:datetime;not null;comment:开放时间"`
+	CloseAt   time.Time `json:"close_at" gorm:"type:datetime;not null;comment:截止时间"`
+	Remark    string    `json:"remark" gorm:"type:varchar(255);comment:备注"`
+	CreatedBy int64     `json:"created_by" gorm:"comment:创建人ID"`
+	CreatedAt time.Time `json:"created_at" gorm:"type:timestamp;autoCreateTime;comment:创建时间"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"type:timestamp;autoUpdateTime;comment:更新时间"`
+
+	Targets []CampaignTarget `json:"targets" gorm:"foreignKey:CampaignID"` // 目标组织
+	Status  string           `json:"status" gorm:"-"`                      // 状态，查询时计算
+}
+
+// TableName 指定表名为 campaign
+func (Campaign) TableName() string {
+	return "campaign"
+}
+
+// StatusAt 按指定时间计算批次状态
+func (c Campaign) StatusAt(now time.Time) string {
+	if now.Before(c.OpenAt) {
+		return CampaignStatusPending
+	}
+	if now.After(c.CloseAt) {
+		return CampaignStatusClosed
+	}
+	return CampaignStatusOpen
+}
+
+// CampaignTarget 表示 campaign_target 表的结构体，记录筛查批次的目标组织（含其全部下级班级）
+type CampaignTarget struct {
+	ID         int64 `json:"id" gorm:"primaryKey;autoIncrement;comment:主键ID"`
+	CampaignID int64 `json:"campaign_id" gorm:"not null;index;comment:筛查批次ID"`
+	OrgID      int64 `json:"org_id" gorm:"not null;comment:目标组织ID"`
+}
+
+// TableName 指定表名为 campaign_target
+func (CampaignTarget) TableName() string {
+	return "campaign_target"
+}
@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+// 数据范围，自小到大为 本人 → 所负责班级 → 所在院系 → 全部
+const (
+	DataScopeSelf       = "self"       // 仅本人的数据
+	DataScopeClass      = "class"      // 所负责组织（班级）内学生的数据
+	DataScopeDepartment = "department" // 所负责组织所在院系（学校下一级组织）内学生的数据
+	DataScopeAll        = "all"        // 全部数据
+)
+
+// RoleDataScope 表示 role_data_scope 表的结构体，记录角色可访问的数据范围
+// 用户拥有多个角色时取最大的范围；未配置的角色使用配置文件中的默认范围
+type RoleDataScope struct {
+	ID        int64     `json:"id" gorm:"primaryKey;autoIncrement;comment:主键ID"`
+	RoleID    int       `json:"role_id" gorm:"not null;uniqueIndex;comment:角色ID"`
+	Scope     string    `json:"scope" gorm:"type:varchar(20);not null;comment:数据范围 self/class/department/all"`
+	UpdatedBy int64     `json:"updated_by" gorm:"comment:最近修改人ID"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"type:timestamp;autoUpdateTime;comment:更新时间"`
+}
+
+// TableName 指定表名为 role_data_scope
+func (RoleDataScope) TableName() string {
+	return "role_data_scope"
+}
+
+// OrgManager 表示 org_manager 表的结构体，记录辅导员等教职工负责的组织，是 class/department 数据范围的依据
+type OrgManager struct {
+	ID        int64     `json:"id" gorm:"primaryKey;autoIncrement;comment:主键ID"`
+	UserID    int64     `json:"user_id" gorm:"not null;uniqueIndex:idx_org_manager;comment:用户ID"`
+	OrgID     int64     `json:"org_id" gorm:"not null;uniqueIndex:idx_org_manager;index;comment:负责的组织ID"`
+	CreatedAt time.Time `json:"created_at" gorm:"type:timestamp;autoCreateTime;comment:创建时间"`
+}
+
+// TableName 指定表名为 org_manager
+func (OrgManager) TableName() string {
+	return "org_manager"
+}
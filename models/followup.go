@@ -0,0 +1,52 @@
+package models
+
+import "time"
+
+// 干预方式
+const (
+	InterventionInterview = "interview" // 面谈
+	InterventionPhone     = "phone"     // 电话/线上沟通
+	InterventionGroup     = "group"     // 团体辅导
+	InterventionParent    = "parent"    // 家校沟通
+	InterventionReferral  = "referral"  // 转介专业机构
+	InterventionObserve   = "observe"   // 观察随访
+	InterventionOther     = "other"     // 其他
+)
+
+// FollowUp 表示 follow_up 表的结构体，记录辅导员对学生的一次跟进/干预
+type FollowUp struct {
+	ID               int64       `json:"id" gorm:"primaryKey;autoIncrement;comment:主键ID"`
+	StudentID        int64       `json:"student_id" gorm:"column:student_id;not null;index;comment:学生ID"`
+	SCLID            *int64      `json:"scl_id,omitempty" gorm:"column:scl_id;index;comment:关联的测评记录ID"`
+	AlertID          *int64      `json:"alert_id,omitempty" gorm:"column:alert_id;index;comment:关联的预警ID"`
+	CounselorID      int64       `json:"counselor_id" gorm:"not null;index;comment:记录人（辅导员）ID"`
+	InterventionType string      `json:"intervention_type" gorm:"type:varchar(20);not null;comment:干预方式"`
+	FollowUpDate     CustomTime  `json:"follow_up_date" gorm:"type:date;not null;comment:跟进日期"`
+	Notes            string      `json:"notes" gorm:"type:text;comment:访谈记录"`
+	Outcome          string      `json:"outcome" gorm:"type:varchar(255);comment:跟进结果"`
+	NextFollowUpDate *CustomTime `json:"next_follow_up_date,omitempty" gorm:"type:date;index;comment:下次跟进日期"`
+	CreatedAt        time.Time   `json:"created_at" gorm:"type:timestamp;autoCreateTime;comment:创建时间"`
+	UpdatedAt        time.Time   `json:"updated_at" gorm:"type:timestamp;autoUpdateTime;comment:更新时间"`
+
+	Attachments []FollowUpAttachment `json:"attachments" gorm:"foreignKey:FollowUpID"`
+}
+
+// TableName 指定表名为 follow_up
+func (FollowUp) TableName() string {
+	return "follow_up"
+}
+
+// FollowUpAttachment 表示 follow_up_attachment 表的结构体，记录跟进记录的附件
+// 附件先通过 /common/upload 上传，这里只保存文件ID（MD5）及其存储路径
+type FollowUpAttachment struct {
+	ID         int64  `json:"id" gorm:"primaryKey;autoIncrement;comment:主键ID"`
+	FollowUpID int64  `json:"follow_up_id" gorm:"column:follow_up_id;not null;index;comment:所属跟进记录ID"`
+	FileID     string `json:"file_id" gorm:"column:file_id;type:varchar(64);not null;comment:文件ID（MD5）"`
+	Name       string `json:"name" gorm:"type:varchar(255);comment:附件名称"`
+	Path       string `json:"path" gorm:"type:varchar(255);comment:文件存储路径"`
+}
+
+// TableName 指定表名为 follow_up_attachment
+func (FollowUpAttachment) TableName() string {
+	return "follow_up_attachment"
+}
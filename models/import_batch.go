@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// 导入模式
+const (
+	ImportModePartial = "partial" // 逐行导入，校验或写入失败的行跳过（默认）
+	ImportModeAtomic  = "atomic"  // 全部成功才导入，任一行失败则整批不导入
+)
+
+// 导入批次状态
+const (
+	ImportBatchCompleted  = "completed"   // 已导入
+	ImportBatchFailed     = "failed"      // 全部成功模式下有行失败，未导入任何记录
+	ImportBatchRolledBack = "rolled_back" // 已回滚
+)
+
+// ImportBatch 表示 import_batch 表的结构体，记录一次 Excel 导入，导入的测评记录通过 scl.import_batch_id 关联
+type ImportBatch struct {
+	ID           int64      `json:"id" gorm:"primaryKey;autoIncrement;comment:主键ID"`
+	FileID       string     `json:"file_id" gorm:"type:varchar(64);not null;index;comment:导入文件ID（MD5）"`
+	ProfileID    int64      `json:"profile_id" gorm:"not null;default:0;comment:使用的映射方案ID，0 为内置方案"`
+	Mode         string     `json:"mode" gorm:"type:varchar(20);not null;comment:导入模式 partial/atomic"`
+	Status       string     `json:"status" gorm:"type:varchar(20);not null;index;comment:状态 completed/failed/rolled_back"`
+	SuccessCount int        `json:"success_count" gorm:"not null;default:0;comment:导入成功的行数"`
+	ErrorCount   int        `json:"error_count" gorm:"not null;default:0;comment:失败的行数"`
+	CreatedBy    int64      `json:"created_by" gorm:"index;comment:导入人ID"`
+	CreatedAt    time.Time  `json:"created_at" gorm:"type:timestamp;autoCreateTime;comment:导入时间"`
+	RolledBackBy *int64     `json:"rolled_back_by" gorm:"comment:回滚人ID"`
+	RolledBackAt *time.Time `json:"rolled_back_at" gorm:"type:timestamp;comment:回滚时间"`
+}
+
+// TableName 指定表名为 import_batch
+func (ImportBatch) TableName() string {
+	return "import_batch"
+}
@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// 导入任务状态
+const (
+	ImportJobPending   = "pending"   // 已创建，等待执行
+	ImportJobRunning   = "running"   // 正在解析和导入
+	ImportJobCompleted = "completed" // 已完成
+	ImportJobFailed    = "failed"    // 失败（文件无法读取，或全部成功模式下有行失败）
+	ImportJobCanceled  = "canceled"  // 已取消，已写入的记录已撤销
+)
+
+// ImportJob 后台 Excel 导入任务，以 JSON 保存在 Redis 中，不落库
+type ImportJob struct {
+	ID         string    `json:"id"`          // 任务ID
+	FileID     string    `json:"file_id"`     // 导入文件ID
+	UserID     int64     `json:"user_id"`     // 发起人ID
+	Status     string    `json:"status"`      // 任务状态
+	Total      int       `json:"total"`       // 数据行数，文件解析完成后才有
+	Processed  int       `json:"processed"`   // 已处理行数
+	Failed     int       `json:"failed"`      // 失败行数
+	BatchID    int64     `json:"batch_id"`    // 导入批次ID
+	SuccessNum int       `json:"success_num"` // 导入成功的行数
+	ErrorRows  []string  `json:"error_rows"`  // 错误信息
+	ErrorFile  string    `json:"error_file"`  // 失败行文件下载地址
+	CreatedAt  time.Time `json:"created_at"`  // 创建时间
+	UpdatedAt  time.Time `json:"updated_at"`  // 最近更新时间
+}
+
+// Finished 任务是否已结束
+func (j *ImportJob) Finished() bool {
+	return j.Status == ImportJobCompleted || j.Status == ImportJobFailed || j.Status == ImportJobCanceled
+}
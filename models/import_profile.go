@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+// ImportProfile 表示 import_profile 表的结构体，记录 SCL 测评数据 Excel 导入的列映射方案
+// 各组织可保存多个方案并启用其中一个，导入时由组织自身逐级向上查找启用的方案
+type ImportProfile struct {
+	ID          int64      `json:"id" gorm:"primaryKey;autoIncrement;comment:主键ID"`
+	Name        string     `json:"name" gorm:"type:varchar(50);not null;comment:方案名称"`
+	OrgID       int64      `json:"org_id" gorm:"column:org_id;not null;default:0;index;comment:适用组织ID，0 为全局"`
+	Content     string     `json:"content" gorm:"type:text;not null;comment:映射内容（JSON）"`
+	Remark      string     `json:"remark" gorm:"type:varchar(255);comment:备注"`
+	Active      bool       `json:"active" gorm:"not null;default:false;comment:是否启用"`
+	CreatedBy   int64      `json:"created_by" gorm:"comment:创建人ID"`
+	CreatedAt   time.Time  `json:"created_at" gorm:"type:timestamp;autoCreateTime;comment:创建时间"`
+	UpdatedAt   time.Time  `json:"updated_at" gorm:"type:timestamp;autoUpdateTime;comment:更新时间"`
+	ActivatedAt *time.Time `json:"activated_at" gorm:"type:timestamp;comment:最近启用时间"`
+}
+
+// TableName 指定表名为 import_profile
+func (ImportProfile) TableName() string {
+	return "import_profile"
+}
+
+// ImportProfileContent 映射方案内容，对应 ImportProfile.Content 中的 JSON
+type ImportProfileContent struct {
+	Sheet      string         `json:"sheet"`       // 读取的工作表名，为空时读取第一个工作表
+	AllSheets  bool           `json:"all_sheets"`  // 是否读取全部工作表（各表表头须符合同一映射），为 true 时忽略 sheet
+	HeaderRow  int            `json:"header_row"`  // 表头所在行，从 1 开始，为空时为 1；其后各行为数据
+	Columns    []ImportColumn `json:"columns"`     // 字段映射
+	ItemsStart string         `json:"items_start"` // 表头中找不到逐题作答列时，90 道题依次所在的起始列（如 F），为空时不按位置读取
+}
+
+// ImportColumn 一个字段的列映射：先按表头名称（含别名）匹配，匹配不到时使用 Column 指定的列
+type ImportColumn struct {
+	Field   string   `json:"field"`   // 字段：student_id/name/gender/age/test_date 或因子字段名
+	Headers []string `json:"headers"` // 表头名称及别名，匹配时忽略空白和大小写
+	Column  string   `json:"column"`  // 兜底列（如 A），为空时只按表头匹配
+}
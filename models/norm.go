@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+// NormGroup 表示 norm_group 表的结构体，记录一个常模组（如全国成人常模、大学生常模）
+type NormGroup struct {
+	ID          int64     `json:"id" gorm:"primaryKey;autoIncrement;comment:主键ID"`
+	Code        string    `json:"code" gorm:"type:varchar(50);not null;uniqueIndex;comment:常模组编码"`
+	Name        string    `json:"name" gorm:"type:varchar(100);not null;comment:常模组名称"`
+	Description string    `json:"description" gorm:"type:varchar(255);comment:来源说明"`
+	IsDefault   bool      `json:"is_default" gorm:"not null;default:false;comment:是否为默认常模"`
+	CreatedAt   time.Time `json:"created_at" gorm:"type:timestamp;autoCreateTime;comment:创建时间"`
+
+	Entries []NormEntry `json:"entries,omitempty" gorm:"foreignKey:GroupID"`
+}
+
+// TableName 指定表名为 norm_group
+func (NormGroup) TableName() string {
+	return "norm_group"
+}
+
+// NormEntry 表示 norm_entry 表的结构体，记录某指标在指定性别、年龄段下的均值和标准差
+type NormEntry struct {
+	ID      int64   `json:"id" gorm:"primaryKey;autoIncrement;comment:主键ID"`
+	GroupID int64   `json:"group_id" gorm:"column:group_id;not null;index;comment:所属常模组ID"`
+	Factor  string  `json:"factor" gorm:"type:varchar(30);not null;comment:指标（因子字段名，或 total_score/positive_items/gsi）"`
+	Gender  int     `json:"gender" gorm:"type:tinyint;not null;default:-1;comment:性别 -1不限 0女 1男"`
+	AgeMin  int     `json:"age_min" gorm:"not null;default:0;comment:年龄下限（含）"`
+	AgeMax  int     `json:"age_max" gorm:"not null;default:150;comment:年龄上限（含）"`
+	Mean    float64 `json:"mean" gorm:"type:decimal(8,3);not null;comment:均值"`
+	SD      float64 `json:"sd" gorm:"column:sd;type:decimal(8,3);not null;comment:标准差"`
+}
+
+// TableName 指定表名为 norm_entry
+func (NormEntry) TableName() string {
+	return "norm_entry"
+}
@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// 站内通知类型
+const (
+	NotificationAppointmentReminder  = "appointment_reminder"  // 预约即将开始提醒
+	NotificationAppointmentBooked    = "appointment_booked"    // 新预约
+	NotificationAppointmentCancelled = "appointment_cancelled" // 预约被取消
+)
+
+// Notification 表示 notification 表的结构体，记录发给用户的站内通知
+type Notification struct {
+	ID        int64      `json:"id" gorm:"primaryKey;autoIncrement;comment:主键ID"`
+	UserID    int64      `json:"user_id" gorm:"not null;index;comment:接收人ID"`
+	Type      string     `json:"type" gorm:"type:varchar(30);not null;comment:通知类型"`
+	Title     string     `json:"title" gorm:"type:varchar(100);not null;comment:标题"`
+	Content   string     `json:"content" gorm:"type:varchar(500);comment:内容"`
+	RefID     int64      `json:"ref_id" gorm:"comment:关联记录ID（如预约ID）"`
+	ReadAt    *time.Time `json:"read_at,omitempty" gorm:"type:timestamp;comment:已读时间"`
+	CreatedAt time.Time  `json:"created_at" gorm:"type:timestamp;autoCreateTime;index;comment:创建时间"`
+}
+
+// TableName 指定表名为 notification
+func (Notification) TableName() string {
+	return "notification"
+}
@@ -0,0 +1,64 @@
+package models
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 组织单元类型，自上而下为 学校 → 学院/年级 → 班级
+const (
+	OrgTypeSchool  = "school"  // 学校
+	OrgTypeCollege = "college" // 学院（系）
+	OrgTypeGrade   = "grade"   // 年级
+	OrgTypeClass   = "class"   // 班级
+)
+
+// OrgUnit 表示 org_unit 表的结构体，记录学校、学院/年级、班级组成的组织树
+// Path 为从根到自身的 ID 路径（如 /1/5/9/），按前缀匹配即可查询整棵子树
+type OrgUnit struct {
+	ID        int64     `json:"id" gorm:"primaryKey;autoIncrement;comment:主键ID"`
+	ParentID  int64     `json:"parent_id" gorm:"not null;default:0;index;comment:上级组织ID，0 为根"`
+	Type      string    `json:"type" gorm:"type:varchar(20);not null;comment:类型 school/college/grade/class"`
+	Name      string    `json:"name" gorm:"type:varchar(50);not null;comment:名称"`
+	Code      string    `json:"code" gorm:"type:varchar(50);not null;uniqueIndex;comment:编码，花名册导入时按编码匹配班级"`
+	Path      string    `json:"path" gorm:"type:varchar(255);not null;index;comment:ID 路径"`
+	Sort      int       `json:"sort" gorm:"not null;default:0;comment:同级排序"`
+	CreatedAt time.Time `json:"created_at" gorm:"type:timestamp;autoCreateTime;comment:创建时间"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"type:timestamp;autoUpdateTime;comment:更新时间"`
+
+	Children []*OrgUnit `json:"children,omitempty" gorm:"-"` // 下级组织，仅组织树返回
+}
+
+// TableName 指定表名为 org_unit
+func (OrgUnit) TableName() string {
+	return "org_unit"
+}
+
+// AncestorIDs 从 Path 解析出自根到自身的组织ID
+func (o OrgUnit) AncestorIDs() []int64 {
+	var ids []int64
+	for _, part := range strings.Split(strings.Trim(o.Path, "/"), "/") {
+		if id, err := strconv.ParseInt(part, 10, 64); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// OrgMember 表示 org_member 表的结构体，记录班级花名册中的学生，一个学号只属于一个班级
+type OrgMember struct {
+	ID        int64     `json:"id" gorm:"primaryKey;autoIncrement;comment:主键ID"`
+	OrgID     int64     `json:"org_id" gorm:"not null;index;comment:所属班级ID"`
+	StudentNo string    `json:"student_no" gorm:"type:varchar(50);not null;uniqueIndex;comment:学号"`
+	Name      string    `json:"name" gorm:"type:varchar(50);not null;comment:姓名"`
+	Gender    int       `json:"gender" gorm:"type:tinyint;not null;comment:性别 0女 1男"`
+	UserID    *int64    `json:"user_id,omitempty" gorm:"index;comment:关联的用户ID（账号与学号一致的用户）"`
+	CreatedAt time.Time `json:"created_at" gorm:"type:timestamp;autoCreateTime;comment:创建时间"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"type:timestamp;autoUpdateTime;comment:更新时间"`
+}
+
+// TableName 指定表名为 org_member
+func (OrgMember) TableName() string {
+	return "org_member"
+}
@@ -0,0 +1,153 @@
+package models
+
+import "time"
+
+// 计分方式
+const (
+	ScaleMethodSum  = "sum"  // 求和
+	ScaleMethodMean = "mean" // 求均值
+)
+
+// 换算取整方式
+const (
+	ScaleRoundNone  = ""      // 不取整（保留两位小数）
+	ScaleRoundFloor = "floor" // 向下取整，如 SDS/SAS 标准分取粗分×1.25 的整数部分
+	ScaleRoundRound = "round" // 四舍五入
+)
+
+// Scale 表示 scale 表的结构体，记录一个量表定义
+// 内置量表（SCL-90、PHQ-9、GAD-7、SDS、SAS）在代码中定义，本表保存新增的量表，新增量表只需录入数据
+type Scale struct {
+	ID          int64     `json:"id" gorm:"primaryKey;autoIncrement;comment:主键ID"`
+	Code        string    `json:"code" gorm:"type:varchar(30);not null;uniqueIndex;comment:量表编码"`
+	Name        string    `json:"name" gorm:"type:varchar(100);not null;comment:量表名称"`
+	Description string    `json:"description" gorm:"type:varchar(255);comment:量表说明"`
+	Definition  string    `json:"definition" gorm:"type:mediumtext;not null;comment:量表定义（JSON）"`
+	Builtin     bool      `json:"builtin" gorm:"-"` // 是否为内置量表，不落库
+	CreatedBy   int64     `json:"created_by" gorm:"comment:创建人ID"`
+	CreatedAt   time.Time `json:"created_at" gorm:"type:timestamp;autoCreateTime;comment:创建时间"`
+}
+
+// TableName 指定表名为 scale
+func (Scale) TableName() string {
+	return "scale"
+}
+
+// ScaleDefinition 量表定义，对应 Scale.Definition 中的 JSON
+type ScaleDefinition struct {
+	Instruction  string          `json:"instruction"`   // 指导语
+	Options      []ScaleOption   `json:"options"`       // 通用选项，题目未单独指定选项时使用
+	Items        []ScaleItem     `json:"items"`         // 题目，题号从 1 开始连续编号
+	Subscales    []ScaleSubscale `json:"subscales"`     // 分量表（因子）
+	Scoring      ScaleScoring    `json:"scoring"`       // 总分计算公式
+	Cutoffs      []ScaleCutoff   `json:"cutoffs"`       // 按换算后的总分分级，按 min 从高到低匹配
+	NormalResult string          `json:"normal_result"` // 未达到任何分级时的结论
+	ItemFlags    []ScaleItemFlag `json:"item_flags"`    // 单题预警，如 PHQ-9 第 9 题
+	TimeLimit    int             `json:"time_limit"`    // 在线作答时限（分钟），0 使用默认时限
+
+	ConsistencyPairs []ScaleConsistencyPair `json:"consistency_pairs"` // 一致性题对，用于作答有效性检查
+}
+
+// ScaleOption 选项
+type ScaleOption struct {
+	Score int    `json:"score"` // 选项分值
+	Label string `json:"label"` // 选项文字
+}
+
+// ScaleItem 题目
+type ScaleItem struct {
+	No      int           `json:"no"`                // 题号
+	Text    string        `json:"text"`              // 题干
+	Reverse bool          `json:"reverse,omitempty"` // 是否反向计分：计分 = 最低分 + 最高分 - 作答分值
+	Options []ScaleOption `json:"options,omitempty"` // 本题选项，为空时使用通用选项
+}
+
+// ScaleSubscale 分量表
+type ScaleSubscale struct {
+	Key    string `json:"key"`    // 分量表编码
+	Name   string `json:"name"`   // 分量表名称
+	Items  []int  `json:"items"`  // 包含的题号
+	Method string `json:"method"` // 计分方式：sum/mean
+}
+
+// ScaleScoring 总分计算公式：换算分 = 取整(粗分 × multiplier)，粗分按 method 由全部题目计分得到
+type ScaleScoring struct {
+	Method     string  `json:"method"`     // 计分方式：sum/mean
+	Multiplier float64 `json:"multiplier"` // 换算系数，0 视为 1
+	Round      string  `json:"round"`      // 取整方式：空/floor/round
+}
+
+// ScaleCutoff 分级界值
+type ScaleCutoff struct {
+	Min    float64 `json:"min"`    // 阈值（含）
+	Level  string  `json:"level"`  // 等级：mild/moderate/severe
+	Result string  `json:"result"` // 结论
+}
+
+// ScaleItemFlag 单题预警
+type ScaleItemFlag struct {
+	Item    int    `json:"item"`    // 题号
+	Min     int    `json:"min"`     // 作答分值达到 min 即预警
+	Level   string `json:"level"`   // 预警等级
+	Message string `json:"message"` // 预警说明
+}
+
+// ScaleConsistencyPair 一致性题对：内容相近的两道题，反向计分换算后分差超过 max_diff 视为前后矛盾
+type ScaleConsistencyPair struct {
+	A       int `json:"a"`        // 题号
+	B       int `json:"b"`        // 题号
+	MaxDiff int `json:"max_diff"` // 允许的最大分差
+}
+
+// ScaleResponse 表示 scale_response 表的结构体，记录一次量表作答的计分结果
+type ScaleResponse struct {
+	ID         int64      `json:"id" gorm:"primaryKey;autoIncrement;comment:主键ID"`
+	ScaleCode  string     `json:"scale_code" gorm:"type:varchar(30);not null;index;comment:量表编码"`
+	StudentID  *int64     `json:"student_id,omitempty" gorm:"column:student_id;index;comment:学生ID"`
+	TestDate   CustomTime `json:"test_date" gorm:"type:date;not null;comment:测评日期"`
+	RawScore   float64    `json:"raw_score" gorm:"type:decimal(8,2);not null;comment:粗分"`
+	Score      float64    `json:"score" gorm:"type:decimal(8,2);not null;comment:换算后总分"`
+	Level      string     `json:"level" gorm:"type:varchar(20);not null;comment:等级"`
+	Result     string     `json:"result" gorm:"type:varchar(100);comment:结论"`
+	Subscores  string     `json:"-" gorm:"type:text;comment:分量表得分（JSON）"`
+	Flags      string     `json:"-" gorm:"type:text;comment:单题预警（JSON）"`
+	Invalid    bool       `json:"invalid" gorm:"not null;default:false;index;comment:是否为无效作答（有效性检查未通过）"`
+	Validity   string     `json:"-" gorm:"type:text;comment:作答有效性指标（JSON）"`
+	CampaignID *int64     `json:"campaign_id,omitempty" gorm:"index;comment:所属筛查批次ID，按开放窗口和班级自动关联"`
+	CreatedAt  time.Time  `json:"created_at" gorm:"type:timestamp;autoCreateTime;comment:创建时间"`
+
+	Answers []int `json:"answers,omitempty" gorm:"-"` // 按题号顺序的作答分值，不落库（逐题作答见 scale_answer）
+}
+
+// TableName 指定表名为 scale_response
+func (ScaleResponse) TableName() string {
+	return "scale_response"
+}
+
+// ScaleAnswer 表示 scale_answer 表的结构体，记录量表每道题目的原始作答（反向计分前）
+type ScaleAnswer struct {
+	ID         int64 `json:"id" gorm:"primaryKey;autoIncrement;comment:主键ID"`
+	ResponseID int64 `json:"response_id" gorm:"column:response_id;not null;index;comment:所属作答记录ID"`
+	ItemNo     int   `json:"item_no" gorm:"column:item_no;not null;comment:题号"`
+	Score      int   `json:"score" gorm:"type:tinyint;not null;comment:作答分值"`
+}
+
+// TableName 指定表名为 scale_answer
+func (ScaleAnswer) TableName() string {
+	return "scale_answer"
+}
+
+// ScaleSubscore 分量表得分，存于 ScaleResponse.Subscores
+type ScaleSubscore struct {
+	Key   string  `json:"key"`   // 分量表编码
+	Name  string  `json:"name"`  // 分量表名称
+	Score float64 `json:"score"` // 得分
+}
+
+// ScaleFlag 命中的单题预警，存于 ScaleResponse.Flags
+type ScaleFlag struct {
+	Item    int    `json:"item"`    // 题号
+	Score   int    `json:"score"`   // 作答分值
+	Level   string `json:"level"`   // 预警等级
+	Message string `json:"message"` // 预警说明
+}
@@ -64,8 +64,25 @@ type SCL struct {
 	Psychoticism  float32 `json:"psychoticism" gorm:"type:decimal(3,1);not null;comment:精神病性"`
 	Other         float32 `json:"other" gorm:"type:decimal(3,1);not null;comment:其他"`
 
-	TotalScore    float64 `json:"total_score" gorm:"type:int;default:0;comment:总分（估算值）"`
-	PositiveItems float64 `json:"positive_items" gorm:"type:int;default:0;comment:阳性项目数（因子>=2的数量）"`
+	TotalScore    float64 `json:"total_score" gorm:"type:int;default:0;comment:总分（90 题得分之和，仅有因子分时为估算值）"`
+	PositiveItems float64 `json:"positive_items" gorm:"type:int;default:0;comment:阳性项目数（单题>=2分的题数；仅有因子分时为估算值：因子均分>=2则该因子题目全部计为阳性）"`
+	NegativeItems float64 `json:"negative_items" gorm:"type:int;default:0;comment:阴性项目数（单题=1分的题数，即 90 - 阳性项目数）"`
+	GSI           float64 `json:"gsi" gorm:"column:gsi;type:decimal(4,2);default:0;comment:总症状指数（总均分）"`
+	PSDI          float64 `json:"psdi" gorm:"column:psdi;type:decimal(4,2);default:0;comment:阳性症状均分"`
+	// IndicesEstimated 为 true 时记录没有逐题作答，总分、阳性/阴性项目数、GSI、PSDI 均由因子分估算，并非实测值
+	IndicesEstimated bool `json:"indices_estimated" gorm:"not null;default:false;comment:总分、阳性/阴性项目数等全局指数是否由因子分估算（没有逐题作答）"`
+
+	RuleSetID int64  `json:"rule_set_id" gorm:"column:rule_set_id;not null;default:0;comment:生成结论所用规则集ID，0 为内置规则"`
+	Level     string `json:"-" gorm:"type:varchar(20);not null;default:'';index;comment:总体等级（按所用规则集计算，用于筛选和排序）"`
+
+	CampaignID    *int64 `json:"campaign_id,omitempty" gorm:"index;comment:所属筛查批次ID，按开放窗口和班级自动关联"`
+	ImportBatchID *int64 `json:"import_batch_id,omitempty" gorm:"index;comment:导入批次ID，通过 Excel 导入的记录才有"`
+
+	Invalid  bool   `json:"invalid" gorm:"not null;default:false;index;comment:是否为无效作答（有效性检查未通过）"`
+	Validity string `json:"-" gorm:"type:text;comment:作答有效性指标（JSON），仅逐题作答的记录有"`
+
+	Items           []int `json:"items,omitempty" gorm:"-"` // 90 道题的原始作答（1-5），提交时可替代因子分
+	DurationSeconds *int  `json:"-" gorm:"-"`               // 作答用时（秒），仅在线测评提交时有
 
 	CreatedAt time.Time      `json:"-" gorm:"type:timestamp;autoCreateTime;comment:记录创建时间"`
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"` // 软删除字段，代表删除时间
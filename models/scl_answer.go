@@ -0,0 +1,14 @@
+package models
+
+// SCLAnswer 表示 scl_answer 表的结构体，记录 SCL-90 每道题目的原始作答
+type SCLAnswer struct {
+	ID     int64 `json:"id" gorm:"primaryKey;autoIncrement;comment:主键ID"`
+	SCLID  int64 `json:"scl_id" gorm:"column:scl_id;not null;index;comment:所属测评记录ID"`
+	ItemNo int   `json:"item_no" gorm:"column:item_no;type:tinyint;not null;comment:题号 1-90"`
+	Score  int   `json:"score" gorm:"type:tinyint;not null;comment:作答分值 1-5"`
+}
+
+// TableName 指定表名为 scl_answer
+func (SCLAnswer) TableName() string {
+	return "scl_answer"
+}
@@ -0,0 +1,63 @@
+package models
+
+import "time"
+
+// 规则比较依据
+const (
+	SCLCriterionRaw    = "raw"     // 原始分（默认）
+	SCLCriterionTScore = "t_score" // 常模 T 分
+)
+
+// 心理健康等级
+const (
+	SCLLevelNormal   = "normal"   // 基本正常
+	SCLLevelMild     = "mild"     // 轻度
+	SCLLevelModerate = "moderate" // 中度
+	SCLLevelSevere   = "severe"   // 重度
+)
+
+// SCLRuleSet 表示 scl_rule_set 表的结构体，记录版本化的 SCL-90 结果解释规则
+// 规则集创建后内容不可修改，调整规则需创建新版本，保证历史结论可追溯
+type SCLRuleSet struct {
+	ID          int64      `json:"id" gorm:"primaryKey;autoIncrement;comment:主键ID"`
+	Name        string     `json:"name" gorm:"type:varchar(50);not null;comment:规则集名称"`
+	Version     int        `json:"version" gorm:"not null;comment:版本号（同一组织内递增）"`
+	OrgID       int64      `json:"org_id" gorm:"column:org_id;not null;default:0;index;comment:适用组织ID，0 为全局"`
+	Content     string     `json:"content" gorm:"type:text;not null;comment:规则内容（JSON）"`
+	Remark      string     `json:"remark" gorm:"type:varchar(255);comment:备注"`
+	Active      bool       `json:"active" gorm:"not null;default:false;comment:是否启用"`
+	CreatedBy   int64      `json:"created_by" gorm:"comment:创建人ID"`
+	CreatedAt   time.Time  `json:"created_at" gorm:"type:timestamp;autoCreateTime;comment:创建时间"`
+	ActivatedAt *time.Time `json:"activated_at" gorm:"type:timestamp;comment:最近启用时间"`
+}
+
+// TableName 指定表名为 scl_rule_set
+func (SCLRuleSet) TableName() string {
+	return "scl_rule_set"
+}
+
+// SCLRuleContent 规则集内容，对应 SCLRuleSet.Content 中的 JSON
+// 判断优先级：总分 > 阳性项目数 > 因子均分
+type SCLRuleContent struct {
+	TotalRules    []SCLThresholdRule `json:"total_rules"`    // 总分超过 min 即命中，按等级从重到轻、min 从高到低匹配
+	PositiveRules []SCLThresholdRule `json:"positive_rules"` // 阳性项目数超过 min 即命中，匹配顺序同上
+	FactorLevels  []SCLFactorLevel   `json:"factor_levels"`  // 因子均分达到 min 即归入该等级，匹配顺序同上
+	NormalResult  string             `json:"normal_result"`  // 均未命中时的结论
+	NormGroup     string             `json:"norm_group"`     // 按 T 分判断时使用的常模组编码
+}
+
+// SCLThresholdRule 总分/阳性项目数阈值规则
+type SCLThresholdRule struct {
+	Criterion string  `json:"criterion"` // 比较依据：raw 原始值（默认）/ t_score 常模 T 分
+	Min       float64 `json:"min"`       // 阈值（不含）
+	Level     string  `json:"level"`     // 命中后的等级
+	Result    string  `json:"result"`    // 命中后的结论
+}
+
+// SCLFactorLevel 因子分级规则
+type SCLFactorLevel struct {
+	Criterion string  `json:"criterion"` // 比较依据：raw 因子均分（默认）/ t_score 常模 T 分
+	Min       float64 `json:"min"`       // 阈值（含）
+	Level     string  `json:"level"`     // 等级
+	Label     string  `json:"label"`     // 结论前缀，如“重度”
+}
@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// SessionUnanswered 会话中未作答题目的占位分值
+const SessionUnanswered = -1
+
+// AssessmentSession 在线测评会话，以 JSON 保存在 Redis 中，过期时间即作答时限，不落库
+type AssessmentSession struct {
+	ID        string    `json:"id"`         // 会话ID
+	ScaleCode string    `json:"scale_code"` // 量表编码
+	StudentID int64     `json:"student_id"` // 学生ID
+	Name      string    `json:"name"`       // 姓名（SCL-90 必填）
+	Gender    int       `json:"gender"`     // 性别 0女 1男（SCL-90 必填）
+	Age       int       `json:"age"`        // 年龄（SCL-90 必填）
+	Answers   []int     `json:"answers"`    // 按题号顺序的作答分值，未作答为 -1
+	StartedAt time.Time `json:"started_at"` // 开始时间
+	UpdatedAt time.Time `json:"updated_at"` // 最近保存时间
+	ExpiresAt time.Time `json:"expires_at"` // 截止时间
+}
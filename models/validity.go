@@ -0,0 +1,20 @@
+package models
+
+// 作答有效性标记
+const (
+	ValidityLongstring   = "longstring"   // 连续相同作答过长（直线作答）
+	ValidityLowVariance  = "low_variance" // 全部作答相同
+	ValidityTooFast      = "too_fast"     // 作答速度过快
+	ValidityInconsistent = "inconsistent" // 一致性题对前后矛盾
+)
+
+// ResponseValidity 作答有效性指标，以 JSON 存于 SCL.Validity、ScaleResponse.Validity
+type ResponseValidity struct {
+	Longstring        int      `json:"longstring"`         // 最长连续相同作答的题数
+	Variance          float64  `json:"variance"`           // 作答分值的方差
+	DurationSeconds   *int     `json:"duration_seconds"`   // 作答用时（秒），非在线测评提交时为 null
+	SecondsPerItem    *float64 `json:"seconds_per_item"`   // 平均每题用时（秒）
+	PairsChecked      int      `json:"pairs_checked"`      // 检查的一致性题对数
+	InconsistentPairs int      `json:"inconsistent_pairs"` // 前后矛盾的题对数
+	Flags             []string `json:"flags"`              // 未通过的检查，为空表示有效
+}
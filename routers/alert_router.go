@@ -0,0 +1,23 @@
+package routers
+
+import (
+	"github.com/gin-gonic/gin"
+	"mental/controllers/user"
+	"mental/middleware"
+)
+
+// InitAlertRouter 初始化高风险预警路由
+func InitAlertRouter(r *gin.Engine) {
+	alertRouter := r.Group("/alert")
+	{
+		alertRouter.Use(middleware.JWTMiddleWare())
+		alertRouter.GET("/list", user.AlertController{}.ListAlerts)             // 查询预警列表
+		alertRouter.GET("", user.AlertController{}.GetAlert)                    // 查询预警详情
+		alertRouter.POST("/status", user.AlertController{}.ChangeStatus)        // 变更预警状态
+		alertRouter.POST("/assign", user.AlertController{}.Assign)              // 分派预警负责人
+		alertRouter.POST("/note", user.AlertController{}.AddNote)               // 添加处理备注
+		alertRouter.POST("/rule", user.AlertController{}.CreateRule)            // 新建预警规则
+		alertRouter.GET("/rule/list", user.AlertController{}.ListRules)         // 查询预警规则
+		alertRouter.POST("/rule/enable", user.AlertController{}.SetRuleEnabled) // 启用或停用预警规则
+	}
+}
@@ -0,0 +1,24 @@
+package routers
+
+import (
+	"github.com/gin-gonic/gin"
+	"mental/controllers/user"
+	"mental/middleware"
+)
+
+// InitAppointmentRouter 初始化心理咨询预约路由
+func InitAppointmentRouter(r *gin.Engine) {
+	appointmentRouter := r.Group("/appointment")
+	{
+		appointmentRouter.Use(middleware.JWTMiddleWare())
+		appointmentRouter.POST("/slot", user.AppointmentController{}.PublishSlots)           // 发布咨询时段
+		appointmentRouter.POST("/slot/close", user.AppointmentController{}.CloseSlot)        // 关闭咨询时段
+		appointmentRouter.GET("/slot/available", user.AppointmentController{}.ListAvailable) // 查询可预约时段
+		appointmentRouter.POST("/book", user.AppointmentController{}.Book)                   // 预约咨询
+		appointmentRouter.POST("/cancel", user.AppointmentController{}.Cancel)               // 取消预约
+		appointmentRouter.POST("/finish", user.AppointmentController{}.Finish)               // 标记已完成或未到
+		appointmentRouter.GET("/mine", user.AppointmentController{}.ListMine)                // 查询我的预约
+		appointmentRouter.GET("/calendar", user.AppointmentController{}.Calendar)            // 查询预约日历
+		appointmentRouter.GET("/ics", user.AppointmentController{}.ExportICS)                // 导出 iCalendar 日历
+	}
+}
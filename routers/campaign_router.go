@@ -0,0 +1,21 @@
+package routers
+
+import (
+	"github.com/gin-gonic/gin"
+	"mental/controllers/user"
+	"mental/middleware"
+)
+
+// InitCampaignRouter 初始化批量筛查批次路由
+func InitCampaignRouter(r *gin.Engine) {
+	campaignRouter := r.Group("/campaign")
+	{
+		campaignRouter.Use(middleware.JWTMiddleWare())
+		campaignRouter.POST("", user.CampaignController{}.CreateCampaign)        // 新建筛查批次
+		campaignRouter.POST("/update", user.CampaignController{}.UpdateCampaign) // 修改筛查批次
+		campaignRouter.GET("/list", user.CampaignController{}.ListCampaigns)     // 查询筛查批次列表
+		campaignRouter.GET("", user.CampaignController{}.GetCampaign)            // 查询筛查批次详情
+		campaignRouter.GET("/progress", user.CampaignController{}.Progress)      // 查询各班级完成情况
+		campaignRouter.GET("/compare", user.CampaignController{}.Compare)        // 比较多个筛查批次
+	}
+}
@@ -13,6 +13,20 @@ func InitCommonRouter(r *gin.Engine) {
 		commonRouter.Use(middleware.JWTMiddleWare())
 		commonRouter.POST("/check-file", user.FileController{}.Check)
 		commonRouter.POST("/upload", user.FileController{}.Upload)
-		commonRouter.POST("/parse-file", user.FileController{}.ParseFile) // 解析文件
+		commonRouter.POST("/parse-file", user.FileController{}.ParseFile)          // 解析文件（preview=true 时只预览）
+		commonRouter.POST("/parse-file/commit", user.FileController{}.CommitParse) // 确认导入预览过的文件
+
+		commonRouter.POST("/import-profile", user.ImportProfileController{}.CreateProfile)            // 新建导入映射方案
+		commonRouter.POST("/import-profile/update", user.ImportProfileController{}.UpdateProfile)     // 修改导入映射方案
+		commonRouter.GET("/import-profile/list", user.ImportProfileController{}.ListProfiles)         // 查询导入映射方案列表
+		commonRouter.POST("/import-profile/activate", user.ImportProfileController{}.ActivateProfile) // 启用导入映射方案
+		commonRouter.GET("/import-template", user.ImportProfileController{}.Template)                 // 下载空白导入模板
+
+		commonRouter.GET("/import-batch/list", user.ImportBatchController{}.ListBatches)   // 查询导入批次列表
+		commonRouter.POST("/import-batch/rollback", user.ImportBatchController{}.Rollback) // 回滚导入批次
+
+		commonRouter.GET("/import-job", user.ImportJobController{}.GetJob)            // 查询后台导入任务进度
+		commonRouter.GET("/import-job/events", user.ImportJobController{}.Events)     // 推送后台导入任务进度（SSE）
+		commonRouter.POST("/import-job/cancel", user.ImportJobController{}.CancelJob) // 取消后台导入任务
 	}
 }
@@ -0,0 +1,21 @@
+package routers
+
+import (
+	"github.com/gin-gonic/gin"
+	"mental/controllers/user"
+	"mental/middleware"
+)
+
+// InitDataScopeRouter 初始化数据范围路由
+func InitDataScopeRouter(r *gin.Engine) {
+	scopeRouter := r.Group("/datascope")
+	{
+		scopeRouter.Use(middleware.JWTMiddleWare())
+		scopeRouter.POST("/role", user.DataScopeController{}.SetRoleScope)        // 设置角色数据范围
+		scopeRouter.GET("/role/list", user.DataScopeController{}.ListRoleScopes)  // 查询角色数据范围
+		scopeRouter.GET("/mine", user.DataScopeController{}.MyScope)              // 查询当前用户的数据范围
+		scopeRouter.POST("/manager", user.DataScopeController{}.AddManager)       // 指定组织负责人
+		scopeRouter.DELETE("/manager", user.DataScopeController{}.RemoveManager)  // 取消组织负责人
+		scopeRouter.GET("/manager/list", user.DataScopeController{}.ListManagers) // 查询组织负责人
+	}
+}
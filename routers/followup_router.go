@@ -0,0 +1,21 @@
+package routers
+
+import (
+	"github.com/gin-gonic/gin"
+	"mental/controllers/user"
+	"mental/middleware"
+)
+
+// InitFollowUpRouter 初始化辅导员跟进记录路由
+func InitFollowUpRouter(r *gin.Engine) {
+	followUpRouter := r.Group("/followup")
+	{
+		followUpRouter.Use(middleware.JWTMiddleWare())
+		followUpRouter.POST("", user.FollowUpController{}.CreateFollowUp)        // 新建跟进记录
+		followUpRouter.POST("/update", user.FollowUpController{}.UpdateFollowUp) // 修改跟进记录
+		followUpRouter.GET("", user.FollowUpController{}.GetFollowUp)            // 查询跟进记录详情
+		followUpRouter.GET("/list", user.FollowUpController{}.ListFollowUps)     // 查询学生的跟进记录
+		followUpRouter.GET("/due", user.FollowUpController{}.ListDue)            // 查询待跟进的学生
+		followUpRouter.GET("/timeline", user.FollowUpController{}.Timeline)      // 查询学生时间线
+	}
+}
@@ -0,0 +1,19 @@
+package routers
+
+import (
+	"github.com/gin-gonic/gin"
+	"mental/controllers/user"
+	"mental/middleware"
+)
+
+// InitNormRouter 初始化常模路由
+func InitNormRouter(r *gin.Engine) {
+	normRouter := r.Group("/norm")
+	{
+		normRouter.Use(middleware.JWTMiddleWare())
+		normRouter.POST("", user.NormController{}.CreateGroup)        // 新建常模组
+		normRouter.POST("/import", user.NormController{}.ImportGroup) // 从Excel导入常模组
+		normRouter.GET("/list", user.NormController{}.ListGroups)     // 查询常模组列表
+		normRouter.POST("/default", user.NormController{}.SetDefault) // 设置默认常模组
+	}
+}
@@ -0,0 +1,17 @@
+package routers
+
+import (
+	"github.com/gin-gonic/gin"
+	"mental/controllers/user"
+	"mental/middleware"
+)
+
+// InitNotificationRouter 初始化站内通知路由
+func InitNotificationRouter(r *gin.Engine) {
+	notificationRouter := r.Group("/notification")
+	{
+		notificationRouter.Use(middleware.JWTMiddleWare())
+		notificationRouter.GET("/list", user.NotificationController{}.ListNotifications) // 查询我的通知
+		notificationRouter.POST("/read", user.NotificationController{}.MarkRead)         // 标记通知已读
+	}
+}
@@ -0,0 +1,23 @@
+package routers
+
+import (
+	"github.com/gin-gonic/gin"
+	"mental/controllers/user"
+	"mental/middleware"
+)
+
+// InitOrgRouter 初始化组织树与花名册路由
+func InitOrgRouter(r *gin.Engine) {
+	orgRouter := r.Group("/org")
+	{
+		orgRouter.Use(middleware.JWTMiddleWare())
+		orgRouter.POST("", user.OrgController{}.CreateOrg)                  // 新建组织
+		orgRouter.POST("/update", user.OrgController{}.UpdateOrg)           // 修改组织
+		orgRouter.DELETE("", user.OrgController{}.DeleteOrg)                // 删除组织
+		orgRouter.GET("/tree", user.OrgController{}.Tree)                   // 查询组织树
+		orgRouter.GET("/members", user.OrgController{}.ListMembers)         // 查询花名册
+		orgRouter.DELETE("/member", user.OrgController{}.RemoveMember)      // 移出花名册
+		orgRouter.POST("/roster/import", user.OrgController{}.ImportRoster) // 导入花名册
+		orgRouter.GET("/stats", user.OrgController{}.Stats)                 // 按组织汇总测评情况
+	}
+}
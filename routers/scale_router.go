@@ -0,0 +1,27 @@
+package routers
+
+import (
+	"github.com/gin-gonic/gin"
+	"mental/controllers/user"
+	"mental/middleware"
+)
+
+// InitScaleRouter 初始化通用量表路由
+func InitScaleRouter(r *gin.Engine) {
+	scaleRouter := r.Group("/scale")
+	{
+		scaleRouter.Use(middleware.JWTMiddleWare())
+		scaleRouter.GET("/list", user.ScaleController{}.ListScales)           // 查询量表列表
+		scaleRouter.GET("", user.ScaleController{}.GetScale)                  // 查询量表定义
+		scaleRouter.POST("", user.ScaleController{}.CreateScale)              // 新建量表
+		scaleRouter.POST("/submit", user.ScaleController{}.Submit)            // 提交量表作答
+		scaleRouter.GET("/result", user.ScaleController{}.ListResults)        // 查询当前用户的量表结果
+		scaleRouter.GET("/result/all", user.ScaleController{}.ListAllResults) // 查询所有用户的量表结果
+
+		scaleRouter.POST("/session/start", user.SessionController{}.Start)   // 开始或恢复在线测评
+		scaleRouter.GET("/session", user.SessionController{}.Get)            // 查询在线测评进度
+		scaleRouter.GET("/session/items", user.SessionController{}.Items)    // 分页获取题目
+		scaleRouter.POST("/session/save", user.SessionController{}.Save)     // 自动保存作答
+		scaleRouter.POST("/session/submit", user.SessionController{}.Submit) // 提交在线测评
+	}
+}
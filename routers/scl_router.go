@@ -11,10 +11,22 @@ func InitSCLRouter(r *gin.Engine) {
 	commonRouter := r.Group("/scl")
 	{
 		commonRouter.Use(middleware.JWTMiddleWare())
-		commonRouter.POST("", user.SCLController{}.InsertSCL)        // 用户导入scl数据
-		commonRouter.GET("", user.SCLController{}.SelectAllByUserId) // 查询用户scl数据
-		commonRouter.DELETE("", user.SCLController{}.DeleteSCL)      // 删除指定id的scl数据
-		commonRouter.POST("/update", user.SCLController{}.UpdateSCL) // 更新指定id的scl数据
-		commonRouter.GET("/all", user.SCLController{}.SelectSCLs)    // 查询所有用户的scl数据
+		commonRouter.POST("", user.SCLController{}.InsertSCL)            // 用户导入scl数据
+		commonRouter.GET("", user.SCLController{}.SelectAllByUserId)     // 查询用户scl数据
+		commonRouter.DELETE("", user.SCLController{}.DeleteSCL)          // 删除指定id的scl数据
+		commonRouter.POST("/update", user.SCLController{}.UpdateSCL)     // 更新指定id的scl数据
+		commonRouter.GET("/all", user.SCLController{}.SelectSCLs)        // 查询所有用户的scl数据
+		commonRouter.GET("/answers", user.SCLController{}.SelectAnswers) // 查询指定scl记录的逐题作答
+		commonRouter.GET("/trend", user.SCLController{}.SelectTrend)     // 查询学生历次测评的变化趋势
+		commonRouter.GET("/stats", user.CohortController{}.Stats)        // 群体统计（筛查看板）
+
+		commonRouter.GET("/export", user.SCLController{}.Export)                // 导出测评记录（xlsx/csv）
+		commonRouter.GET("/report", user.SCLController{}.SCLReport)             // 生成单条记录的PDF报告
+		commonRouter.GET("/report/student", user.SCLController{}.StudentReport) // 生成学生的PDF报告
+
+		commonRouter.POST("/rule", user.SCLRuleController{}.CreateRuleSet)            // 新建结果解释规则集
+		commonRouter.GET("/rule/list", user.SCLRuleController{}.ListRuleSets)         // 查询规则集列表
+		commonRouter.POST("/rule/preview", user.SCLRuleController{}.PreviewRuleSet)   // 规则集试算
+		commonRouter.POST("/rule/activate", user.SCLRuleController{}.ActivateRuleSet) // 启用规则集
 	}
 }
@@ -0,0 +1,399 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"mental/config"
+	"mental/dao"
+	"mental/models"
+	"mental/vo"
+	"strings"
+	"time"
+)
+
+// builtinAlertRules 内置预警规则，数据库中没有启用的规则时使用
+var builtinAlertRules = []models.AlertRule{
+	{Name: "总体等级为重度", Metric: models.AlertMetricLevel, Min: 3, Level: models.SCLLevelSevere, Enabled: true},
+	{Name: "抑郁因子达到重度", Metric: "depression", Min: 3, Level: models.SCLLevelSevere, Enabled: true},
+	{Name: "精神病性因子达到重度", Metric: "psychoticism", Min: 3, Level: models.SCLLevelSevere, Enabled: true},
+}
+
+// alertTransitions 预警状态流转：已关闭的预警可重新进入跟进
+var alertTransitions = map[string][]string{
+	models.AlertStatusNew:          {models.AlertStatusAcknowledged, models.AlertStatusFollowUp, models.AlertStatusClosed},
+	models.AlertStatusAcknowledged: {models.AlertStatusFollowUp, models.AlertStatusClosed},
+	models.AlertStatusFollowUp:     {models.AlertStatusClosed},
+	models.AlertStatusClosed:       {models.AlertStatusFollowUp},
+}
+
+// alertOpenStatuses 未关闭的预警状态，预警列表默认只查询这些状态
+var alertOpenStatuses = []string{models.AlertStatusNew, models.AlertStatusAcknowledged, models.AlertStatusFollowUp}
+
+// alertMetricName 预警指标的中文名
+func alertMetricName(key string) string {
+	if key == models.AlertMetricLevel {
+		return "总体等级"
+	}
+	for _, metric := range sclMetrics() {
+		if metric.Key == key {
+			return metric.Name
+		}
+	}
+	return key
+}
+
+// loadAlertRules 加载启用的预警规则，没有时使用内置规则
+func loadAlertRules() ([]models.AlertRule, error) {
+	rules, err := dao.NewAlertDao(config.DB).ListRules(true)
+	if err != nil {
+		return nil, err
+	}
+	if len(rules) == 0 {
+		return builtinAlertRules, nil
+	}
+	return rules, nil
+}
+
+// evaluateSCLAlert 按预警规则评估测评记录，返回命中的原因和最高预警等级，未命中时原因为空
+func evaluateSCLAlert(scl models.SCL) ([]models.AlertReason, string, error) {
+	alertRules, err := loadAlertRules()
+	if err != nil {
+		return nil, "", err
+	}
+	sclRules, err := loadSCLRules(scl.RuleSetID)
+	if err != nil {
+		return nil, "", err
+	}
+	analysis := analyzeSCL(scl, sclRules)
+
+	var (
+		reasons []models.AlertReason
+		level   = models.SCLLevelNormal
+	)
+	for _, rule := range alertRules {
+		value := sclMetricValue(scl, rule.Metric)
+		if rule.Metric == models.AlertMetricLevel {
+			value = float64(sclLevelRank(analysis.Level))
+		}
+		if value < rule.Min {
+			continue
+		}
+		message := fmt.Sprintf("%s：%s %.2f ≥ %.2f", rule.Name, alertMetricName(rule.Metric), value, rule.Min)
+		if rule.Metric == models.AlertMetricLevel {
+			message = fmt.Sprintf("%s：%s", rule.Name, analysis.Summary)
+		}
+		reasons = append(reasons, models.AlertReason{RuleID: rule.ID, Metric: rule.Metric, Min: rule.Min, Value: roundIndex(value), Level: rule.Level, Message: message})
+		if sclLevelRank(rule.Level) > sclLevelRank(level) {
+			level = rule.Level
+		}
+	}
+	return reasons, level, nil
+}
+
+// raiseSCLAlert 在保存测评记录的事务中评估预警：命中时新建预警，已有未关闭的预警则更新其原因和等级；
+// 记录更新后不再命中任何规则时关闭其未关闭的预警，等级变化时记录备注
+func raiseSCLAlert(tx *gorm.DB, scl *models.SCL) error {
+	reasons, level, err := evaluateSCLAlert(*scl)
+	if err != nil {
+		return err
+	}
+	if len(reasons) == 0 {
+		return closeSCLAlerts(tx, []int64{scl.ID}, "测评记录更新后不再命中预警规则，自动关闭")
+	}
+	content, _ := json.Marshal(reasons)
+
+	alertDao := dao.NewAlertDao(tx)
+	alert, err := alertDao.FindOpenBySCLID(scl.ID)
+	if err != nil {
+		return err
+	}
+	if alert != nil {
+		previous := alert.Level
+		alert.Level = level
+		alert.Reasons = string(content)
+		alert.StudentName = scl.Name
+		alert.TestDate = scl.TestDate
+		if err := alertDao.Update(alert); err != nil {
+			return err
+		}
+		if previous == level {
+			return nil
+		}
+		return alertDao.SaveNote(&models.AlertNote{AlertID: alert.ID, Content: fmt.Sprintf("测评记录更新，预警等级由 %s 调整为 %s", previous, level)})
+	}
+	return alertDao.Save(&models.Alert{
+		SCLID:          scl.ID,
+		StudentID:      scl.StudentID,
+		StudentName:    scl.Name,
+		TestDate:       scl.TestDate,
+		Level:          level,
+		Reasons:        string(content),
+		Status:         models.AlertStatusNew,
+		AssigneeRoleID: config.AlertSettings.CounselorRoleID,
+	})
+}
+
+// raiseScaleAlert 在保存通用量表作答的事务中，按命中的单题预警（如 PHQ-9 第 9 题）新建预警，等级取命中项中最高的等级
+func raiseScaleAlert(tx *gorm.DB, scale *scaleDef, response *models.ScaleResponse, name string, flags []models.ScaleFlag) error {
+	if len(flags) == 0 {
+		return nil
+	}
+	reasons := make([]models.AlertReason, 0, len(flags))
+	level := models.SCLLevelNormal
+	for _, flag := range flags {
+		reasons = append(reasons, models.AlertReason{
+			Metric:  fmt.Sprintf("%s_item_%d", scale.Code, flag.Item),
+			Value:   float64(flag.Score),
+			Level:   flag.Level,
+			Message: fmt.Sprintf("%s 第 %d 题作答 %d 分：%s", scale.Name, flag.Item, flag.Score, flag.Message),
+		})
+		if sclLevelRank(flag.Level) > sclLevelRank(level) {
+			level = flag.Level
+		}
+	}
+	content, _ := json.Marshal(reasons)
+	return dao.NewAlertDao(tx).Save(&models.Alert{
+		ResponseID:     &response.ID,
+		StudentID:      response.StudentID,
+		StudentName:    name,
+		TestDate:       response.TestDate,
+		Level:          level,
+		Reasons:        string(content),
+		Status:         models.AlertStatusNew,
+		AssigneeRoleID: config.AlertSettings.CounselorRoleID,
+	})
+}
+
+// closeSCLAlerts 在调用方的事务 tx 中关闭一组测评记录未关闭的预警，并以系统身份（记录人ID为 0）记录关闭原因
+func closeSCLAlerts(tx *gorm.DB, sclIds []int64, content string) error {
+	alertDao := dao.NewAlertDao(tx)
+	alerts, err := alertDao.ListOpenBySCLIDs(sclIds)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	for i := range alerts {
+		alert := &alerts[i]
+		note := &models.AlertNote{AlertID: alert.ID, FromStatus: alert.Status, ToStatus: models.AlertStatusClosed, Content: content}
+		alert.Status = models.AlertStatusClosed
+		alert.ClosedAt = &now
+		if err := alertDao.Update(alert); err != nil {
+			return err
+		}
+		if err := alertDao.SaveNote(note); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AlertService 高风险预警业务
+type AlertService struct {
+}
+
+// NewAlertService 创建新的 AlertService
+func NewAlertService() *AlertService {
+	return &AlertService{}
+}
+
+// buildAlertVO 解析预警的触发原因
+func buildAlertVO(alert models.Alert) (vo.AlertVO, error) {
+	result := vo.AlertVO{Alert: alert, Reasons: []models.AlertReason{}}
+	if err := json.Unmarshal([]byte(alert.Reasons), &result.Reasons); err != nil {
+		return result, fmt.Errorf("预警 %d 的触发原因格式错误: %v", alert.ID, err)
+	}
+	return result, nil
+}
+
+// ListAlerts 按条件查询数据范围内学生的预警，未指定状态时只查询未关闭的预警；仅辅导员或管理员可查询
+func (s *AlertService) ListAlerts(filter dao.AlertFilter, scope *dao.DataScope) ([]vo.AlertVO, error) {
+	if err := requireCounselor(scope); err != nil {
+		return nil, err
+	}
+	if len(filter.Statuses) == 0 {
+		filter.Statuses = alertOpenStatuses
+	}
+	alerts, err := dao.NewAlertDao(config.DB).WithScope(scope).List(filter)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]vo.AlertVO, 0, len(alerts))
+	for _, alert := range alerts {
+		result, err := buildAlertVO(alert)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// GetAlert 查询数据范围内学生的预警详情及全部处理备注；仅辅导员或管理员可查询
+func (s *AlertService) GetAlert(id int64, scope *dao.DataScope) (*vo.AlertVO, error) {
+	if err := requireCounselor(scope); err != nil {
+		return nil, err
+	}
+	alertDao := dao.NewAlertDao(config.DB).WithScope(scope)
+	alert, err := alertDao.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if alert == nil {
+		return nil, errors.New("预警不存在")
+	}
+	result, err := buildAlertVO(*alert)
+	if err != nil {
+		return nil, err
+	}
+	if result.Notes, err = alertDao.ListNotes(id); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// ChangeStatus 变更数据范围内学生的预警状态并记录备注，关闭预警时必须填写备注；仅辅导员或管理员可操作
+func (s *AlertService) ChangeStatus(id int64, operator *dao.DataScope, status string, content string) error {
+	if err := requireCounselor(operator); err != nil {
+		return err
+	}
+	content = strings.TrimSpace(content)
+	if status == models.AlertStatusClosed && content == "" {
+		return errors.New("关闭预警时必须填写处理备注")
+	}
+	userId := operator.UserID
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		alertDao := dao.NewAlertDao(tx).WithScope(operator)
+		alert, err := alertDao.FindByID(id)
+		if err != nil {
+			return err
+		}
+		if alert == nil {
+			return errors.New("预警不存在")
+		}
+		allowed := false
+		for _, next := range alertTransitions[alert.Status] {
+			if next == status {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return fmt.Errorf("预警状态不能从 %s 变更为 %s", alert.Status, status)
+		}
+
+		note := &models.AlertNote{AlertID: id, AuthorID: userId, FromStatus: alert.Status, ToStatus: status, Content: content}
+		alert.Status = status
+		alert.ClosedAt = nil
+		if status == models.AlertStatusClosed {
+			now := time.Now()
+			alert.ClosedAt = &now
+		}
+		if alert.AssigneeID == nil && status != models.AlertStatusClosed {
+			alert.AssigneeID = &userId // 未分派负责人时，由首个处理人负责
+		}
+		if err := alertDao.Update(alert); err != nil {
+			return err
+		}
+		return alertDao.SaveNote(note)
+	})
+}
+
+// Assign 分派数据范围内学生的预警负责人并记录备注；仅辅导员或管理员可操作
+func (s *AlertService) Assign(id int64, operator *dao.DataScope, assigneeId int64, content string) error {
+	if err := requireCounselor(operator); err != nil {
+		return err
+	}
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		alertDao := dao.NewAlertDao(tx).WithScope(operator)
+		alert, err := alertDao.FindByID(id)
+		if err != nil {
+			return err
+		}
+		if alert == nil {
+			return errors.New("预警不存在")
+		}
+		if alert.Status == models.AlertStatusClosed {
+			return errors.New("预警已关闭，不能分派")
+		}
+		alert.AssigneeID = &assigneeId
+		if err := alertDao.Update(alert); err != nil {
+			return err
+		}
+		if content = strings.TrimSpace(content); content == "" {
+			content = fmt.Sprintf("分派给用户 %d", assigneeId)
+		}
+		return alertDao.SaveNote(&models.AlertNote{AlertID: id, AuthorID: operator.UserID, Content: content})
+	})
+}
+
+// AddNote 为数据范围内学生的预警添加处理备注；仅辅导员或管理员可操作
+func (s *AlertService) AddNote(id int64, operator *dao.DataScope, content string) error {
+	if err := requireCounselor(operator); err != nil {
+		return err
+	}
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return errors.New("备注内容不能为空")
+	}
+	alertDao := dao.NewAlertDao(config.DB).WithScope(operator)
+	alert, err := alertDao.FindByID(id)
+	if err != nil {
+		return err
+	}
+	if alert == nil {
+		return errors.New("预警不存在")
+	}
+	return alertDao.SaveNote(&models.AlertNote{AlertID: id, AuthorID: operator.UserID, Content: content})
+}
+
+// CreateRule 校验并保存预警规则；仅管理员可操作
+func (s *AlertService) CreateRule(rule *models.AlertRule, operator *dao.DataScope) error {
+	if err := requireAdmin(operator); err != nil {
+		return err
+	}
+	if rule.Name == "" {
+		return errors.New("规则名称不能为空")
+	}
+	if rule.Metric != models.AlertMetricLevel {
+		key := sclMetricKey(rule.Metric)
+		if key == "" {
+			return fmt.Errorf("指标 %s 无法识别", rule.Metric)
+		}
+		rule.Metric = key
+	}
+	if !isSCLLevel(rule.Level) || rule.Level == models.SCLLevelNormal {
+		return fmt.Errorf("预警等级 %s 无效", rule.Level)
+	}
+	rule.ID = 0
+	rule.Enabled = true
+	return dao.NewAlertDao(config.DB).SaveRule(rule)
+}
+
+// ListRules 查询全部预警规则；数据库中没有启用的规则时，内置规则生效
+func (s *AlertService) ListRules() (*vo.AlertRuleList, error) {
+	rules, err := dao.NewAlertDao(config.DB).ListRules(false)
+	if err != nil {
+		return nil, err
+	}
+	effective, err := loadAlertRules()
+	if err != nil {
+		return nil, err
+	}
+	return &vo.AlertRuleList{Rules: rules, Effective: effective}, nil
+}
+
+// SetRuleEnabled 启用或停用预警规则；仅管理员可操作
+func (s *AlertService) SetRuleEnabled(id int64, enabled bool, operator *dao.DataScope) error {
+	if err := requireAdmin(operator); err != nil {
+		return err
+	}
+	if err := dao.NewAlertDao(config.DB).SetRuleEnabled(id, enabled); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("预警规则不存在")
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,251 @@
+package service
+
+import (
+	"bytes"
+	"errors"
+	"github.com/xuri/excelize/v2"
+	"mental/config"
+	"mental/dao"
+	"mental/models"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// sclAlerts 查询测评记录的全部预警
+func sclAlerts(t *testing.T, sclId int64) []models.Alert {
+	t.Helper()
+	var alerts []models.Alert
+	if err := config.DB.Where("scl_id = ?", sclId).Order("id asc").Find(&alerts).Error; err != nil {
+		t.Fatalf("查询预警失败: %v", err)
+	}
+	return alerts
+}
+
+func TestCreateSCLRaisesAlert(t *testing.T) {
+	setupTestEnv(t)
+	sclService := NewSCLService()
+
+	risky := testSCL(100, 3.5)
+	if err := sclService.CreateSCL(risky); err != nil {
+		t.Fatalf("新增测评记录失败: %v", err)
+	}
+	alerts := sclAlerts(t, risky.ID)
+	if len(alerts) != 1 {
+		t.Fatalf("高风险记录应生成 1 条预警，实际为 %d 条", len(alerts))
+	}
+	if alerts[0].Status != models.AlertStatusNew || alerts[0].Level != models.SCLLevelSevere {
+		t.Errorf("预警状态/等级应为 new/severe，实际为 %s/%s", alerts[0].Status, alerts[0].Level)
+	}
+	if alerts[0].AssigneeRoleID != config.AlertSettings.CounselorRoleID {
+		t.Errorf("预警应分派给辅导员角色 %d，实际为 %d", config.AlertSettings.CounselorRoleID, alerts[0].AssigneeRoleID)
+	}
+
+	normal := testSCL(101, 1.5)
+	if err := sclService.CreateSCL(normal); err != nil {
+		t.Fatalf("新增测评记录失败: %v", err)
+	}
+	if alerts := sclAlerts(t, normal.ID); len(alerts) != 0 {
+		t.Errorf("未命中规则的记录不应生成预警，实际为 %d 条", len(alerts))
+	}
+}
+
+// importTestRows 以内置方案的导入模板为基础写入两行（学生 100 高风险、学生 101 正常），按部分导入写入数据库
+func importTestRows(t *testing.T) *models.ImportBatch {
+	t.Helper()
+	var template bytes.Buffer
+	if err := NewImportProfileService().Template(0, 0, false, adminScope(), &template); err != nil {
+		t.Fatalf("生成导入模板失败: %v", err)
+	}
+	f, err := excelize.OpenReader(&template)
+	if err != nil {
+		t.Fatalf("打开导入模板失败: %v", err)
+	}
+	defer f.Close()
+	sheet := f.GetSheetList()[0]
+	testDate := time.Now().AddDate(0, 0, -1).Format("2006-01-02")
+	for i, row := range [][]interface{}{
+		{"100", "高风险", "男", 18, testDate, 3.5, 3.5, 3.5, 3.5, 3.5, 3.5, 3.5, 3.5, 3.5, 3.5},
+		{"101", "正常", "女", 18, testDate, 1.5, 1.5, 1.5, 1.5, 1.5, 1.5, 1.5, 1.5, 1.5, 1.5},
+	} {
+		cell, _ := excelize.CoordinatesToCellName(1, i+2)
+		if err := f.SetSheetRow(sheet, cell, &row); err != nil {
+			t.Fatalf("写入数据行失败: %v", err)
+		}
+	}
+
+	var rows []importRow
+	fileService := &FileService{}
+	if err := fileService.readWorkbookRows(f, defaultImportProfile, func(row importRow) { rows = append(rows, row) }); err != nil {
+		t.Fatalf("读取导入文件失败: %v", err)
+	}
+	batch := &models.ImportBatch{FileID: "test", Mode: models.ImportModePartial, Status: models.ImportBatchCompleted}
+	if errorRows := importPartial(batch, rows, &importProgress{saved: time.Now()}); len(errorRows) != 0 {
+		t.Fatalf("导入不应有失败行: %v", errorRows)
+	}
+	if batch.SuccessCount != 2 {
+		t.Fatalf("应导入 2 行，实际为 %d 行", batch.SuccessCount)
+	}
+	return batch
+}
+
+func TestImportRowsRaiseAlert(t *testing.T) {
+	setupTestEnv(t)
+	importTestRows(t)
+
+	var alerts []models.Alert
+	if err := config.DB.Find(&alerts).Error; err != nil {
+		t.Fatalf("查询预警失败: %v", err)
+	}
+	if len(alerts) != 1 {
+		t.Fatalf("导入应只为高风险行生成 1 条预警，实际为 %d 条", len(alerts))
+	}
+	if alerts[0].StudentID == nil || *alerts[0].StudentID != 100 || alerts[0].Level != models.SCLLevelSevere {
+		t.Errorf("预警应属于学生 100 且等级为 severe: %+v", alerts[0])
+	}
+}
+
+func TestUpdateSCLClosesAlertWhenRiskDrops(t *testing.T) {
+	setupTestEnv(t)
+	sclService := NewSCLService()
+	scl := testSCL(100, 3.5)
+	if err := sclService.CreateSCL(scl); err != nil {
+		t.Fatalf("新增测评记录失败: %v", err)
+	}
+
+	update := testSCL(100, 1.5)
+	update.ID = scl.ID
+	if err := sclService.UpdateSCL(update, selfScope(100)); err != nil {
+		t.Fatalf("修改测评记录失败: %v", err)
+	}
+	alerts := sclAlerts(t, scl.ID)
+	if len(alerts) != 1 || alerts[0].Status != models.AlertStatusClosed || alerts[0].ClosedAt == nil {
+		t.Fatalf("风险解除后预警应被关闭: %+v", alerts)
+	}
+	notes, err := dao.NewAlertDao(config.DB).ListNotes(alerts[0].ID)
+	if err != nil {
+		t.Fatalf("查询处理备注失败: %v", err)
+	}
+	if len(notes) != 1 || notes[0].ToStatus != models.AlertStatusClosed {
+		t.Errorf("关闭预警应记录状态变更备注: %+v", notes)
+	}
+}
+
+func TestUpdateSCLDowngradesAlert(t *testing.T) {
+	setupTestEnv(t)
+	for _, rule := range []models.AlertRule{
+		{Name: "抑郁因子达到中度", Metric: "depression", Min: 2, Level: models.SCLLevelModerate, Enabled: true},
+		{Name: "抑郁因子达到重度", Metric: "depression", Min: 3, Level: models.SCLLevelSevere, Enabled: true},
+	} {
+		if err := dao.NewAlertDao(config.DB).SaveRule(&rule); err != nil {
+			t.Fatalf("保存预警规则失败: %v", err)
+		}
+	}
+	sclService := NewSCLService()
+	scl := testSCL(100, 3.5)
+	if err := sclService.CreateSCL(scl); err != nil {
+		t.Fatalf("新增测评记录失败: %v", err)
+	}
+
+	update := testSCL(100, 2.5)
+	update.ID = scl.ID
+	if err := sclService.UpdateSCL(update, selfScope(100)); err != nil {
+		t.Fatalf("修改测评记录失败: %v", err)
+	}
+	alerts := sclAlerts(t, scl.ID)
+	if len(alerts) != 1 || alerts[0].Status != models.AlertStatusNew || alerts[0].Level != models.SCLLevelModerate {
+		t.Fatalf("风险降低后预警应保持未关闭并降为 moderate: %+v", alerts)
+	}
+}
+
+func TestDeleteSCLClosesAlert(t *testing.T) {
+	setupTestEnv(t)
+	sclService := NewSCLService()
+	scl := testSCL(100, 3.5)
+	if err := sclService.CreateSCL(scl); err != nil {
+		t.Fatalf("新增测评记录失败: %v", err)
+	}
+
+	if err := sclService.DeleteSCL(scl.ID, selfScope(100)); err != nil {
+		t.Fatalf("删除测评记录失败: %v", err)
+	}
+	alerts := sclAlerts(t, scl.ID)
+	if len(alerts) != 1 || alerts[0].Status != models.AlertStatusClosed {
+		t.Fatalf("删除测评记录后预警应被关闭: %+v", alerts)
+	}
+}
+
+func TestRollbackImportBatchClosesAlerts(t *testing.T) {
+	setupTestEnv(t)
+	batch := importTestRows(t)
+
+	deleted, err := NewImportBatchService().Rollback(batch.ID, 1, &dao.DataScope{Scope: "all", All: true})
+	if err != nil {
+		t.Fatalf("回滚导入批次失败: %v", err)
+	}
+	if deleted != 2 {
+		t.Errorf("应删除 2 条记录，实际为 %d 条", deleted)
+	}
+	var open int64
+	if err := config.DB.Model(&models.Alert{}).Where("status <> ?", models.AlertStatusClosed).Count(&open).Error; err != nil {
+		t.Fatalf("查询预警失败: %v", err)
+	}
+	if open != 0 {
+		t.Errorf("回滚后批次记录的预警应全部关闭，仍有 %d 条未关闭", open)
+	}
+}
+
+func TestRollbackImportBatchOutOfScope(t *testing.T) {
+	setupTestEnv(t)
+	batch := importTestRows(t)
+
+	if _, err := NewImportBatchService().Rollback(batch.ID, 100, selfScope(100)); err == nil {
+		t.Fatal("批次中有不在数据范围内的记录时应拒绝回滚")
+	}
+	var count int64
+	if err := config.DB.Model(&models.SCL{}).Where("import_batch_id = ?", batch.ID).Count(&count).Error; err != nil {
+		t.Fatalf("查询测评记录失败: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("拒绝回滚时不应删除记录，剩余 %d 条", count)
+	}
+}
+
+func TestStudentCannotHandleOwnAlert(t *testing.T) {
+	setupTestEnv(t)
+	scl := testSCL(100, 3.5)
+	if err := NewSCLService().CreateSCL(scl); err != nil {
+		t.Fatalf("新增测评记录失败: %v", err)
+	}
+	alerts := sclAlerts(t, scl.ID)
+	if len(alerts) != 1 {
+		t.Fatalf("高风险记录应生成 1 条预警，实际为 %d 条", len(alerts))
+	}
+	alertId := alerts[0].ID
+
+	alertService := NewAlertService()
+	student := selfScope(100)
+	if err := alertService.ChangeStatus(alertId, student, models.AlertStatusClosed, "我没事"); !errors.Is(err, ErrPermissionDenied) {
+		t.Errorf("学生关闭本人的预警应返回 ErrPermissionDenied，实际为 %v", err)
+	}
+	if _, err := alertService.ListAlerts(dao.AlertFilter{}, student); !errors.Is(err, ErrPermissionDenied) {
+		t.Errorf("学生查询预警列表应返回 ErrPermissionDenied，实际为 %v", err)
+	}
+	if _, err := alertService.GetAlert(alertId, student); !errors.Is(err, ErrPermissionDenied) {
+		t.Errorf("学生查询预警详情应返回 ErrPermissionDenied，实际为 %v", err)
+	}
+	if err := alertService.Assign(alertId, student, 100, ""); !errors.Is(err, ErrPermissionDenied) {
+		t.Errorf("学生分派预警应返回 ErrPermissionDenied，实际为 %v", err)
+	}
+	if err := alertService.AddNote(alertId, student, "备注"); !errors.Is(err, ErrPermissionDenied) {
+		t.Errorf("学生添加预警备注应返回 ErrPermissionDenied，实际为 %v", err)
+	}
+	if alerts := sclAlerts(t, scl.ID); alerts[0].Status != models.AlertStatusNew {
+		t.Errorf("被拒绝的操作不应改变预警状态，实际为 %s", alerts[0].Status)
+	}
+
+	counselor := &dao.DataScope{Scope: "all", UserID: 5, All: true, RoleIDs: []string{strconv.Itoa(config.AlertSettings.CounselorRoleID)}}
+	if err := alertService.ChangeStatus(alertId, counselor, models.AlertStatusClosed, "已访谈，风险解除"); err != nil {
+		t.Errorf("辅导员关闭预警失败: %v", err)
+	}
+}
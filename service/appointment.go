@@ -0,0 +1,353 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"mental/config"
+	"mental/constant"
+	"mental/dao"
+	"mental/models"
+	"mental/utils"
+	"mental/vo"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// appointmentTimeFormat 通知中展示预约时间的格式
+const appointmentTimeFormat = "2006-01-02 15:04"
+
+// AppointmentService 心理咨询预约业务：发布时段、预约/取消、日历与 iCalendar 导出
+type AppointmentService struct {
+}
+
+// NewAppointmentService 创建新的 AppointmentService
+func NewAppointmentService() *AppointmentService {
+	return &AppointmentService{}
+}
+
+// userDisplayName 取用户的展示名：用户名，未设置时为账号
+func userDisplayName(userId int64) string {
+	user, err := dao.NewUserDao(config.DB).GetUserById(userId)
+	if err != nil {
+		return ""
+	}
+	if user.Username != "" {
+		return user.Username
+	}
+	return user.Account
+}
+
+// PublishSlots 辅导员批量发布咨询时段：时段须在未来、不超过最长时长，且与本人已有时段及本次其他时段均不重叠；仅辅导员或管理员可发布
+func (s *AppointmentService) PublishSlots(operator *dao.DataScope, forms []vo.SlotForm) ([]models.AppointmentSlot, error) {
+	if err := requireCounselor(operator); err != nil {
+		return nil, err
+	}
+	counselorId := operator.UserID
+	if len(forms) == 0 {
+		return nil, errors.New("咨询时段不能为空")
+	}
+	now := time.Now()
+	maxDuration := time.Duration(config.AppointmentSettings.MaxSlotMinutes) * time.Minute
+	appointmentDao := dao.NewAppointmentDao(config.DB)
+	slots := make([]models.AppointmentSlot, 0, len(forms))
+	for i, form := range forms {
+		if !form.EndAt.After(form.StartAt) {
+			return nil, fmt.Errorf("第 %d 个时段的结束时间必须晚于开始时间", i+1)
+		}
+		if !form.StartAt.After(now) {
+			return nil, fmt.Errorf("第 %d 个时段的开始时间必须晚于当前时间", i+1)
+		}
+		if form.EndAt.Sub(form.StartAt) > maxDuration {
+			return nil, fmt.Errorf("第 %d 个时段超过最长时长 %d 分钟", i+1, config.AppointmentSettings.MaxSlotMinutes)
+		}
+		for j, other := range forms[:i] {
+			if form.StartAt.Before(other.EndAt) && form.EndAt.After(other.StartAt) {
+				return nil, fmt.Errorf("第 %d 个时段与第 %d 个时段重叠", i+1, j+1)
+			}
+		}
+		count, err := appointmentDao.CountOverlappingSlots(counselorId, form.StartAt, form.EndAt)
+		if err != nil {
+			return nil, err
+		}
+		if count > 0 {
+			return nil, fmt.Errorf("第 %d 个时段与已发布的时段重叠", i+1)
+		}
+		slots = append(slots, models.AppointmentSlot{
+			CounselorID: counselorId,
+			StartAt:     form.StartAt,
+			EndAt:       form.EndAt,
+			Location:    strings.TrimSpace(form.Location),
+			Remark:      strings.TrimSpace(form.Remark),
+			Status:      models.SlotStatusOpen,
+		})
+	}
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		return dao.NewAppointmentDao(tx).SaveSlots(slots)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return slots, nil
+}
+
+// CloseSlot 辅导员关闭本人未被预约的时段
+func (s *AppointmentService) CloseSlot(operator *dao.DataScope, slotId int64) error {
+	if err := requireCounselor(operator); err != nil {
+		return err
+	}
+	appointmentDao := dao.NewAppointmentDao(config.DB)
+	slot, err := appointmentDao.FindSlotByID(slotId)
+	if err != nil {
+		return err
+	}
+	if slot == nil {
+		return errors.New("咨询时段不存在")
+	}
+	if slot.CounselorID != operator.UserID {
+		return errors.New("只能关闭本人发布的时段")
+	}
+	closed, err := appointmentDao.UpdateSlotStatus(slotId, models.SlotStatusOpen, models.SlotStatusClosed)
+	if err != nil {
+		return err
+	}
+	if !closed {
+		return errors.New("时段已被预约或已关闭，不能关闭")
+	}
+	return nil
+}
+
+// ListAvailable 查询可预约的未来时段，counselorId 为 nil 时不限辅导员
+func (s *AppointmentService) ListAvailable(counselorId *int64, from time.Time, to time.Time) ([]models.AppointmentSlot, error) {
+	if now := time.Now(); from.Before(now) {
+		from = now
+	}
+	return dao.NewAppointmentDao(config.DB).ListSlots(dao.SlotFilter{
+		CounselorID: counselorId,
+		Statuses:    []string{models.SlotStatusOpen},
+		From:        from,
+		To:          to,
+	})
+}
+
+// Book 学生预约咨询时段
+// 以时段ID加分布式锁，锁内在同一事务中校验时段可预约、学生在该时间段没有其他预约，并占用时段，防止重复预约
+func (s *AppointmentService) Book(studentId int64, slotId int64, reason string) (*models.Appointment, error) {
+	lock, err := utils.TryLock(constant.AppointmentLockPrefix+strconv.FormatInt(slotId, 10), 10*time.Second)
+	if err != nil {
+		return nil, errors.New("该时段正在被预约，请稍后重试")
+	}
+	defer func() { _ = utils.Unlock(lock) }()
+
+	var appointment *models.Appointment
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		appointmentDao := dao.NewAppointmentDao(tx)
+		slot, err := appointmentDao.FindSlotByID(slotId)
+		if err != nil {
+			return err
+		}
+		if slot == nil {
+			return errors.New("咨询时段不存在")
+		}
+		if slot.Status != models.SlotStatusOpen {
+			return errors.New("该时段已被预约或已关闭")
+		}
+		if !slot.StartAt.After(time.Now()) {
+			return errors.New("该时段已开始，不能预约")
+		}
+		if slot.CounselorID == studentId {
+			return errors.New("不能预约本人发布的时段")
+		}
+		count, err := appointmentDao.CountStudentOverlapping(studentId, slot.StartAt, slot.EndAt)
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			return errors.New("该时间段内已有其他预约")
+		}
+		booked, err := appointmentDao.UpdateSlotStatus(slot.ID, models.SlotStatusOpen, models.SlotStatusBooked)
+		if err != nil {
+			return err
+		}
+		if !booked { // 锁过期等极端情况下的兜底
+			return errors.New("该时段已被预约或已关闭")
+		}
+
+		appointment = &models.Appointment{
+			SlotID:        slot.ID,
+			StudentID:     studentId,
+			StudentName:   userDisplayName(studentId),
+			CounselorID:   slot.CounselorID,
+			CounselorName: userDisplayName(slot.CounselorID),
+			StartAt:       slot.StartAt,
+			EndAt:         slot.EndAt,
+			Location:      slot.Location,
+			Reason:        strings.TrimSpace(reason),
+			Status:        models.AppointmentStatusBooked,
+		}
+		if err := appointmentDao.SaveAppointment(appointment); err != nil {
+			return err
+		}
+		return notify(tx, models.Notification{
+			UserID:  slot.CounselorID,
+			Type:    models.NotificationAppointmentBooked,
+			Title:   "新的咨询预约",
+			Content: fmt.Sprintf("%s 预约了 %s 的咨询（%s）", appointment.StudentName, slot.StartAt.Format(appointmentTimeFormat), slot.Location),
+			RefID:   appointment.ID,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return appointment, nil
+}
+
+// Cancel 取消预约：学生只能在开始前规定时间之前取消本人的预约，辅导员可在开始前取消本人时段上的预约；取消后时段重新开放
+func (s *AppointmentService) Cancel(userId int64, appointmentId int64, reason string) error {
+	appointmentDao := dao.NewAppointmentDao(config.DB)
+	appointment, err := appointmentDao.FindAppointmentByID(appointmentId)
+	if err != nil {
+		return err
+	}
+	if appointment == nil {
+		return errors.New("预约不存在")
+	}
+	lock, err := utils.TryLock(constant.AppointmentLockPrefix+strconv.FormatInt(appointment.SlotID, 10), 10*time.Second)
+	if err != nil {
+		return errors.New("该时段正在处理中，请稍后重试")
+	}
+	defer func() { _ = utils.Unlock(lock) }()
+
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		appointmentDao := dao.NewAppointmentDao(tx)
+		appointment, err := appointmentDao.FindAppointmentByID(appointmentId) // 加锁后重新读取最新状态
+		if err != nil {
+			return err
+		}
+		if appointment == nil {
+			return errors.New("预约不存在")
+		}
+		if appointment.Status != models.AppointmentStatusBooked {
+			return errors.New("只能取消已预约状态的预约")
+		}
+		now := time.Now()
+		if !appointment.StartAt.After(now) {
+			return errors.New("预约已开始，不能取消")
+		}
+		var notifyUserId int64
+		switch userId {
+		case appointment.StudentID:
+			deadline := appointment.StartAt.Add(-time.Duration(config.AppointmentSettings.CancelBeforeHours) * time.Hour)
+			if now.After(deadline) {
+				return fmt.Errorf("需在预约开始前 %d 小时取消，请直接联系辅导员", config.AppointmentSettings.CancelBeforeHours)
+			}
+			notifyUserId = appointment.CounselorID
+		case appointment.CounselorID:
+			notifyUserId = appointment.StudentID
+		default:
+			return errors.New("无权取消该预约")
+		}
+
+		appointment.Status = models.AppointmentStatusCancelled
+		appointment.CancelReason = strings.TrimSpace(reason)
+		appointment.CancelledBy = &userId
+		if err := appointmentDao.UpdateAppointment(appointment); err != nil {
+			return err
+		}
+		if _, err := appointmentDao.UpdateSlotStatus(appointment.SlotID, models.SlotStatusBooked, models.SlotStatusOpen); err != nil {
+			return err
+		}
+		content := fmt.Sprintf("%s 的咨询预约已取消", appointment.StartAt.Format(appointmentTimeFormat))
+		if appointment.CancelReason != "" {
+			content += "，原因：" + appointment.CancelReason
+		}
+		return notify(tx, models.Notification{
+			UserID:  notifyUserId,
+			Type:    models.NotificationAppointmentCancelled,
+			Title:   "咨询预约已取消",
+			Content: content,
+			RefID:   appointment.ID,
+		})
+	})
+}
+
+// Finish 辅导员在预约开始后记录结果：已完成或学生未到
+func (s *AppointmentService) Finish(userId int64, appointmentId int64, status string) error {
+	if status != models.AppointmentStatusCompleted && status != models.AppointmentStatusNoShow {
+		return fmt.Errorf("预约结果只能是 %s 或 %s", models.AppointmentStatusCompleted, models.AppointmentStatusNoShow)
+	}
+	appointmentDao := dao.NewAppointmentDao(config.DB)
+	appointment, err := appointmentDao.FindAppointmentByID(appointmentId)
+	if err != nil {
+		return err
+	}
+	if appointment == nil {
+		return errors.New("预约不存在")
+	}
+	if appointment.CounselorID != userId {
+		return errors.New("只能记录本人时段上的预约结果")
+	}
+	if appointment.Status != models.AppointmentStatusBooked {
+		return errors.New("只能记录已预约状态的预约结果")
+	}
+	if appointment.StartAt.After(time.Now()) {
+		return errors.New("预约尚未开始")
+	}
+	appointment.Status = status
+	return appointmentDao.UpdateAppointment(appointment)
+}
+
+// ListMine 查询学生本人的全部预约
+func (s *AppointmentService) ListMine(studentId int64) ([]models.Appointment, error) {
+	return dao.NewAppointmentDao(config.DB).ListByStudent(studentId)
+}
+
+// Calendar 按天汇总 [from, to) 内的咨询时段及其预约，counselorId 为 nil 时不限辅导员；预约含学生姓名和事由，仅管理员可查询
+func (s *AppointmentService) Calendar(from time.Time, to time.Time, counselorId *int64, operator *dao.DataScope) ([]vo.CalendarDay, error) {
+	if err := requireAdmin(operator); err != nil {
+		return nil, err
+	}
+	if !to.After(from) {
+		return nil, errors.New("结束日期必须晚于开始日期")
+	}
+	if to.Sub(from) > 92*24*time.Hour {
+		return nil, errors.New("日历查询范围不能超过 92 天")
+	}
+	appointmentDao := dao.NewAppointmentDao(config.DB)
+	slots, err := appointmentDao.ListSlots(dao.SlotFilter{CounselorID: counselorId, From: from, To: to})
+	if err != nil {
+		return nil, err
+	}
+	slotIds := make([]int64, 0, len(slots))
+	for _, slot := range slots {
+		slotIds = append(slotIds, slot.ID)
+	}
+	appointments, err := appointmentDao.ListBySlotIDs(slotIds)
+	if err != nil {
+		return nil, err
+	}
+	bySlot := make(map[int64]*models.Appointment, len(appointments))
+	for i := range appointments {
+		bySlot[appointments[i].SlotID] = &appointments[i]
+	}
+
+	days := []vo.CalendarDay{}
+	for _, slot := range slots {
+		date := slot.StartAt.In(time.Local).Format("2006-01-02")
+		if len(days) == 0 || days[len(days)-1].Date != date {
+			days = append(days, vo.CalendarDay{Date: date, Slots: []vo.CalendarSlot{}})
+		}
+		day := &days[len(days)-1]
+		day.Slots = append(day.Slots, vo.CalendarSlot{AppointmentSlot: slot, Appointment: bySlot[slot.ID]})
+		switch slot.Status {
+		case models.SlotStatusOpen:
+			day.OpenCount++
+		case models.SlotStatusBooked:
+			day.BookedCount++
+		case models.SlotStatusClosed:
+			day.ClosedCount++
+		}
+	}
+	return days, nil
+}
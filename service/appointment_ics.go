@@ -0,0 +1,83 @@
+package service
+
+import (
+	"fmt"
+	"mental/config"
+	"mental/dao"
+	"mental/models"
+	"strings"
+	"time"
+)
+
+const (
+	icsTimeFormat = "20060102T150405Z"  // iCalendar UTC 时间格式
+	icsLookback   = 30 * 24 * time.Hour // 导出最近 30 天起的预约
+)
+
+// icsEscape 按 RFC 5545 转义文本值中的反斜杠、分号、逗号和换行
+func icsEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `;`, `\;`, `,`, `\,`, "\r\n", `\n`, "\n", `\n`).Replace(s)
+}
+
+// icsFold 按 RFC 5545 将超过 75 字节的内容行折行，续行以空格开头，不拆分多字节字符
+func icsFold(line string) string {
+	var builder strings.Builder
+	width := 0
+	for _, r := range line {
+		size := len(string(r))
+		if width+size > 75 {
+			builder.WriteString("\r\n ")
+			width = 1
+		}
+		builder.WriteRune(r)
+		width += size
+	}
+	builder.WriteString("\r\n")
+	return builder.String()
+}
+
+// ExportICS 导出辅导员最近 30 天起已预约及已完成的咨询为 iCalendar 文件内容；只能导出本人的咨询，管理员可导出任一辅导员的咨询
+func (s *AppointmentService) ExportICS(counselorId int64, operator *dao.DataScope) ([]byte, error) {
+	if counselorId != operator.UserID && !operator.IsAdmin() {
+		return nil, ErrPermissionDenied
+	}
+	now := time.Now()
+	appointments, err := dao.NewAppointmentDao(config.DB).ListByCounselor(counselorId, now.Add(-icsLookback),
+		[]string{models.AppointmentStatusBooked, models.AppointmentStatusCompleted})
+	if err != nil {
+		return nil, err
+	}
+
+	var builder strings.Builder
+	lines := []string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//mental//appointment//CN",
+		"CALSCALE:GREGORIAN",
+		"METHOD:PUBLISH",
+		"X-WR-CALNAME:" + icsEscape("心理咨询预约"),
+	}
+	for _, appointment := range appointments {
+		description := "预约事由：" + appointment.Reason
+		if appointment.Status == models.AppointmentStatusCompleted {
+			description += "\n（已完成）"
+		}
+		lines = append(lines,
+			"BEGIN:VEVENT",
+			fmt.Sprintf("UID:appointment-%d@mental", appointment.ID),
+			"DTSTAMP:"+now.UTC().Format(icsTimeFormat),
+			"DTSTART:"+appointment.StartAt.UTC().Format(icsTimeFormat),
+			"DTEND:"+appointment.EndAt.UTC().Format(icsTimeFormat),
+			"SUMMARY:"+icsEscape("心理咨询："+appointment.StudentName),
+			"LOCATION:"+icsEscape(appointment.Location),
+			"DESCRIPTION:"+icsEscape(description),
+			"STATUS:CONFIRMED",
+			"END:VEVENT",
+		)
+	}
+	lines = append(lines, "END:VCALENDAR")
+	for _, line := range lines {
+		builder.WriteString(icsFold(line))
+	}
+	return []byte(builder.String()), nil
+}
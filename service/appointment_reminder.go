@@ -0,0 +1,74 @@
+package service
+
+import (
+	"fmt"
+	"mental/config"
+	"mental/constant"
+	"mental/dao"
+	"mental/models"
+	"mental/utils"
+	"time"
+)
+
+// StartAppointmentReminder 启动预约提醒后台任务：按配置的间隔扫描即将开始的预约，向学生和辅导员发送站内提醒
+// 每轮扫描先获取分布式锁，多实例部署时同一时刻只有一个实例执行
+func StartAppointmentReminder() {
+	interval := time.Duration(config.AppointmentSettings.ScanSeconds) * time.Second
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for now := range ticker.C {
+			if err := sendAppointmentReminders(now, interval); err != nil {
+				fmt.Printf("发送预约提醒失败: %v\n", err)
+			}
+		}
+	}()
+}
+
+// sendAppointmentReminders 为提醒窗口内尚未提醒的预约发送提醒，每条预约只提醒一次
+func sendAppointmentReminders(now time.Time, interval time.Duration) error {
+	lock, err := utils.TryLock(constant.AppointmentReminderLock, interval)
+	if err != nil {
+		return nil // 其他实例正在扫描
+	}
+	defer func() { _ = utils.Unlock(lock) }()
+
+	appointmentDao := dao.NewAppointmentDao(config.DB)
+	before := now.Add(time.Duration(config.AppointmentSettings.ReminderMinutes) * time.Minute)
+	appointments, err := appointmentDao.ListDueReminders(now, before)
+	if err != nil {
+		return err
+	}
+	for _, appointment := range appointments {
+		marked, err := appointmentDao.MarkReminderSent(appointment.ID, now)
+		if err != nil {
+			return err
+		}
+		if !marked {
+			continue
+		}
+		start := appointment.StartAt.Format(appointmentTimeFormat)
+		if err := notify(config.DB,
+			models.Notification{
+				UserID:  appointment.StudentID,
+				Type:    models.NotificationAppointmentReminder,
+				Title:   "咨询预约即将开始",
+				Content: fmt.Sprintf("您预约的心理咨询将于 %s 开始，地点：%s，辅导员：%s", start, appointment.Location, appointment.CounselorName),
+				RefID:   appointment.ID,
+			},
+			models.Notification{
+				UserID:  appointment.CounselorID,
+				Type:    models.NotificationAppointmentReminder,
+				Title:   "咨询预约即将开始",
+				Content: fmt.Sprintf("%s 的咨询将于 %s 开始，地点：%s", appointment.StudentName, start, appointment.Location),
+				RefID:   appointment.ID,
+			},
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
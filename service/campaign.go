@@ -0,0 +1,459 @@
+package service
+
+import (
+	"errors"
+	"gorm.io/gorm"
+	"mental/config"
+	"mental/dao"
+	"mental/models"
+	"mental/vo"
+	"sort"
+	"strings"
+	"time"
+)
+
+// campaignLevels 比较视图中统计的等级
+var campaignLevels = []string{models.SCLLevelNormal, models.SCLLevelMild, models.SCLLevelModerate, models.SCLLevelSevere}
+
+// matchCampaign 查找测评记录应关联的筛查批次：使用同一量表、开放窗口覆盖测评日期，且学生所在班级属于目标组织
+// 多个批次符合时取开放时间最晚的；学生为空或不在花名册中时不关联
+func matchCampaign(scaleCode string, studentId *int64, testDate models.CustomTime) (*int64, error) {
+	orgId, err := studentOrgID(studentId)
+	if err != nil || orgId == 0 {
+		return nil, err
+	}
+	campaigns, err := dao.NewCampaignDao(config.DB).ListOpenOn(scaleCode, time.Time(testDate))
+	if err != nil || len(campaigns) == 0 {
+		return nil, err
+	}
+	orgDao := dao.NewOrgDao(config.DB)
+	class, err := orgDao.FindByID(orgId)
+	if err != nil || class == nil {
+		return nil, err
+	}
+	for _, campaign := range campaigns {
+		targets, err := campaignTargetOrgs(campaign)
+		if err != nil {
+			return nil, err
+		}
+		for _, target := range targets {
+			if strings.HasPrefix(class.Path, target.Path) {
+				return &campaign.ID, nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+// campaignTargetOrgs 查询筛查批次的目标组织
+func campaignTargetOrgs(campaign models.Campaign) ([]models.OrgUnit, error) {
+	ids := make([]int64, 0, len(campaign.Targets))
+	for _, target := range campaign.Targets {
+		ids = append(ids, target.OrgID)
+	}
+	return dao.NewOrgDao(config.DB).FindByIDs(ids)
+}
+
+// CampaignService 批量筛查批次业务
+type CampaignService struct {
+}
+
+// NewCampaignService 创建新的 CampaignService
+func NewCampaignService() *CampaignService {
+	return &CampaignService{}
+}
+
+// buildCampaign 校验表单并转换为筛查批次：量表存在，开放时间早于截止时间，目标组织非空且均存在
+func buildCampaign(form vo.CampaignForm) (*models.Campaign, error) {
+	campaign := &models.Campaign{
+		ID:        form.ID,
+		Name:      strings.TrimSpace(form.Name),
+		ScaleCode: strings.TrimSpace(form.ScaleCode),
+		OpenAt:    form.OpenAt,
+		CloseAt:   form.CloseAt,
+		Remark:    form.Remark,
+	}
+	if campaign.Name == "" {
+		return nil, errors.New("批次名称不能为空")
+	}
+	if _, err := loadScale(campaign.ScaleCode); err != nil {
+		return nil, err
+	}
+	if campaign.OpenAt.IsZero() || campaign.CloseAt.IsZero() || !campaign.OpenAt.Before(campaign.CloseAt) {
+		return nil, errors.New("开放时间必须早于截止时间")
+	}
+	seen := make(map[int64]bool, len(form.OrgIDs))
+	for _, orgId := range form.OrgIDs {
+		if seen[orgId] {
+			continue
+		}
+		seen[orgId] = true
+		campaign.Targets = append(campaign.Targets, models.CampaignTarget{CampaignID: campaign.ID, OrgID: orgId})
+	}
+	if len(campaign.Targets) == 0 {
+		return nil, errors.New("目标组织不能为空")
+	}
+	orgs, err := campaignTargetOrgs(*campaign)
+	if err != nil {
+		return nil, err
+	}
+	if len(orgs) != len(campaign.Targets) {
+		return nil, errors.New("目标组织不存在")
+	}
+	return campaign, nil
+}
+
+// requireCampaignTargets 只允许管理员或全部目标组织（含上级组织）的负责人维护筛查批次，其余用户返回 ErrPermissionDenied
+func requireCampaignTargets(targets []models.CampaignTarget, operator *dao.DataScope) error {
+	if operator.IsAdmin() {
+		return nil
+	}
+	for _, target := range targets {
+		if err := requireOrgManager(target.OrgID, operator); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CreateCampaign 新建筛查批次；仅管理员或全部目标组织的负责人可操作
+func (s *CampaignService) CreateCampaign(form vo.CampaignForm, operator *dao.DataScope) (*models.Campaign, error) {
+	form.ID = 0
+	campaign, err := buildCampaign(form)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireCampaignTargets(campaign.Targets, operator); err != nil {
+		return nil, err
+	}
+	campaign.CreatedBy = operator.UserID
+	if err := dao.NewCampaignDao(config.DB).Save(campaign); err != nil {
+		return nil, err
+	}
+	campaign.Status = campaign.StatusAt(time.Now())
+	return campaign, nil
+}
+
+// UpdateCampaign 修改筛查批次及其目标组织；已关联的测评记录保持不变，修改只影响之后提交的记录
+// 仅管理员或原有及新的全部目标组织的负责人可操作
+func (s *CampaignService) UpdateCampaign(form vo.CampaignForm, operator *dao.DataScope) error {
+	existing, err := findCampaign(form.ID)
+	if err != nil {
+		return err
+	}
+	if err := requireCampaignTargets(existing.Targets, operator); err != nil {
+		return err
+	}
+	campaign, err := buildCampaign(form)
+	if err != nil {
+		return err
+	}
+	if err := requireCampaignTargets(campaign.Targets, operator); err != nil {
+		return err
+	}
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		campaignDao := dao.NewCampaignDao(tx)
+		if err := campaignDao.Update(campaign); err != nil {
+			return err
+		}
+		return campaignDao.ReplaceTargets(campaign.ID, campaign.Targets)
+	})
+}
+
+// findCampaign 查询筛查批次，不存在时返回错误
+func findCampaign(id int64) (*models.Campaign, error) {
+	campaign, err := dao.NewCampaignDao(config.DB).FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if campaign == nil {
+		return nil, errors.New("筛查批次不存在")
+	}
+	campaign.Status = campaign.StatusAt(time.Now())
+	return campaign, nil
+}
+
+// GetCampaign 查询筛查批次详情
+func (s *CampaignService) GetCampaign(id int64) (*models.Campaign, error) {
+	return findCampaign(id)
+}
+
+// ListCampaigns 查询筛查批次，按开放时间倒序；scaleCode 为空时不限量表
+func (s *CampaignService) ListCampaigns(scaleCode string) ([]models.Campaign, error) {
+	list, err := dao.NewCampaignDao(config.DB).List(scaleCode)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	for i := range list {
+		list[i].Status = list[i].StatusAt(now)
+	}
+	return list, nil
+}
+
+// campaignClasses 查询目标组织下的全部班级（去重，按目标顺序），数据范围不是全部时只保留范围内的班级
+func campaignClasses(campaign models.Campaign, scope *dao.DataScope) ([]models.OrgUnit, error) {
+	targets, err := campaignTargetOrgs(campaign)
+	if err != nil {
+		return nil, err
+	}
+	orgDao := dao.NewOrgDao(config.DB)
+	classes := []models.OrgUnit{}
+	seen := make(map[int64]bool)
+	for _, target := range targets {
+		units, err := orgDao.ListSubtree(target.Path)
+		if err != nil {
+			return nil, err
+		}
+		for _, unit := range units {
+			if unit.Type != models.OrgTypeClass || seen[unit.ID] || !inDataScope(unit, scope) {
+				continue
+			}
+			seen[unit.ID] = true
+			classes = append(classes, unit)
+		}
+	}
+	return classes, nil
+}
+
+// inDataScope 判断组织是否在数据范围负责的组织子树内
+func inDataScope(org models.OrgUnit, scope *dao.DataScope) bool {
+	if scope == nil || scope.All {
+		return true
+	}
+	for _, path := range scope.OrgPaths {
+		if strings.HasPrefix(org.Path, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// campaignResults 查询关联到筛查批次的全部测评结果，按测评日期倒序
+func campaignResults(campaign models.Campaign, scope *dao.DataScope) ([]vo.ScaleResultVO, error) {
+	results := []vo.ScaleResultVO{}
+	if campaign.ScaleCode == sclScaleCode {
+		scls, err := dao.NewSCLDao(config.DB).WithScope(scope).SelectByCampaign(campaign.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, scl := range scls {
+			result, err := sclToScaleResult(scl)
+			if err != nil {
+				return nil, err
+			}
+			results = append(results, *result)
+		}
+		return results, nil
+	}
+
+	scale, err := loadScale(campaign.ScaleCode)
+	if err != nil {
+		return nil, err
+	}
+	responses, err := dao.NewScaleDao(config.DB).ListResponsesByCampaign(campaign.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, response := range responses {
+		result, err := responseToScaleResult(scale, response)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, *result)
+	}
+	return results, nil
+}
+
+// campaignProgress 统计筛查批次各目标班级的完成情况，并返回每名已完成学生最近一次有效作答的结果
+func campaignProgress(campaign models.Campaign, scope *dao.DataScope) (*vo.CampaignProgress, []vo.ScaleResultVO, error) {
+	classes, err := campaignClasses(campaign, scope)
+	if err != nil {
+		return nil, nil, err
+	}
+	results, err := campaignResults(campaign, scope)
+	if err != nil {
+		return nil, nil, err
+	}
+	// 结果按测评日期倒序，每名学生优先取最近一次有效作答，没有时取最近一次无效作答
+	latest := make(map[int64]vo.ScaleResultVO)
+	for _, result := range results {
+		if result.StudentID == nil {
+			continue
+		}
+		if existing, ok := latest[*result.StudentID]; !ok || (existing.Invalid && !result.Invalid) {
+			latest[*result.StudentID] = result
+		}
+	}
+
+	orgDao := dao.NewOrgDao(config.DB)
+	progress := &vo.CampaignProgress{Campaign: campaign, Classes: make([]vo.CampaignClassProgress, 0, len(classes))}
+	completed := []vo.ScaleResultVO{}
+	for _, class := range classes {
+		members, err := orgDao.ListMembers(class.Path)
+		if err != nil {
+			return nil, nil, err
+		}
+		classProgress := vo.CampaignClassProgress{
+			OrgID:     class.ID,
+			OrgName:   class.Name,
+			OrgCode:   class.Code,
+			Completed: []vo.CampaignStudent{},
+			Pending:   []vo.CampaignStudent{},
+		}
+		for _, member := range members {
+			if member.OrgID != class.ID {
+				continue
+			}
+			classProgress.MemberCount++
+			student := vo.CampaignStudent{MemberID: member.ID, UserID: member.UserID, StudentNo: member.StudentNo, Name: member.Name}
+			result, ok := vo.ScaleResultVO{}, false
+			if member.UserID != nil {
+				result, ok = latest[*member.UserID]
+			}
+			if ok {
+				testDate := result.TestDate
+				student.RecordID, student.TestDate, student.Level, student.Invalid = result.ID, &testDate, result.Level, result.Invalid
+			}
+			if !ok || result.Invalid {
+				classProgress.Pending = append(classProgress.Pending, student)
+				continue
+			}
+			classProgress.CompletedCount++
+			classProgress.Completed = append(classProgress.Completed, student)
+			completed = append(completed, result)
+		}
+		if classProgress.MemberCount > 0 {
+			classProgress.CompletionRate = roundIndex(float64(classProgress.CompletedCount) * 100 / float64(classProgress.MemberCount))
+		}
+		progress.MemberCount += classProgress.MemberCount
+		progress.CompletedCount += classProgress.CompletedCount
+		progress.Classes = append(progress.Classes, classProgress)
+	}
+	if progress.MemberCount > 0 {
+		progress.CompletionRate = roundIndex(float64(progress.CompletedCount) * 100 / float64(progress.MemberCount))
+	}
+	return progress, completed, nil
+}
+
+// Progress 查询筛查批次各目标班级的完成情况：已完成与未完成的学生名单，只有无效作答的学生计为未完成
+func (s *CampaignService) Progress(id int64, scope *dao.DataScope) (*vo.CampaignProgress, error) {
+	campaign, err := findCampaign(id)
+	if err != nil {
+		return nil, err
+	}
+	progress, _, err := campaignProgress(*campaign, scope)
+	return progress, err
+}
+
+// summarizeCampaign 汇总一个批次已完成学生的等级分布和总分、各分量表得分的均值与标准差
+func summarizeCampaign(progress *vo.CampaignProgress, completed []vo.ScaleResultVO) vo.CampaignSummary {
+	summary := vo.CampaignSummary{
+		Campaign:       progress.Campaign,
+		MemberCount:    progress.MemberCount,
+		CompletedCount: progress.CompletedCount,
+		CompletionRate: progress.CompletionRate,
+		LevelCounts:    make(map[string]int, len(campaignLevels)),
+		LevelPercent:   make(map[string]float64, len(campaignLevels)),
+		Metrics:        []vo.CampaignMetric{{Key: "score", Name: "总分"}},
+	}
+	for _, level := range campaignLevels {
+		summary.LevelCounts[level] = 0
+	}
+	values := [][]float64{{}}
+	index := map[string]int{"score": 0}
+	for _, result := range completed {
+		summary.LevelCounts[result.Level]++
+		if result.Level != models.SCLLevelNormal {
+			summary.PositiveCount++
+		}
+		values[0] = append(values[0], result.Score)
+		for _, subscore := range result.Subscores {
+			i, ok := index[subscore.Key]
+			if !ok {
+				i = len(values)
+				index[subscore.Key] = i
+				values = append(values, nil)
+				summary.Metrics = append(summary.Metrics, vo.CampaignMetric{Key: subscore.Key, Name: subscore.Name})
+			}
+			values[i] = append(values[i], subscore.Score)
+		}
+	}
+	for i := range summary.Metrics {
+		summary.Metrics[i].Mean, summary.Metrics[i].SD = meanSD(values[i])
+	}
+	for level, count := range summary.LevelCounts {
+		summary.LevelPercent[level] = 0
+		if len(completed) > 0 {
+			summary.LevelPercent[level] = roundIndex(float64(count) * 100 / float64(len(completed)))
+		}
+	}
+	if len(completed) > 0 {
+		summary.PositiveRate = roundIndex(float64(summary.PositiveCount) * 100 / float64(len(completed)))
+	}
+	return summary
+}
+
+// Compare 比较使用同一量表的多个筛查批次：完成率、等级分布、阳性率及各得分指标的均值变化，批次按开放时间正序
+func (s *CampaignService) Compare(ids []int64, scope *dao.DataScope) (*vo.CampaignComparison, error) {
+	campaigns, err := dao.NewCampaignDao(config.DB).FindByIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+	if len(campaigns) < 2 {
+		return nil, errors.New("至少需要两个存在的筛查批次才能比较")
+	}
+	for _, campaign := range campaigns[1:] {
+		if campaign.ScaleCode != campaigns[0].ScaleCode {
+			return nil, errors.New("只能比较使用同一量表的筛查批次")
+		}
+	}
+	scale, err := loadScale(campaigns[0].ScaleCode)
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(campaigns, func(i, j int) bool {
+		return campaigns[i].OpenAt.Before(campaigns[j].OpenAt)
+	})
+
+	comparison := &vo.CampaignComparison{
+		ScaleCode: scale.Code,
+		ScaleName: scale.Name,
+		Campaigns: make([]vo.CampaignSummary, 0, len(campaigns)),
+		Series: []vo.CampaignSeries{
+			{Key: "completion_rate", Name: "完成率（%）"},
+			{Key: "positive_rate", Name: "阳性率（%）"},
+		},
+	}
+	now := time.Now()
+	for _, campaign := range campaigns {
+		campaign.Status = campaign.StatusAt(now)
+		progress, completed, err := campaignProgress(campaign, scope)
+		if err != nil {
+			return nil, err
+		}
+		comparison.Campaigns = append(comparison.Campaigns, summarizeCampaign(progress, completed))
+	}
+
+	// 指标序列以各批次出现过的指标为准，批次中没有的指标均值记为 0
+	seriesOf := make(map[string]int)
+	for _, summary := range comparison.Campaigns {
+		for _, metric := range summary.Metrics {
+			if _, ok := seriesOf[metric.Key]; !ok {
+				seriesOf[metric.Key] = len(comparison.Series)
+				comparison.Series = append(comparison.Series, vo.CampaignSeries{Key: metric.Key, Name: metric.Name})
+			}
+		}
+	}
+	for i := range comparison.Series {
+		comparison.Series[i].Values = make([]float64, len(comparison.Campaigns))
+	}
+	for c, summary := range comparison.Campaigns {
+		comparison.Series[0].Values[c] = summary.CompletionRate
+		comparison.Series[1].Values[c] = summary.PositiveRate
+		for _, metric := range summary.Metrics {
+			comparison.Series[seriesOf[metric.Key]].Values[c] = metric.Mean
+		}
+	}
+	return comparison, nil
+}
@@ -0,0 +1,401 @@
+package service
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"math"
+	"mental/config"
+	"mental/constant"
+	"mental/dao"
+	"mental/models"
+	"mental/utils"
+	"mental/vo"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// 群体统计的分组维度
+const (
+	CohortGroupGender  = "gender"   // 按性别
+	CohortGroupAgeBand = "age_band" // 按年龄段
+	CohortGroupOrg     = "org"      // 按组织（指定组织的直接下级）
+	CohortGroupPeriod  = "period"   // 按测评周期
+)
+
+// cohortStatsTTL 群体统计结果的缓存时间，数据变化时通过版本号提前失效
+const cohortStatsTTL = 30 * time.Minute
+
+// cohortBatchSize 统计时每批从数据库读取的记录数
+const cohortBatchSize = 1000
+
+// defaultAgeBands 默认年龄分段下界
+var defaultAgeBands = []int{18, 21, 24, 27}
+
+// cohortHistogram 指标分布直方图的区间设置：起点、区间宽度、区间数
+type cohortHistogram struct {
+	Start float64
+	Width float64
+	Bins  int
+}
+
+// cohortHistogramOf 按指标取直方图区间：因子均分与总症状指数 1-5 分每 0.5 分一档，总分 90-450 每 45 分一档，阳性项目数 0-90 每 10 项一档
+func cohortHistogramOf(key string) cohortHistogram {
+	switch key {
+	case "total_score":
+		return cohortHistogram{Start: 90, Width: 45, Bins: 8}
+	case "positive_items":
+		return cohortHistogram{Start: 0, Width: 10, Bins: 9}
+	}
+	return cohortHistogram{Start: 1, Width: 0.5, Bins: 8}
+}
+
+// invalidateCohortStats 测评或花名册数据变化后使群体统计缓存失效，失败时缓存最迟在过期后更新
+func invalidateCohortStats() {
+	if _, err := utils.Incr(constant.CohortStatsVersion); err != nil {
+		fmt.Printf("群体统计缓存失效失败: %v\n", err)
+	}
+}
+
+// cohortAcc 一个分组的累加器
+type cohortAcc struct {
+	group    vo.CohortGroup
+	rank     int
+	values   [][]float64
+	students map[int64]bool
+}
+
+// newCohortAcc 创建分组累加器
+func newCohortAcc(key string, label string, rank int) *cohortAcc {
+	return &cohortAcc{
+		group: vo.CohortGroup{Key: key, Label: label, LevelCounts: map[string]int{
+			models.SCLLevelNormal:   0,
+			models.SCLLevelMild:     0,
+			models.SCLLevelModerate: 0,
+			models.SCLLevelSevere:   0,
+		}},
+		rank:     rank,
+		values:   make([][]float64, len(sclMetrics())),
+		students: make(map[int64]bool),
+	}
+}
+
+// add 累加一条记录及其等级
+func (a *cohortAcc) add(scl models.SCL, level string) {
+	a.group.RecordCount++
+	if scl.StudentID != nil {
+		a.students[*scl.StudentID] = true
+	}
+	a.group.LevelCounts[level]++
+	if level != models.SCLLevelNormal {
+		a.group.PositiveCount++
+	}
+	for i, metric := range sclMetrics() {
+		a.values[i] = append(a.values[i], sclMetricValue(scl, metric.Key))
+	}
+}
+
+// result 计算均值、标准差、直方图和各比率
+func (a *cohortAcc) result() vo.CohortGroup {
+	group := a.group
+	group.StudentCount = len(a.students)
+	group.Metrics = make([]vo.CohortMetric, 0, len(a.values))
+	for i, metric := range sclMetrics() {
+		group.Metrics = append(group.Metrics, cohortMetricOf(metric, a.values[i]))
+	}
+	group.LevelPercent = make(map[string]float64, len(group.LevelCounts))
+	for level, count := range group.LevelCounts {
+		group.LevelPercent[level] = 0
+		if group.RecordCount > 0 {
+			group.LevelPercent[level] = roundIndex(float64(count) * 100 / float64(group.RecordCount))
+		}
+	}
+	if group.RecordCount > 0 {
+		group.PositiveRate = roundIndex(float64(group.PositiveCount) * 100 / float64(group.RecordCount))
+	}
+	return group
+}
+
+// cohortMetricOf 计算一项指标的均值、样本标准差和直方图，超出区间的值计入首尾区间
+func cohortMetricOf(metric sclMetric, values []float64) vo.CohortMetric {
+	result := vo.CohortMetric{Key: metric.Key, Name: metric.Name}
+	histogram := cohortHistogramOf(metric.Key)
+	result.Histogram = make([]vo.HistogramBin, histogram.Bins)
+	for i := range result.Histogram {
+		result.Histogram[i].Min = histogram.Start + float64(i)*histogram.Width
+		result.Histogram[i].Max = histogram.Start + float64(i+1)*histogram.Width
+	}
+	if len(values) == 0 {
+		return result
+	}
+	for _, value := range values {
+		bin := int(math.Floor((value - histogram.Start) / histogram.Width))
+		if bin < 0 {
+			bin = 0
+		}
+		if bin >= histogram.Bins {
+			bin = histogram.Bins - 1
+		}
+		result.Histogram[bin].Count++
+	}
+	result.Mean, result.SD = meanSD(values)
+	return result
+}
+
+// meanSD 计算均值和样本标准差（保留两位小数），不足两个值时标准差为 0
+func meanSD(values []float64) (float64, float64) {
+	if len(values) == 0 {
+		return 0, 0
+	}
+	sum := 0.0
+	for _, value := range values {
+		sum += value
+	}
+	mean := sum / float64(len(values))
+	if len(values) < 2 {
+		return roundIndex(mean), 0
+	}
+	squares := 0.0
+	for _, value := range values {
+		squares += (value - mean) * (value - mean)
+	}
+	return roundIndex(mean), roundIndex(math.Sqrt(squares / float64(len(values)-1)))
+}
+
+// ageBandOf 按分段下界确定年龄段，返回分段序号和名称
+func ageBandOf(age int, bands []int) (int, string) {
+	for i, lower := range bands {
+		if age < lower {
+			if i == 0 {
+				return 0, fmt.Sprintf("%d岁以下", lower)
+			}
+			return i, fmt.Sprintf("%d-%d岁", bands[i-1], lower-1)
+		}
+	}
+	return len(bands), fmt.Sprintf("%d岁及以上", bands[len(bands)-1])
+}
+
+// periodOf 按周期确定测评日期所属的分组键
+func periodOf(date time.Time, period string) string {
+	switch period {
+	case "year":
+		return strconv.Itoa(date.Year())
+	case "quarter":
+		return fmt.Sprintf("%d-Q%d", date.Year(), (int(date.Month())-1)/3+1)
+	}
+	return date.Format("2006-01")
+}
+
+// CohortService 群体统计（筛查看板）业务
+type CohortService struct {
+}
+
+// NewCohortService 创建新的 CohortService
+func NewCohortService() *CohortService {
+	return &CohortService{}
+}
+
+// normalizeCohortQuery 校验分组维度并补全默认值
+func normalizeCohortQuery(query *vo.CohortQuery) error {
+	switch query.GroupBy {
+	case "", CohortGroupGender, CohortGroupOrg:
+	case CohortGroupAgeBand:
+		if len(query.AgeBands) == 0 {
+			query.AgeBands = defaultAgeBands
+		}
+		if !sort.IntsAreSorted(query.AgeBands) {
+			return errors.New("年龄分段必须升序排列")
+		}
+	case CohortGroupPeriod:
+		if query.Period == "" {
+			query.Period = "month"
+		}
+		if query.Period != "month" && query.Period != "quarter" && query.Period != "year" {
+			return fmt.Errorf("统计周期 %s 无效，只能是 month/quarter/year", query.Period)
+		}
+	default:
+		return fmt.Errorf("分组维度 %s 无效，只能是 gender/age_band/org/period", query.GroupBy)
+	}
+	if query.GroupBy != CohortGroupAgeBand {
+		query.AgeBands = nil
+	}
+	if query.GroupBy != CohortGroupPeriod {
+		query.Period = ""
+	}
+	return nil
+}
+
+// cohortCacheKey 缓存键：数据版本号 + 查询条件与数据范围的摘要，不同数据范围的用户不共享缓存
+func cohortCacheKey(query vo.CohortQuery, scope *dao.DataScope) (string, error) {
+	version := "0"
+	value, err := utils.Get(constant.CohortStatsVersion)
+	if err == nil {
+		version = value.(string)
+	} else if !errors.Is(err, redis.Nil) {
+		return "", err
+	}
+	scopeKey := "all"
+	if scope != nil && !scope.All {
+		scopeKey = fmt.Sprintf("%d:%v", scope.UserID, scope.OrgPaths)
+	}
+	content, _ := json.Marshal(struct {
+		Query vo.CohortQuery
+		Scope string
+	}{query, scopeKey})
+	sum := md5.Sum(content)
+	return constant.CohortStatsPrefix + version + ":" + hex.EncodeToString(sum[:]), nil
+}
+
+// Stats 统计数据范围内测评记录的因子均值/标准差、分布直方图、等级占比和筛查阳性率，可按性别、年龄段、组织或测评周期分组
+// 每条记录按其生成时所用的规则集判断等级；默认不计入无效作答。结果按数据版本缓存于 Redis，测评或花名册数据变化时失效
+func (s *CohortService) Stats(query vo.CohortQuery, scope *dao.DataScope) (*vo.CohortStats, error) {
+	if err := normalizeCohortQuery(&query); err != nil {
+		return nil, err
+	}
+	cacheKey, err := cohortCacheKey(query, scope)
+	if err != nil {
+		return nil, err
+	}
+	if value, err := utils.Get(cacheKey); err == nil {
+		var cached vo.CohortStats
+		if json.Unmarshal([]byte(value.(string)), &cached) == nil {
+			cached.Cached = true
+			return &cached, nil
+		}
+	}
+
+	stats, err := s.compute(query, scope)
+	if err != nil {
+		return nil, err
+	}
+	if content, err := json.Marshal(stats); err == nil {
+		if err := utils.Set(cacheKey, string(content), cohortStatsTTL); err != nil {
+			fmt.Printf("缓存群体统计失败: %v\n", err)
+		}
+	}
+	return stats, nil
+}
+
+// compute 从数据库读取记录并计算统计结果
+func (s *CohortService) compute(query vo.CohortQuery, scope *dao.DataScope) (*vo.CohortStats, error) {
+	sclDao := dao.NewSCLDao(config.DB).WithScope(scope)
+	orgDao := dao.NewOrgDao(config.DB)
+	var (
+		org *models.OrgUnit
+		err error
+	)
+	orgPath := ""
+	if query.OrgID != 0 {
+		if org, err = findOrg(query.OrgID); err != nil {
+			return nil, err
+		}
+		orgPath = org.Path
+	}
+	filter := dao.SCLFilter{From: query.From, To: query.To, OrgPath: orgPath, ValidOnly: !query.IncludeInvalid}
+
+	// 按组织分组时，学生归入其班级所在的直接下级组织（未指定组织时为各学校），不在花名册中的归入“未分配”
+	var studentGroup map[int64]*models.OrgUnit
+	orgRank := make(map[int64]int)
+	if query.GroupBy == CohortGroupOrg {
+		units, err := orgDao.ListSubtree(orgPath)
+		if err != nil {
+			return nil, err
+		}
+		members, err := orgDao.ListMembers(orgPath)
+		if err != nil {
+			return nil, err
+		}
+		depth := 0
+		if org != nil {
+			depth = len(org.AncestorIDs())
+		}
+		unitByID := make(map[int64]*models.OrgUnit, len(units))
+		for i := range units {
+			unitByID[units[i].ID] = &units[i]
+			if len(units[i].AncestorIDs()) == depth+1 {
+				orgRank[units[i].ID] = len(orgRank)
+			}
+		}
+		studentGroup = make(map[int64]*models.OrgUnit, len(members))
+		for _, member := range members {
+			unit := unitByID[member.OrgID]
+			if member.UserID == nil || unit == nil {
+				continue
+			}
+			if ancestors := unit.AncestorIDs(); len(ancestors) > depth {
+				studentGroup[*member.UserID] = unitByID[ancestors[depth]]
+			}
+		}
+	}
+
+	total := newCohortAcc("total", "总体", 0)
+	groups := make(map[string]*cohortAcc)
+	err = sclDao.EachBatch(filter, cohortBatchSize, func(scls []models.SCL) error {
+		for _, scl := range scls {
+			ensureSCLIndices(&scl)
+			rules, err := loadSCLRules(scl.RuleSetID)
+			if err != nil {
+				return err
+			}
+			level := analyzeSCL(scl, rules).Level
+			total.add(scl, level)
+
+			var (
+				key, label string
+				rank       int
+			)
+			switch query.GroupBy {
+			case "":
+				continue
+			case CohortGroupGender:
+				key, label, rank = strconv.Itoa(scl.Gender), "女", scl.Gender
+				if scl.Gender == 1 {
+					label = "男"
+				}
+			case CohortGroupAgeBand:
+				rank, label = ageBandOf(scl.Age, query.AgeBands)
+				key = strconv.Itoa(rank)
+			case CohortGroupPeriod:
+				key = periodOf(time.Time(scl.TestDate), query.Period)
+				label = key
+			case CohortGroupOrg:
+				key, label, rank = "0", "未分配", len(orgRank)
+				if scl.StudentID != nil {
+					if unit := studentGroup[*scl.StudentID]; unit != nil {
+						key, label, rank = strconv.FormatInt(unit.ID, 10), unit.Name, orgRank[unit.ID]
+					}
+				}
+			}
+			acc, ok := groups[key]
+			if !ok {
+				acc = newCohortAcc(key, label, rank)
+				groups[key] = acc
+			}
+			acc.add(scl, level)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	accs := make([]*cohortAcc, 0, len(groups))
+	for _, acc := range groups {
+		accs = append(accs, acc)
+	}
+	sort.Slice(accs, func(i, j int) bool {
+		if accs[i].rank != accs[j].rank {
+			return accs[i].rank < accs[j].rank
+		}
+		return accs[i].group.Key < accs[j].group.Key
+	})
+	stats := &vo.CohortStats{Query: query, Total: total.result(), Groups: make([]vo.CohortGroup, 0, len(accs)), GeneratedAt: time.Now()}
+	for _, acc := range accs {
+		stats.Groups = append(stats.Groups, acc.result())
+	}
+	return stats, nil
+}
@@ -2,20 +2,31 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"github.com/bsm/redislock"
 	"github.com/minio/minio-go/v7"
 	"github.com/xuri/excelize/v2"
+	"gorm.io/gorm"
 	"mental/config"
+	"mental/constant"
 	"mental/dao"
 	"mental/models"
 	"mental/utils"
+	"mental/vo"
 	"strconv"
 	"strings"
 	"time"
 )
 
 type FileService struct {
-	FileId string `json:"file_id"`
+	FileId    string `json:"file_id"`
+	ProfileID int64  `json:"profile_id"` // 导入使用的映射方案ID，为 0 时按组织查找启用的方案
+	OrgID     int64  `json:"org_id"`     // 查找映射方案的组织，为 0 时使用导入人所在组织
+	UserID    int64  `json:"user_id"`    // 导入人ID
+	Sheet     string `json:"sheet"`      // 读取的工作表名，为空时按映射方案
+	AllSheets bool   `json:"all_sheets"` // 读取全部工作表
+	Atomic    bool   `json:"atomic"`     // 全部成功才导入，任一行失败则整批不导入
 }
 
 // CheckFileIsExit 检查文件是否已经存在
@@ -54,120 +65,331 @@ func (fileService *FileService) SaveFile(filePath string) (string, error) {
 }
 
 // ImportFromFileId 根据 file_id 获取路径并导入 Excel 数据
-func (s *FileService) ImportFromFileId() (int, []string) {
+// 按映射方案读取工作表并按表头定位各列，表头行之后每行为一条测评记录，空行跳过；每次导入生成一个导入批次，导入的记录关联该批次。
+// 默认逐行导入，校验或写入失败的行跳过；Atomic 为 true 时全部行在同一事务中写入，任一行失败则整批不导入，文件也不标记为已解析
+// 同一文件同一时刻只允许一个导入（同步或后台任务）执行
+func (s *FileService) ImportFromFileId() *vo.ImportResult {
+	lock, err := lockImportFile(s.FileId)
+	if err != nil {
+		return &vo.ImportResult{ErrorRows: []string{err.Error()}}
+	}
+	defer func() { _ = utils.Unlock(lock) }()
+	return s.importFile(&importProgress{lock: lock})
+}
+
+// lockImportFile 获取导入文件锁，文件正在导入时返回错误
+func lockImportFile(fileId string) (*redislock.Lock, error) {
+	lock, err := utils.TryLock(constant.ImportFileLockPrefix+fileId, importLockTTL)
+	if err != nil {
+		return nil, errors.New("该文件正在导入中，请稍后再试")
+	}
+	return lock, nil
+}
+
+// importFile 在持有文件锁的情况下执行导入，progress 记录进度并在任务被取消时中止
+func (s *FileService) importFile(progress *importProgress) *vo.ImportResult {
 	fileId := s.FileId
+	result := &vo.ImportResult{}
 
 	// 1. 根据 fileId 查数据库拿到 MinIO 对象路径（完整 URL）
 	objectPath, exists := s.CheckFileIsExist(fileId)
 	if !exists {
-		return 0, []string{fmt.Sprintf("文件ID %s 不存在", fileId)}
+		result.ErrorRows = []string{fmt.Sprintf("文件ID %s 不存在", fileId)}
+		return result
 	}
 
 	// 判断文件是否已解析
 	fileDao := dao.NewFileDao(config.DB)
 	hasAnalyzed, err := fileDao.IsFileAnalyzed(fileId)
 	if err != nil {
-		return 0, []string{fmt.Sprintf("判断文件状态错误！")}
+		result.ErrorRows = []string{fmt.Sprintf("判断文件状态错误！")}
+		return result
 	}
 	if hasAnalyzed { // 如果解析过，防止重复解析
-		return 0, []string{fmt.Sprintf("该文件已经解析过！")}
+		result.ErrorRows = []string{fmt.Sprintf("该文件已经解析过！")}
+		return result
 	}
 
-	ctx := context.Background()
+	// 2. 逐行解析校验
+	var rows []importRow
+	profile, err := s.readImportRows(objectPath, func(row importRow) {
+		rows = append(rows, row)
+		progress.tick()
+	})
+	if err != nil {
+		result.ErrorRows = []string{err.Error()}
+		return result
+	}
+	if err := progress.begin(len(rows)); err != nil {
+		result.ErrorRows = []string{err.Error()}
+		return result
+	}
 
-	// 2. 处理 objectPath，去除 URL 前缀和桶名，得到 MinIO 的 objectKey
-	endpoint := config.MinioSettings.Endpoint // "8.130.77.225:9000"
-	prefix := "http://" + endpoint + "/"      // "http://8.130.77.225:9000/"
-	bucket := config.MinioSettings.Bucket     // "mental"
+	// 3. 写入并记录导入批次
+	batch := &models.ImportBatch{
+		FileID:    fileId,
+		ProfileID: profile.ID,
+		Mode:      models.ImportModePartial,
+		Status:    models.ImportBatchCompleted,
+		CreatedBy: s.UserID,
+	}
+	if s.Atomic {
+		batch.Mode = models.ImportModeAtomic
+		result.ErrorRows = importAtomic(batch, rows, progress)
+	} else {
+		result.ErrorRows = importPartial(batch, rows, progress)
+	}
+	result.BatchID, result.Status, result.SuccessNum = batch.ID, batch.Status, batch.SuccessCount
+	if batch.Status == models.ImportBatchCompleted {
+		fileDao.UpdateStatusAnalyzed(fileId) // 将文件设置为已解析
+	}
 
-	// 去掉 URL 前缀
-	objectKey := strings.TrimPrefix(objectPath, prefix) // "mental/2025-05-23/xxxx.xlsx"
+	// 4. 生成失败行文件，生成失败不影响导入结果
+	if result.ErrorFile, err = storeErrorWorkbook(rows); err != nil {
+		fmt.Printf("生成失败行文件失败: %v\n", err)
+	}
+	return result
+}
 
-	// 去掉桶名和斜杠
-	objectKey = strings.TrimPrefix(objectKey, bucket+"/") // "2025-05-23/xxxx.xlsx"
+// importPartial 逐行写入，失败的行跳过，写入失败的原因记入该行的 Err，返回错误信息；导入被取消时撤销已写入的记录，批次标记为已回滚
+func importPartial(batch *models.ImportBatch, rows []importRow, progress *importProgress) []string {
+	batchDao := dao.NewImportBatchDao(config.DB)
+	if err := batchDao.Save(batch); err != nil {
+		return []string{fmt.Sprintf("创建导入批次失败: %v", err)}
+	}
+	var errorRows []string
+	for i := range rows {
+		row := &rows[i]
+		failed := true
+		if row.Err != nil {
+			errorRows = append(errorRows, row.Label+row.Err.Error())
+		} else {
+			row.SCL.ImportBatchID = &batch.ID
+			if err := saveSCL(row.SCL); err != nil {
+				row.Err = fmt.Errorf("插入失败: %v", err)
+				errorRows = append(errorRows, row.Label+row.Err.Error())
+			} else {
+				batch.SuccessCount++
+				failed = false
+			}
+		}
+		if err := progress.step(failed); err != nil {
+			batch.ErrorCount = len(errorRows)
+			return append(errorRows, cancelPartialImport(batch, progress.userId()))
+		}
+	}
+	batch.ErrorCount = len(errorRows)
+	if err := batchDao.UpdateResult(batch); err != nil {
+		fmt.Printf("更新导入批次失败: %v\n", err)
+	}
+	return errorRows
+}
 
-	// 3. 从 MinIO 下载文件流
-	object, err := config.MinioClient.GetObject(ctx, bucket, objectKey, minio.GetObjectOptions{})
+// cancelPartialImport 撤销已取消导入中已写入的记录并关闭其预警，返回说明信息
+func cancelPartialImport(batch *models.ImportBatch, userId int64) string {
+	now := time.Now()
+	batch.Status = models.ImportBatchRolledBack
+	batch.RolledBackBy = &userId
+	batch.RolledBackAt = &now
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		sclDao := dao.NewSCLDao(tx)
+		sclIds, err := sclDao.ListIDsByImportBatch(batch.ID)
+		if err != nil {
+			return err
+		}
+		if err := closeSCLAlerts(tx, sclIds, "导入已取消，自动关闭"); err != nil {
+			return err
+		}
+		if _, err := sclDao.DeleteByImportBatch(batch.ID); err != nil {
+			return err
+		}
+		if err := dao.NewImportBatchDao(tx).UpdateResult(batch); err != nil {
+			return err
+		}
+		return dao.NewImportBatchDao(tx).MarkRolledBack(batch)
+	})
+	invalidateCohortStats()
 	if err != nil {
-		return 0, []string{fmt.Sprintf("从MinIO获取文件失败: %v", err)}
+		return fmt.Sprintf("导入已取消，但撤销已写入的 %d 条记录失败: %v", batch.SuccessCount, err)
 	}
-	defer object.Close()
+	return fmt.Sprintf("导入已取消，已撤销已写入的 %d 条记录", batch.SuccessCount)
+}
 
-	// 4. 读取 Excel 文件
-	f, err := excelize.OpenReader(object)
-	if err != nil {
-		return 0, []string{fmt.Sprintf("打开Excel失败: %v", err)}
+// importAtomic 在同一事务中写入全部行，有校验错误时不写入，写入失败的原因记入该行的 Err；失败或被取消时记录为失败的批次，返回错误信息
+func importAtomic(batch *models.ImportBatch, rows []importRow, progress *importProgress) []string {
+	var errorRows []string
+	for _, row := range rows {
+		if row.Err != nil {
+			errorRows = append(errorRows, row.Label+row.Err.Error())
+		}
+	}
+	if len(errorRows) == 0 {
+		err := config.DB.Transaction(func(tx *gorm.DB) error {
+			if err := dao.NewImportBatchDao(tx).Save(batch); err != nil {
+				return fmt.Errorf("创建导入批次失败: %v", err)
+			}
+			for i := range rows {
+				row := &rows[i]
+				row.SCL.ImportBatchID = &batch.ID
+				if err := saveSCLTx(tx, row.SCL); err != nil {
+					row.Err = fmt.Errorf("插入失败: %v", err)
+					return errors.New(row.Label + row.Err.Error())
+				}
+				if err := progress.step(false); err != nil {
+					return err
+				}
+			}
+			batch.SuccessCount = len(rows)
+			return dao.NewImportBatchDao(tx).UpdateResult(batch)
+		})
+		if err == nil {
+			invalidateCohortStats()
+			return nil
+		}
+		errorRows = append(errorRows, err.Error())
+	}
+
+	// 整批未导入，事务中分配的 ID 已随回滚失效，另行记录失败的批次
+	batch.ID = 0
+	batch.Status = models.ImportBatchFailed
+	batch.SuccessCount = 0
+	batch.ErrorCount = len(errorRows)
+	if err := dao.NewImportBatchDao(config.DB).Save(batch); err != nil {
+		fmt.Printf("记录导入批次失败: %v\n", err)
 	}
+	return errorRows
+}
+
+// importRow 一行导入数据的解析与校验结果；Row 为 0 时表示工作表级错误
+type importRow struct {
+	Sheet     string
+	Row       int
+	Label     string      // 错误信息前缀：工作表名（读取多个工作表时）和行号
+	StudentNo string      // 学生ID单元格原文
+	SCL       *models.SCL // 校验通过时为已计算派生字段的记录
+	Err       error
+	Cells     []string   // 该行单元格原文
+	Head      [][]string // 所在工作表表头行及其之前的行，同一工作表的行共用
+	Column    int        // 出错的列（从 0 开始），无法定位到单元格时为 -1
+}
 
-	rows, err := f.GetRows("Sheet1")
+// readImportRows 确定映射方案，从 MinIO 读取 Excel 文件，逐行解析并按 CreateSCL 的规则校验（不落库），依次交给 fn；
+// 返回所用的映射方案，文件级错误（文件无法打开、方案或工作表不存在）直接返回
+func (s *FileService) readImportRows(objectPath string, fn func(row importRow)) (*importProfile, error) {
+	orgId := s.OrgID
+	if orgId == 0 && s.UserID != 0 {
+		var err error
+		if orgId, err = studentOrgID(&s.UserID); err != nil {
+			return nil, fmt.Errorf("查询所在组织失败: %v", err)
+		}
+	}
+	profile, err := loadImportProfile(s.ProfileID, orgId)
+	if err != nil {
+		return nil, err
+	}
+	f, err := openExcel(objectPath)
 	if err != nil {
-		return 0, []string{fmt.Sprintf("读取工作表失败: %v", err)}
+		return nil, err
 	}
+	defer f.Close()
+	if err := s.readWorkbookRows(f, profile, fn); err != nil {
+		return nil, err
+	}
+	return profile, nil
+}
 
-	sclDao := dao.NewSCLDao(config.DB)
-	successCount := 0
-	var errorRows []string
+// readWorkbookRows 按映射方案逐行解析已打开的 Excel 文件并校验（不落库），依次交给 fn；方案中的工作表不存在时返回错误
+func (s *FileService) readWorkbookRows(f *excelize.File, profile *importProfile, fn func(row importRow)) error {
+	sheets, err := importSheets(f, profile.content, s.Sheet, s.AllSheets)
+	if err != nil {
+		return err
+	}
 
-	for i, row := range rows[1:] {
-		rowNum := i + 2
-		if len(row) < 15 {
-			errorRows = append(errorRows, fmt.Sprintf("第 %d 行数据列数不足（当前列数：%d）", rowNum, len(row)))
+	sclService := NewSCLService()
+	headerRow := profile.content.HeaderRow
+	for _, sheet := range sheets {
+		// 读取多个工作表时，错误信息带上工作表名
+		prefix := ""
+		if len(sheets) > 1 {
+			prefix = fmt.Sprintf("工作表 %s ", sheet)
+		}
+		rows, err := f.GetRows(sheet)
+		if err != nil {
+			fn(importRow{Sheet: sheet, Label: prefix, Err: fmt.Errorf("读取工作表失败: %v", err)})
+			continue
+		}
+		if len(rows) < headerRow {
+			fn(importRow{Sheet: sheet, Label: prefix, Err: errors.New("没有表头")})
+			continue
+		}
+		layout, err := resolveImportLayout(rows[headerRow-1], profile)
+		if err != nil {
+			fn(importRow{Sheet: sheet, Label: prefix, Err: err})
 			continue
 		}
 
-		func() {
-			defer func() {
-				if r := recover(); r != nil {
-					errorRows = append(errorRows, fmt.Sprintf("第 %d 行解析异常: %v", rowNum, r))
-				}
-			}()
-
-			age, err := strconv.Atoi(row[3])
-			if err != nil {
-				errorRows = append(errorRows, fmt.Sprintf("第 %d 行年龄格式错误: %v", rowNum, err))
-				return
+		for i, cells := range rows[headerRow:] {
+			if isBlankRow(cells) {
+				continue
 			}
-
-			gender, err := strconv.Atoi(row[2])
-			if err != nil {
-				errorRows = append(errorRows, fmt.Sprintf("第 %d 行性别格式错误: %v", rowNum, err))
-				return
+			row := importRow{Sheet: sheet, Row: headerRow + i + 1, StudentNo: layout.cell(cells, "student_id")}
+			row.Label = fmt.Sprintf("%s第 %d 行", prefix, row.Row)
+			row.Cells, row.Head, row.Column = cells, rows[:headerRow], -1
+			row.SCL, row.Err = parseImportRow(cells, layout, sclService)
+			if row.Err != nil {
+				row.Column = layout.errorColumn(row.Err)
 			}
+			fn(row)
+		}
+	}
+	return nil
+}
 
-			testDate, err := time.Parse("2006-01-02", row[4])
-			if err != nil {
-				errorRows = append(errorRows, fmt.Sprintf("第 %d 行测评日期格式错误: %v", rowNum, err))
-				return
-			}
+// parseImportRow 解析并校验一行数据，解析中的异常按错误返回
+func parseImportRow(cells []string, layout *importLayout, sclService *SCLService) (scl *models.SCL, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			scl, err = nil, fmt.Errorf("解析异常: %v", r)
+		}
+	}()
+	if scl, err = parseSCLRow(cells, layout); err != nil {
+		return nil, err
+	}
+	// 计算因子分、总分、阳性项目数等派生字段并校验
+	if err := sclService.validateSCL(scl); err != nil {
+		return nil, fmt.Errorf("数据校验失败: %w", err)
+	}
+	return scl, nil
+}
 
-			scl := &models.SCL{
-				StudentID:     parseInt64Ptr(row[0]),
-				Name:          row[1],
-				Gender:        gender,
-				Age:           age,
-				TestDate:      models.CustomTime(testDate),
-				Somatization:  parseFloat(row[5]),
-				Obsession:     parseFloat(row[6]),
-				Interpersonal: parseFloat(row[7]),
-				Depression:    parseFloat(row[8]),
-				Anxiety:       parseFloat(row[9]),
-				Hostility:     parseFloat(row[10]),
-				Phobia:        parseFloat(row[11]),
-				Paranoia:      parseFloat(row[12]),
-				Psychoticism:  parseFloat(row[13]),
-				Other:         parseFloat(row[14]),
-			}
+// openExcel 根据文件完整 URL 从 MinIO 下载并打开 Excel 文件
+func openExcel(objectPath string) (*excelize.File, error) {
+	ctx := context.Background()
 
-			if err := sclDao.Save(scl); err != nil {
-				errorRows = append(errorRows, fmt.Sprintf("第 %d 行插入失败: %v", rowNum, err))
-				return
-			}
+	// 处理 objectPath，去除 URL 前缀和桶名，得到 MinIO 的 objectKey
+	endpoint := config.MinioSettings.Endpoint // "8.130.77.225:9000"
+	prefix := "http://" + endpoint + "/"      // "http://8.130.77.225:9000/"
+	bucket := config.MinioSettings.Bucket     // "mental"
+
+	// 去掉 URL 前缀
+	objectKey := strings.TrimPrefix(objectPath, prefix) // "mental/2025-05-23/xxxx.xlsx"
 
-			successCount++
-		}()
+	// 去掉桶名和斜杠
+	objectKey = strings.TrimPrefix(objectKey, bucket+"/") // "2025-05-23/xxxx.xlsx"
+
+	// 从 MinIO 下载文件流
+	object, err := config.MinioClient.GetObject(ctx, bucket, objectKey, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("从MinIO获取文件失败: %v", err)
+	}
+	defer object.Close()
+
+	// 读取 Excel 文件
+	f, err := excelize.OpenReader(object)
+	if err != nil {
+		return nil, fmt.Errorf("打开Excel失败: %v", err)
 	}
-	fileDao.UpdateStatusAnalyzed(fileId) // 将文件设置为已解析
-	return successCount, errorRows
+	return f, nil
 }
 
 // parseFloat 安全解析 float32
@@ -182,6 +404,19 @@ func parseFloat(s string) float32 {
 	return float32(val)
 }
 
+// parseItems 解析逐题作答，任一题非整数即返回错误
+func parseItems(cells []string) ([]int, error) {
+	items := make([]int, len(cells))
+	for i, cell := range cells {
+		score, err := strconv.Atoi(strings.TrimSpace(cell))
+		if err != nil {
+			return nil, &sclFieldError{Item: i + 1, Err: fmt.Errorf("第 %d 题作答格式错误: %q", i+1, cell)}
+		}
+		items[i] = score
+	}
+	return items, nil
+}
+
 // parseInt64Ptr 安全解析为 *int64，如果为空或非法返回 nil
 func parseInt64Ptr(s string) *int64 {
 	if s == "" {
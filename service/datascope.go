@@ -0,0 +1,219 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"mental/config"
+	"mental/dao"
+	"mental/models"
+	"strconv"
+	"strings"
+)
+
+// ErrPermissionDenied 当前用户的角色无权执行该操作，控制器据此返回 403
+var ErrPermissionDenied = errors.New("无权执行该操作")
+
+// requireAdmin 只允许管理员执行，其余用户返回 ErrPermissionDenied
+func requireAdmin(operator *dao.DataScope) error {
+	if !operator.IsAdmin() {
+		return ErrPermissionDenied
+	}
+	return nil
+}
+
+// requireCounselor 只允许辅导员（预警分派的辅导员角色）或管理员执行，其余用户返回 ErrPermissionDenied
+func requireCounselor(operator *dao.DataScope) error {
+	if operator.IsAdmin() || operator.HasRole(strconv.Itoa(config.AlertSettings.CounselorRoleID)) {
+		return nil
+	}
+	return ErrPermissionDenied
+}
+
+// requireOrgManager 只允许管理员或组织（含其上级组织）的负责人执行，全局（orgId 为 0）的配置只允许管理员，其余用户返回 ErrPermissionDenied
+func requireOrgManager(orgId int64, operator *dao.DataScope) error {
+	if operator.IsAdmin() {
+		return nil
+	}
+	if operator == nil || orgId == 0 {
+		return ErrPermissionDenied
+	}
+	org, err := dao.NewOrgDao(config.DB).FindByID(orgId)
+	if err != nil {
+		return err
+	}
+	if org == nil {
+		return errors.New("组织不存在")
+	}
+	managed, err := dao.NewDataScopeDao(config.DB).ListManagedOrgs(operator.UserID)
+	if err != nil {
+		return err
+	}
+	for _, unit := range managed {
+		if strings.HasPrefix(org.Path, unit.Path) {
+			return nil
+		}
+	}
+	return ErrPermissionDenied
+}
+
+// checkStudentInScope 学生不在当前用户的数据范围内时返回 ErrPermissionDenied
+func checkStudentInScope(studentId int64, scope *dao.DataScope) error {
+	ok, err := scope.ContainsStudent(config.DB, studentId)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrPermissionDenied
+	}
+	return nil
+}
+
+// dataScopeRank 数据范围大小，数值越大范围越大
+var dataScopeRank = map[string]int{
+	models.DataScopeSelf:       1,
+	models.DataScopeClass:      2,
+	models.DataScopeDepartment: 3,
+	models.DataScopeAll:        4,
+}
+
+// DataScopeService 角色数据范围与组织负责人业务
+type DataScopeService struct {
+}
+
+// NewDataScopeService 创建新的 DataScopeService
+func NewDataScopeService() *DataScopeService {
+	return &DataScopeService{}
+}
+
+// Resolve 解析用户的数据范围：管理员角色为全部；其余取各角色配置中最大的范围，未配置的角色使用默认范围
+// class 范围为用户负责的组织，department 范围为负责组织所在的院系（学校的直接下级，负责学校时为整个学校）
+func (s *DataScopeService) Resolve(userId int64, roleIds []string) (*dao.DataScope, error) {
+	scope := &dao.DataScope{Scope: models.DataScopeSelf, UserID: userId, RoleIDs: roleIds, Orgs: []models.OrgUnit{}}
+	for _, roleId := range roleIds {
+		if roleId == config.DataScopeSettings.AdminRoleID {
+			scope.Scope, scope.All = models.DataScopeAll, true
+			return scope, nil
+		}
+	}
+
+	scopeDao := dao.NewDataScopeDao(config.DB)
+	roleScopes, err := scopeDao.ListRoleScopes(roleIds)
+	if err != nil {
+		return nil, err
+	}
+	configured := make(map[string]string, len(roleScopes))
+	for _, roleScope := range roleScopes {
+		configured[strconv.Itoa(roleScope.RoleID)] = roleScope.Scope
+	}
+	for _, roleId := range roleIds {
+		roleScope, ok := configured[roleId]
+		if !ok {
+			roleScope = config.DataScopeSettings.DefaultScope
+		}
+		if dataScopeRank[roleScope] > dataScopeRank[scope.Scope] {
+			scope.Scope = roleScope
+		}
+	}
+
+	switch scope.Scope {
+	case models.DataScopeAll:
+		scope.All = true
+	case models.DataScopeClass, models.DataScopeDepartment:
+		orgs, err := scopeDao.ListManagedOrgs(userId)
+		if err != nil {
+			return nil, err
+		}
+		if scope.Scope == models.DataScopeDepartment {
+			if orgs, err = departmentsOf(orgs); err != nil {
+				return nil, err
+			}
+		}
+		for _, org := range orgs {
+			scope.Orgs = append(scope.Orgs, org)
+			scope.OrgPaths = append(scope.OrgPaths, org.Path)
+		}
+	}
+	return scope, nil
+}
+
+// departmentsOf 将组织换算为其所在的院系（学校的直接下级），负责学校时为学校本身，结果去重
+func departmentsOf(orgs []models.OrgUnit) ([]models.OrgUnit, error) {
+	seen := make(map[int64]bool)
+	var ids []int64
+	for _, org := range orgs {
+		ancestors := org.AncestorIDs()
+		id := org.ID
+		if len(ancestors) >= 2 {
+			id = ancestors[1]
+		}
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	departments, err := dao.NewOrgDao(config.DB).FindByIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+	// 负责学校时已包含其下全部院系，去掉被覆盖的院系
+	result := make([]models.OrgUnit, 0, len(departments))
+	for _, department := range departments {
+		covered := false
+		for _, other := range departments {
+			if other.ID != department.ID && strings.HasPrefix(department.Path, other.Path) {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			result = append(result, department)
+		}
+	}
+	return result, nil
+}
+
+// SetRoleScope 设置角色的数据范围，仅管理员可操作
+func (s *DataScopeService) SetRoleScope(roleId int, scope string, operator *dao.DataScope) error {
+	if err := requireAdmin(operator); err != nil {
+		return err
+	}
+	if _, ok := dataScopeRank[scope]; !ok {
+		return fmt.Errorf("数据范围 %s 无效，只能是 self/class/department/all", scope)
+	}
+	if roleId <= 0 {
+		return errors.New("无效的角色id")
+	}
+	return dao.NewDataScopeDao(config.DB).SaveRoleScope(&models.RoleDataScope{RoleID: roleId, Scope: scope, UpdatedBy: operator.UserID})
+}
+
+// ListRoleScopes 查询全部角色的数据范围配置
+func (s *DataScopeService) ListRoleScopes() ([]models.RoleDataScope, error) {
+	return dao.NewDataScopeDao(config.DB).ListRoleScopes(nil)
+}
+
+// AddManager 指定用户为组织负责人，仅管理员可操作
+func (s *DataScopeService) AddManager(orgId int64, userId int64, operator *dao.DataScope) error {
+	if err := requireAdmin(operator); err != nil {
+		return err
+	}
+	if _, err := findOrg(orgId); err != nil {
+		return err
+	}
+	if _, err := dao.NewUserDao(config.DB).GetUserById(userId); err != nil {
+		return errors.New("用户不存在")
+	}
+	return dao.NewDataScopeDao(config.DB).AddManager(&models.OrgManager{OrgID: orgId, UserID: userId})
+}
+
+// RemoveManager 取消用户的组织负责人身份，仅管理员可操作
+func (s *DataScopeService) RemoveManager(orgId int64, userId int64, operator *dao.DataScope) error {
+	if err := requireAdmin(operator); err != nil {
+		return err
+	}
+	return dao.NewDataScopeDao(config.DB).RemoveManager(userId, orgId)
+}
+
+// ListManagers 查询组织的负责人
+func (s *DataScopeService) ListManagers(orgId int64) ([]models.OrgManager, error) {
+	return dao.NewDataScopeDao(config.DB).ListManagers(orgId)
+}
@@ -0,0 +1,302 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"mental/config"
+	"mental/dao"
+	"mental/models"
+	"mental/vo"
+	"sort"
+	"strings"
+	"time"
+)
+
+// interventionTypes 可选的干预方式及其中文名
+var interventionTypes = map[string]string{
+	models.InterventionInterview: "面谈",
+	models.InterventionPhone:     "电话/线上沟通",
+	models.InterventionGroup:     "团体辅导",
+	models.InterventionParent:    "家校沟通",
+	models.InterventionReferral:  "转介",
+	models.InterventionObserve:   "观察随访",
+	models.InterventionOther:     "其他",
+}
+
+// 时间线事件类型，同一天内按此顺序排列
+const (
+	timelineAssessment = "assessment"
+	timelineAlert      = "alert"
+	timelineFollowUp   = "follow_up"
+)
+
+var timelineOrder = map[string]int{timelineAssessment: 0, timelineAlert: 1, timelineFollowUp: 2}
+
+// FollowUpService 辅导员跟进记录业务
+type FollowUpService struct {
+}
+
+// NewFollowUpService 创建新的 FollowUpService
+func NewFollowUpService() *FollowUpService {
+	return &FollowUpService{}
+}
+
+// validateFollowUp 校验跟进记录内容，以及关联的测评记录、预警是否属于该学生，并补全附件的存储路径
+func validateFollowUp(db *gorm.DB, followUp *models.FollowUp) (*models.Alert, error) {
+	if followUp.StudentID <= 0 {
+		return nil, errors.New("学生id不能为空")
+	}
+	if _, ok := interventionTypes[followUp.InterventionType]; !ok {
+		return nil, fmt.Errorf("干预方式 %s 无效", followUp.InterventionType)
+	}
+	if time.Time(followUp.FollowUpDate).IsZero() {
+		return nil, errors.New("跟进日期不能为空")
+	}
+	if followUp.NextFollowUpDate != nil && time.Time(*followUp.NextFollowUpDate).Before(time.Time(followUp.FollowUpDate)) {
+		return nil, errors.New("下次跟进日期不能早于本次跟进日期")
+	}
+	followUp.Notes = strings.TrimSpace(followUp.Notes)
+	followUp.Outcome = strings.TrimSpace(followUp.Outcome)
+
+	if followUp.SCLID != nil {
+		scl, err := dao.NewSCLDao(db).FindByID(*followUp.SCLID)
+		if err != nil {
+			return nil, errors.New("关联的测评记录不存在")
+		}
+		if scl.StudentID == nil || *scl.StudentID != followUp.StudentID {
+			return nil, errors.New("关联的测评记录不属于该学生")
+		}
+	}
+	var alert *models.Alert
+	if followUp.AlertID != nil {
+		var err error
+		if alert, err = dao.NewAlertDao(db).FindByID(*followUp.AlertID); err != nil {
+			return nil, err
+		}
+		if alert == nil {
+			return nil, errors.New("关联的预警不存在")
+		}
+		if alert.StudentID == nil || *alert.StudentID != followUp.StudentID {
+			return nil, errors.New("关联的预警不属于该学生")
+		}
+		if followUp.SCLID == nil && alert.SCLID != 0 {
+			followUp.SCLID = &alert.SCLID // 未指定测评记录时关联预警对应的测评记录（通用量表的预警没有）
+		}
+	}
+
+	var fileService FileService
+	for i := range followUp.Attachments {
+		attachment := &followUp.Attachments[i]
+		attachment.ID = 0
+		attachment.FollowUpID = followUp.ID
+		path, exists := fileService.CheckFileIsExist(attachment.FileID)
+		if !exists {
+			return nil, fmt.Errorf("附件文件ID %s 不存在，请先上传", attachment.FileID)
+		}
+		attachment.Path = path
+		if attachment.Name == "" {
+			attachment.Name = attachment.FileID
+		}
+	}
+	return alert, nil
+}
+
+// followAlert 关联的预警尚未进入跟进时，将其状态变更为跟进中并记录备注
+func followAlert(tx *gorm.DB, alert *models.Alert, userId int64, followUpId int64) error {
+	if alert == nil || (alert.Status != models.AlertStatusNew && alert.Status != models.AlertStatusAcknowledged) {
+		return nil
+	}
+	note := &models.AlertNote{
+		AlertID:    alert.ID,
+		AuthorID:   userId,
+		FromStatus: alert.Status,
+		ToStatus:   models.AlertStatusFollowUp,
+		Content:    fmt.Sprintf("新增跟进记录 %d", followUpId),
+	}
+	alert.Status = models.AlertStatusFollowUp
+	if alert.AssigneeID == nil {
+		alert.AssigneeID = &userId
+	}
+	alertDao := dao.NewAlertDao(tx)
+	if err := alertDao.Update(alert); err != nil {
+		return err
+	}
+	return alertDao.SaveNote(note)
+}
+
+// Create 新建跟进记录，记录人为当前用户；关联的预警为新建或已确认状态时自动进入跟进中
+// 仅辅导员或管理员可操作，学生须在当前用户的数据范围内
+func (s *FollowUpService) Create(followUp *models.FollowUp, operator *dao.DataScope) error {
+	if err := requireCounselor(operator); err != nil {
+		return err
+	}
+	if err := checkStudentInScope(followUp.StudentID, operator); err != nil {
+		return err
+	}
+	followUp.ID = 0
+	followUp.CounselorID = operator.UserID
+	alert, err := validateFollowUp(config.DB, followUp)
+	if err != nil {
+		return err
+	}
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := dao.NewFollowUpDao(tx).Save(followUp); err != nil {
+			return err
+		}
+		return followAlert(tx, alert, followUp.CounselorID, followUp.ID)
+	})
+}
+
+// Update 修改跟进记录内容并替换附件，学生和记录人不可修改；仅辅导员或管理员可操作，学生须在当前用户的数据范围内
+func (s *FollowUpService) Update(followUp *models.FollowUp, operator *dao.DataScope) error {
+	existing, err := s.Get(followUp.ID, operator)
+	if err != nil {
+		return err
+	}
+	followUp.StudentID = existing.StudentID
+	followUp.CounselorID = existing.CounselorID
+	alert, err := validateFollowUp(config.DB, followUp)
+	if err != nil {
+		return err
+	}
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		txDao := dao.NewFollowUpDao(tx)
+		if err := txDao.Update(followUp); err != nil {
+			return err
+		}
+		if err := txDao.ReplaceAttachments(followUp.ID, followUp.Attachments); err != nil {
+			return err
+		}
+		return followAlert(tx, alert, operator.UserID, followUp.ID)
+	})
+}
+
+// Get 查询跟进记录详情；仅辅导员或管理员可查询，学生须在当前用户的数据范围内
+func (s *FollowUpService) Get(id int64, operator *dao.DataScope) (*models.FollowUp, error) {
+	if err := requireCounselor(operator); err != nil {
+		return nil, err
+	}
+	followUp, err := dao.NewFollowUpDao(config.DB).FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if followUp == nil {
+		return nil, errors.New("跟进记录不存在")
+	}
+	if err := checkStudentInScope(followUp.StudentID, operator); err != nil {
+		return nil, err
+	}
+	return followUp, nil
+}
+
+// ListByStudent 查询学生的全部跟进记录；仅辅导员或管理员可查询，学生须在当前用户的数据范围内
+func (s *FollowUpService) ListByStudent(studentId int64, operator *dao.DataScope) ([]models.FollowUp, error) {
+	if err := requireCounselor(operator); err != nil {
+		return nil, err
+	}
+	if err := checkStudentInScope(studentId, operator); err != nil {
+		return nil, err
+	}
+	return dao.NewFollowUpDao(config.DB).ListByStudent(studentId)
+}
+
+// ListDue 查询数据范围内学生 days 天内（含已逾期）到期的下次跟进，counselorId 为 nil 时不限记录人；仅辅导员或管理员可查询
+func (s *FollowUpService) ListDue(days int, counselorId *int64, operator *dao.DataScope) ([]models.FollowUp, error) {
+	if err := requireCounselor(operator); err != nil {
+		return nil, err
+	}
+	if days < 0 {
+		return nil, errors.New("天数不能为负数")
+	}
+	now := time.Now()
+	before := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local).AddDate(0, 0, days+1)
+	return dao.NewFollowUpDao(config.DB).WithScope(operator).ListDue(before, counselorId)
+}
+
+// Timeline 按时间顺序合并学生的测评结果（含 SCL-90 及其他量表）、预警和跟进记录；仅辅导员或管理员可查询，学生须在当前用户的数据范围内
+func (s *FollowUpService) Timeline(studentId int64, operator *dao.DataScope) (*vo.StudentTimeline, error) {
+	if err := requireCounselor(operator); err != nil {
+		return nil, err
+	}
+	if err := checkStudentInScope(studentId, operator); err != nil {
+		return nil, err
+	}
+	timeline := &vo.StudentTimeline{StudentID: studentId, Entries: []vo.TimelineEntry{}}
+
+	assessments, err := NewScaleService().ListResults("", &studentId, operator)
+	if err != nil {
+		return nil, err
+	}
+	for i := range assessments {
+		assessment := &assessments[i]
+		summary := assessment.Result
+		if assessment.Invalid {
+			summary += "（无效作答）"
+		}
+		timeline.Entries = append(timeline.Entries, vo.TimelineEntry{
+			Type:       timelineAssessment,
+			Date:       assessment.TestDate,
+			Title:      assessment.ScaleName,
+			Level:      assessment.Level,
+			Summary:    summary,
+			Assessment: assessment,
+		})
+	}
+
+	alerts, err := NewAlertService().ListAlerts(dao.AlertFilter{StudentID: &studentId, Statuses: []string{models.AlertStatusNew, models.AlertStatusAcknowledged, models.AlertStatusFollowUp, models.AlertStatusClosed}}, operator)
+	if err != nil {
+		return nil, err
+	}
+	for i := range alerts {
+		alert := &alerts[i]
+		messages := make([]string, 0, len(alert.Reasons))
+		for _, reason := range alert.Reasons {
+			messages = append(messages, reason.Message)
+		}
+		timeline.Entries = append(timeline.Entries, vo.TimelineEntry{
+			Type:    timelineAlert,
+			Date:    alert.TestDate,
+			Title:   "高风险预警",
+			Level:   alert.Level,
+			Summary: strings.Join(messages, "；"),
+			Alert:   alert,
+		})
+	}
+
+	followUps, err := dao.NewFollowUpDao(config.DB).ListByStudent(studentId)
+	if err != nil {
+		return nil, err
+	}
+	for i := range followUps {
+		followUp := &followUps[i]
+		summary := followUp.Outcome
+		if summary == "" {
+			summary = followUp.Notes
+		}
+		timeline.Entries = append(timeline.Entries, vo.TimelineEntry{
+			Type:     timelineFollowUp,
+			Date:     followUp.FollowUpDate,
+			Title:    "跟进：" + interventionTypes[followUp.InterventionType],
+			Summary:  summary,
+			FollowUp: followUp,
+		})
+	}
+	if len(followUps) > 0 {
+		timeline.NextFollowUpDate = followUps[0].NextFollowUpDate // 已按跟进日期倒序，首条即最近一次跟进
+	}
+
+	// 各类记录均按时间倒序查询，整体反转后稳定排序：按日期正序，同一天按 测评、预警、跟进 排列，同类事件先发生的在前
+	for i, j := 0, len(timeline.Entries)-1; i < j; i, j = i+1, j-1 {
+		timeline.Entries[i], timeline.Entries[j] = timeline.Entries[j], timeline.Entries[i]
+	}
+	sort.SliceStable(timeline.Entries, func(i, j int) bool {
+		a, b := timeline.Entries[i], timeline.Entries[j]
+		ta, tb := time.Time(a.Date), time.Time(b.Date)
+		if !ta.Equal(tb) {
+			return ta.Before(tb)
+		}
+		return timelineOrder[a.Type] < timelineOrder[b.Type]
+	})
+	return timeline, nil
+}
@@ -0,0 +1,88 @@
+package service
+
+import (
+	"errors"
+	"gorm.io/gorm"
+	"mental/config"
+	"mental/dao"
+	"mental/models"
+	"time"
+)
+
+// ImportBatchService Excel 导入批次业务
+type ImportBatchService struct {
+}
+
+// NewImportBatchService 创建新的 ImportBatchService
+func NewImportBatchService() *ImportBatchService {
+	return &ImportBatchService{}
+}
+
+// ListBatches 按导入时间倒序列出导入批次，fileId 不为空时只查询该文件的批次
+// 管理员可查看全部批次，其他用户只能查看自己导入的批次
+func (s *ImportBatchService) ListBatches(fileId string, operator *dao.DataScope) ([]models.ImportBatch, error) {
+	if operator == nil {
+		return nil, ErrPermissionDenied
+	}
+	var createdBy int64
+	if !operator.IsAdmin() {
+		createdBy = operator.UserID
+	}
+	return dao.NewImportBatchDao(config.DB).List(fileId, createdBy)
+}
+
+// Rollback 回滚导入批次：在同一事务中软删除该批次导入的全部测评记录、关闭这些记录未关闭的预警并将批次标记为已回滚，返回删除的记录数
+// 只能回滚已导入的批次，且批次中的记录须全部在当前用户的数据范围内
+func (s *ImportBatchService) Rollback(batchId int64, userId int64, scope *dao.DataScope) (int64, error) {
+	var deleted int64
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		// 在事务内加锁重新读取批次，避免并发回滚或导入期间状态、记录数发生变化
+		batchDao := dao.NewImportBatchDao(tx)
+		batch, err := batchDao.FindByIDForUpdate(batchId)
+		if err != nil {
+			return err
+		}
+		if batch == nil {
+			return errors.New("导入批次不存在")
+		}
+		switch batch.Status {
+		case models.ImportBatchRolledBack:
+			return errors.New("该导入批次已回滚")
+		case models.ImportBatchFailed:
+			return errors.New("该导入批次未导入任何记录，无需回滚")
+		}
+
+		total, err := dao.NewSCLDao(tx).CountByImportBatch(batchId)
+		if err != nil {
+			return err
+		}
+		sclDao := dao.NewSCLDao(tx).WithScope(scope)
+		inScope, err := sclDao.CountByImportBatch(batchId)
+		if err != nil {
+			return err
+		}
+		if inScope < total {
+			return errors.New("批次中有不在数据范围内的记录，无权回滚")
+		}
+
+		sclIds, err := sclDao.ListIDsByImportBatch(batchId)
+		if err != nil {
+			return err
+		}
+		if err := closeSCLAlerts(tx, sclIds, "导入批次已回滚，自动关闭"); err != nil {
+			return err
+		}
+		if deleted, err = sclDao.DeleteByImportBatch(batchId); err != nil {
+			return err
+		}
+		now := time.Now()
+		batch.RolledBackBy = &userId
+		batch.RolledBackAt = &now
+		return batchDao.MarkRolledBack(batch)
+	})
+	if err != nil {
+		return 0, err
+	}
+	invalidateCohortStats()
+	return deleted, nil
+}
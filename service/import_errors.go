@@ -0,0 +1,155 @@
+package service
+
+import (
+	"fmt"
+	"github.com/xuri/excelize/v2"
+	"mental/utils"
+	"os"
+)
+
+// errorWorkbookHeader 失败行文件追加在表头行末尾的错误信息列标题
+const errorWorkbookHeader = "错误信息"
+
+// storeErrorWorkbook 将未导入的数据行写成 Excel 文件保存到 MinIO，返回下载链接；没有失败的数据行时返回空
+func storeErrorWorkbook(rows []importRow) (string, error) {
+	f, err := writeErrorWorkbook(rows)
+	if err != nil || f == nil {
+		return "", err
+	}
+	defer f.Close()
+
+	file, err := os.CreateTemp("", "import-errors-*.xlsx")
+	if err != nil {
+		return "", fmt.Errorf("创建临时文件失败: %v", err)
+	}
+	defer os.Remove(file.Name())
+	if err := f.Write(file); err != nil {
+		file.Close()
+		return "", fmt.Errorf("写入临时文件失败: %v", err)
+	}
+	if err := file.Close(); err != nil {
+		return "", fmt.Errorf("写入临时文件失败: %v", err)
+	}
+	return utils.UploadFileToMinio(file.Name())
+}
+
+// writeErrorWorkbook 按原工作表生成失败行文件：保留原表头行，其后依次为失败的数据行，末尾追加错误信息列，
+// 能定位到的出错单元格标红，修改后可直接按原映射方案重新导入；没有失败的数据行时返回 nil
+func writeErrorWorkbook(rows []importRow) (*excelize.File, error) {
+	var sheets []string
+	failed := make(map[string][]importRow)
+	for _, row := range rows {
+		if row.Err == nil || row.Row == 0 {
+			continue
+		}
+		if _, ok := failed[row.Sheet]; !ok {
+			sheets = append(sheets, row.Sheet)
+		}
+		failed[row.Sheet] = append(failed[row.Sheet], row)
+	}
+	if len(sheets) == 0 {
+		return nil, nil
+	}
+
+	f := excelize.NewFile()
+	errorStyle, err := f.NewStyle(&excelize.Style{
+		Font: &excelize.Font{Color: "9C0006"},
+		Fill: excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{"FFC7CE"}},
+	})
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	messageStyle, err := f.NewStyle(&excelize.Style{Font: &excelize.Font{Color: "9C0006"}})
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	for i, sheet := range sheets {
+		if i == 0 {
+			err = f.SetSheetName("Sheet1", sheet)
+		} else {
+			_, err = f.NewSheet(sheet)
+		}
+		if err == nil {
+			err = writeErrorSheet(f, sheet, failed[sheet], errorStyle, messageStyle)
+		}
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("写入工作表 %s 失败: %v", sheet, err)
+		}
+	}
+	return f, nil
+}
+
+// writeErrorSheet 写入一个工作表的失败行，错误信息列放在表头和各数据行中最宽一行之后
+func writeErrorSheet(f *excelize.File, sheet string, rows []importRow, errorStyle, messageStyle int) error {
+	head := rows[0].Head
+	width := 0
+	for _, cells := range head {
+		width = max(width, len(cells))
+	}
+	for _, row := range rows {
+		width = max(width, len(row.Cells))
+	}
+	messageCol := width + 1
+
+	for i, cells := range head {
+		if err := setErrorSheetRow(f, sheet, i+1, cells); err != nil {
+			return err
+		}
+	}
+	cell, err := excelize.CoordinatesToCellName(messageCol, len(head))
+	if err != nil {
+		return err
+	}
+	if err := f.SetCellStr(sheet, cell, errorWorkbookHeader); err != nil {
+		return err
+	}
+
+	for i, row := range rows {
+		rowNum := len(head) + i + 1
+		if err := setErrorSheetRow(f, sheet, rowNum, row.Cells); err != nil {
+			return err
+		}
+		if row.Column >= 0 {
+			if cell, err = excelize.CoordinatesToCellName(row.Column+1, rowNum); err != nil {
+				return err
+			}
+			if err := f.SetCellStyle(sheet, cell, cell, errorStyle); err != nil {
+				return err
+			}
+		}
+		if cell, err = excelize.CoordinatesToCellName(messageCol, rowNum); err != nil {
+			return err
+		}
+		if err := f.SetCellStr(sheet, cell, row.Err.Error()); err != nil {
+			return err
+		}
+		if err := f.SetCellStyle(sheet, cell, cell, messageStyle); err != nil {
+			return err
+		}
+	}
+	name, err := excelize.ColumnNumberToName(messageCol)
+	if err != nil {
+		return err
+	}
+	return f.SetColWidth(sheet, name, name, 40)
+}
+
+// setErrorSheetRow 按原文写入一行单元格，保持与原文件一致的文本内容
+func setErrorSheetRow(f *excelize.File, sheet string, rowNum int, cells []string) error {
+	if len(cells) == 0 {
+		return nil
+	}
+	values := make([]interface{}, len(cells))
+	for i, cell := range cells {
+		values[i] = cell
+	}
+	cell, err := excelize.CoordinatesToCellName(1, rowNum)
+	if err != nil {
+		return err
+	}
+	return f.SetSheetRow(sheet, cell, &values)
+}
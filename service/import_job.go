@@ -0,0 +1,201 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/bsm/redislock"
+	"github.com/redis/go-redis/v9"
+	"mental/constant"
+	"mental/models"
+	"mental/utils"
+	"strconv"
+	"time"
+)
+
+const (
+	importLockTTL          = 2 * time.Minute  // 导入文件锁的有效期，导入过程中定期续期
+	importJobTTL           = 24 * time.Hour   // 导入任务进度在 Redis 中的保留时间
+	importProgressInterval = 1 * time.Second  // 保存进度、续期文件锁和检查取消标记的最小间隔
+	importCancelTTL        = 10 * time.Minute // 取消标记的有效期
+)
+
+// errImportCanceled 导入任务已被取消
+var errImportCanceled = errors.New("导入已取消")
+
+// importProgress 导入进度：定期续期文件锁；后台任务时将进度写入 Redis，并检查取消标记
+type importProgress struct {
+	lock     *redislock.Lock
+	job      *models.ImportJob // 同步导入时为 nil
+	saved    time.Time
+	canceled bool // 是否已因取消而中止
+}
+
+// userId 导入人ID，同步导入时为 0
+func (p *importProgress) userId() int64 {
+	if p.job == nil {
+		return 0
+	}
+	return p.job.UserID
+}
+
+// tick 解析文件期间定期续期文件锁并保存进度
+func (p *importProgress) tick() {
+	if time.Since(p.saved) >= importProgressInterval {
+		_ = p.flush()
+	}
+}
+
+// begin 文件解析完成，记录数据行数；任务已被取消时返回 errImportCanceled
+func (p *importProgress) begin(total int) error {
+	if p.job != nil {
+		p.job.Total = total
+	}
+	return p.flush()
+}
+
+// step 处理完一行，failed 表示该行失败；任务已被取消时返回 errImportCanceled
+func (p *importProgress) step(failed bool) error {
+	if p.job != nil {
+		p.job.Processed++
+		if failed {
+			p.job.Failed++
+		}
+	}
+	if time.Since(p.saved) < importProgressInterval {
+		return nil
+	}
+	return p.flush()
+}
+
+// flush 续期文件锁并保存进度，任务已被取消时返回 errImportCanceled
+func (p *importProgress) flush() error {
+	p.saved = time.Now()
+	if err := utils.Refresh(p.lock, importLockTTL); err != nil {
+		fmt.Printf("续期导入文件锁失败: %v\n", err)
+	}
+	if p.job == nil {
+		return nil
+	}
+	if err := storeImportJob(p.job); err != nil {
+		fmt.Printf("保存导入进度失败: %v\n", err)
+	}
+	canceled, err := utils.Exists(constant.ImportJobCancelPrefix + p.job.ID)
+	if err == nil && canceled {
+		p.canceled = true
+		return errImportCanceled
+	}
+	return nil
+}
+
+// storeImportJob 保存导入任务进度
+func storeImportJob(job *models.ImportJob) error {
+	job.UpdatedAt = time.Now()
+	content, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	return utils.Set(constant.ImportJobPrefix+job.ID, string(content), importJobTTL)
+}
+
+// loadImportJob 从 Redis 读取导入任务，只能查看本人发起的任务
+func loadImportJob(jobId string, userId int64) (*models.ImportJob, error) {
+	value, err := utils.Get(constant.ImportJobPrefix + jobId)
+	if errors.Is(err, redis.Nil) {
+		return nil, errors.New("导入任务不存在或已过期")
+	}
+	if err != nil {
+		return nil, err
+	}
+	var job models.ImportJob
+	if err := json.Unmarshal([]byte(value.(string)), &job); err != nil {
+		return nil, fmt.Errorf("导入任务数据损坏: %v", err)
+	}
+	if job.UserID != userId {
+		return nil, errors.New("无权查看该导入任务")
+	}
+	return &job, nil
+}
+
+// StartImportJob 创建后台导入任务并立即返回，导入在后台执行，进度保存在 Redis 中
+// 创建时即获取文件锁，同一文件正在导入时直接返回错误
+func (s *FileService) StartImportJob() (*models.ImportJob, error) {
+	lock, err := lockImportFile(s.FileId)
+	if err != nil {
+		return nil, err
+	}
+	snowflake, err := utils.NewSnowflake()
+	if err != nil {
+		_ = utils.Unlock(lock)
+		return nil, err
+	}
+	now := time.Now()
+	job := &models.ImportJob{
+		ID:        strconv.FormatInt(snowflake.GenerateID(), 10),
+		FileID:    s.FileId,
+		UserID:    s.UserID,
+		Status:    models.ImportJobPending,
+		ErrorRows: []string{},
+		CreatedAt: now,
+	}
+	if err := storeImportJob(job); err != nil {
+		_ = utils.Unlock(lock)
+		return nil, err
+	}
+	started := *job // 返回副本，任务对象此后只由后台协程修改
+	go s.runImportJob(job, lock)
+	return &started, nil
+}
+
+// runImportJob 执行后台导入任务，结束时释放文件锁
+func (s *FileService) runImportJob(job *models.ImportJob, lock *redislock.Lock) {
+	defer func() { _ = utils.Unlock(lock) }()
+	defer func() {
+		if r := recover(); r != nil {
+			job.Status = models.ImportJobFailed
+			job.ErrorRows = append(job.ErrorRows, fmt.Sprintf("导入异常: %v", r))
+			if err := storeImportJob(job); err != nil {
+				fmt.Printf("保存导入进度失败: %v\n", err)
+			}
+		}
+	}()
+
+	job.Status = models.ImportJobRunning
+	progress := &importProgress{lock: lock, job: job}
+	_ = progress.flush()
+	result := s.importFile(progress)
+
+	job.BatchID, job.SuccessNum, job.ErrorFile = result.BatchID, result.SuccessNum, result.ErrorFile
+	if result.ErrorRows != nil {
+		job.ErrorRows = result.ErrorRows
+	}
+	switch {
+	case progress.canceled:
+		job.Status = models.ImportJobCanceled
+	case result.Status == models.ImportBatchCompleted:
+		job.Status = models.ImportJobCompleted
+	default:
+		job.Status = models.ImportJobFailed // 文件级错误，或全部成功模式下有行失败
+	}
+	if err := storeImportJob(job); err != nil {
+		fmt.Printf("保存导入进度失败: %v\n", err)
+	}
+	_ = utils.Delete(constant.ImportJobCancelPrefix + job.ID)
+}
+
+// GetImportJob 查询本人发起的导入任务进度
+func GetImportJob(jobId string, userId int64) (*models.ImportJob, error) {
+	return loadImportJob(jobId, userId)
+}
+
+// CancelImportJob 取消本人发起的导入任务：设置取消标记，任务在处理下一批行时停止，已写入的记录随之撤销
+func CancelImportJob(jobId string, userId int64) error {
+	job, err := loadImportJob(jobId, userId)
+	if err != nil {
+		return err
+	}
+	if job.Finished() {
+		return errors.New("导入任务已结束")
+	}
+	return utils.Set(constant.ImportJobCancelPrefix+jobId, "1", importCancelTTL)
+}
@@ -0,0 +1,156 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"mental/config"
+	"mental/constant"
+	"mental/dao"
+	"mental/utils"
+	"mental/vo"
+	"strconv"
+	"time"
+)
+
+// importPreviewTTL 预览确认凭证的有效期
+const importPreviewTTL = 30 * time.Minute
+
+// Preview 预览导入：按与导入相同的映射方案和校验规则逐行检查，返回逐行错误和警告，不写入数据也不标记文件已解析；
+// 有可导入的行时生成确认凭证，确认导入时沿用本次的导入参数
+func (s *FileService) Preview() (*vo.ImportPreview, error) {
+	objectPath, exists := s.CheckFileIsExist(s.FileId)
+	if !exists {
+		return nil, fmt.Errorf("文件ID %s 不存在", s.FileId)
+	}
+	analyzed, err := dao.NewFileDao(config.DB).IsFileAnalyzed(s.FileId)
+	if err != nil {
+		return nil, errors.New("判断文件状态错误！")
+	}
+	if analyzed {
+		return nil, errors.New("该文件已经解析过！")
+	}
+
+	preview := &vo.ImportPreview{Errors: []string{}, Rows: []vo.ImportPreviewRow{}}
+	sclDao := dao.NewSCLDao(config.DB)
+	seen := make(map[string]string) // 学生ID（无学生ID时为姓名）+ 测评日期 -> 首次出现的位置
+	var checkErr error
+	profile, err := s.readImportRows(objectPath, func(row importRow) {
+		if row.Row == 0 {
+			preview.Errors = append(preview.Errors, row.Label+row.Err.Error())
+			return
+		}
+		preview.Total++
+		result := vo.ImportPreviewRow{Sheet: row.Sheet, Row: row.Row, Errors: []string{}, Warnings: []string{}}
+		if row.Err != nil {
+			result.Status = vo.ImportRowError
+			result.Errors = append(result.Errors, row.Err.Error())
+			preview.ErrorCount++
+			preview.Rows = append(preview.Rows, result)
+			return
+		}
+
+		scl := row.SCL
+		result.StudentID = scl.StudentID
+		result.Name = scl.Name
+		result.TestDate = time.Time(scl.TestDate).Format("2006-01-02")
+		result.TotalScore = scl.TotalScore
+		result.Level = scl.Level
+		warnings, err := importWarnings(row, seen, sclDao)
+		if err != nil && checkErr == nil {
+			checkErr = err
+		}
+		result.Status = vo.ImportRowOK
+		if len(warnings) > 0 {
+			result.Status = vo.ImportRowWarning
+			result.Warnings = warnings
+			preview.WarningCount++
+		}
+		preview.ValidCount++
+		preview.Rows = append(preview.Rows, result)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if checkErr != nil {
+		return nil, checkErr
+	}
+	preview.Profile = profile.Name
+
+	if preview.ValidCount > 0 {
+		snowflake, err := utils.NewSnowflake()
+		if err != nil {
+			return nil, err
+		}
+		token := strconv.FormatInt(snowflake.GenerateID(), 10)
+		content, err := json.Marshal(s)
+		if err != nil {
+			return nil, err
+		}
+		if err := utils.Set(constant.ImportPreviewPrefix+token, string(content), importPreviewTTL); err != nil {
+			return nil, err
+		}
+		preview.Token = token
+	}
+	return preview, nil
+}
+
+// importWarnings 检查可导入行中需要确认的问题：学生ID为空或无法识别、作答有效性存疑、与文件中其他行或已有记录为同一学生同一测评日期
+func importWarnings(row importRow, seen map[string]string, sclDao *dao.SCLDao) ([]string, error) {
+	var warnings []string
+	scl := row.SCL
+	if row.StudentNo == "" {
+		warnings = append(warnings, "学生ID为空，记录不会关联到学生")
+	} else if scl.StudentID == nil {
+		warnings = append(warnings, fmt.Sprintf("学生ID %q 无法识别，记录不会关联到学生", row.StudentNo))
+	}
+	if scl.Invalid {
+		warnings = append(warnings, "作答有效性检查未通过，导入后将标记为无效作答")
+	}
+
+	testDate := time.Time(scl.TestDate)
+	key := scl.Name
+	if scl.StudentID != nil {
+		key = strconv.FormatInt(*scl.StudentID, 10)
+	}
+	key += "@" + testDate.Format("2006-01-02")
+	if first, ok := seen[key]; ok {
+		warnings = append(warnings, fmt.Sprintf("与%s为同一学生同一测评日期", first))
+	} else {
+		seen[key] = row.Label
+	}
+	if scl.StudentID != nil {
+		exists, err := sclDao.ExistsByStudentDate(*scl.StudentID, testDate)
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			warnings = append(warnings, "该学生在此测评日期已有测评记录")
+		}
+	}
+	return warnings, nil
+}
+
+// ConfirmPreview 确认导入：校验凭证并返回预览时的导入参数，凭证只能使用一次且只能由预览人确认；
+// 调用方随后按这些参数导入，导入时重新校验，校验未通过的行不导入
+func ConfirmPreview(token string, userId int64) (*FileService, error) {
+	value, err := utils.Get(constant.ImportPreviewPrefix + token)
+	if errors.Is(err, redis.Nil) {
+		return nil, errors.New("确认凭证不存在或已过期，请重新预览")
+	}
+	if err != nil {
+		return nil, err
+	}
+	var fileService FileService
+	if err := json.Unmarshal([]byte(value.(string)), &fileService); err != nil {
+		return nil, fmt.Errorf("确认凭证数据损坏: %v", err)
+	}
+	if fileService.UserID != userId {
+		return nil, errors.New("只能确认本人预览的导入")
+	}
+	if err := utils.Delete(constant.ImportPreviewPrefix + token); err != nil {
+		return nil, err
+	}
+	return &fileService, nil
+}
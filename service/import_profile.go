@@ -0,0 +1,476 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/xuri/excelize/v2"
+	"io"
+	"mental/config"
+	"mental/dao"
+	"mental/models"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// sclImportBaseColumns 基本信息字段的内置映射，兜底列与原固定格式一致（A-E）
+var sclImportBaseColumns = []models.ImportColumn{
+	{Field: "student_id", Headers: []string{"学生ID", "学号", "学生编号", "student_id"}, Column: "A"},
+	{Field: "name", Headers: []string{"姓名", "学生姓名", "name"}, Column: "B"},
+	{Field: "gender", Headers: []string{"性别", "gender"}, Column: "C"},
+	{Field: "age", Headers: []string{"年龄", "age"}, Column: "D"},
+	{Field: "test_date", Headers: []string{"测评日期", "测试日期", "施测日期", "日期", "test_date"}, Column: "E"},
+}
+
+// sclImportRequired 必须能定位到列的基本信息字段
+var sclImportRequired = []string{"name", "gender", "age", "test_date"}
+
+// sclImportDateLayouts 测评日期支持的格式
+var sclImportDateLayouts = []string{"2006-1-2", "2006/1/2", "2006.1.2", "2006年1月2日"}
+
+// defaultImportProfile 内置映射方案：读取第一个工作表，首行为表头，按常见表头名称匹配，
+// 匹配不到时沿用原固定格式（A-E 基本信息 + F-O 十个因子分，或 F 列起 90 列逐题作答）
+var defaultImportProfile = &importProfile{Name: "内置方案", content: &models.ImportProfileContent{HeaderRow: 1, ItemsStart: "F"}}
+
+func init() {
+	columns := append([]models.ImportColumn{}, sclImportBaseColumns...)
+	for i, factor := range sclFactors {
+		column, _ := excelize.ColumnNumberToName(6 + i)
+		columns = append(columns, models.ImportColumn{Field: factor.Key, Headers: []string{factor.Name, factor.Key}, Column: column})
+	}
+	defaultImportProfile.content.Columns = columns
+}
+
+// importProfile 解析后的映射方案
+type importProfile struct {
+	ID      int64
+	Name    string
+	content *models.ImportProfileContent
+}
+
+// column 字段的有效映射：方案配置的表头之后追加内置表头作为别名；方案未配置的字段只按内置表头匹配，不按位置兜底
+func (p *importProfile) column(field string) models.ImportColumn {
+	var builtin models.ImportColumn
+	for _, column := range defaultImportProfile.content.Columns {
+		if column.Field == field {
+			builtin = column
+		}
+	}
+	for _, column := range p.content.Columns {
+		if column.Field == field {
+			column.Headers = append(append([]string{}, column.Headers...), builtin.Headers...)
+			return column
+		}
+	}
+	return models.ImportColumn{Field: field, Headers: builtin.Headers}
+}
+
+// importFields 按模板列顺序返回全部字段：基本信息在前，其后为十个因子
+func importFields() []string {
+	fields := make([]string, 0, len(sclImportBaseColumns)+len(sclFactors))
+	for _, column := range sclImportBaseColumns {
+		fields = append(fields, column.Field)
+	}
+	for _, factor := range sclFactors {
+		fields = append(fields, factor.Key)
+	}
+	return fields
+}
+
+// parseImportProfileContent 解析并校验映射内容
+func parseImportProfileContent(content string) (*models.ImportProfileContent, error) {
+	var parsed models.ImportProfileContent
+	if err := json.Unmarshal([]byte(content), &parsed); err != nil {
+		return nil, fmt.Errorf("映射内容格式错误: %v", err)
+	}
+	if parsed.HeaderRow < 0 {
+		return nil, errors.New("表头行号不能为负数")
+	}
+	if parsed.HeaderRow == 0 {
+		parsed.HeaderRow = 1
+	}
+	if parsed.ItemsStart != "" {
+		if _, err := excelize.ColumnNameToNumber(parsed.ItemsStart); err != nil {
+			return nil, fmt.Errorf("逐题作答起始列 %s 无效", parsed.ItemsStart)
+		}
+	}
+	known := make(map[string]bool)
+	for _, field := range importFields() {
+		known[field] = true
+	}
+	seen := make(map[string]bool)
+	for _, column := range parsed.Columns {
+		if !known[column.Field] {
+			return nil, fmt.Errorf("不支持的字段 %s", column.Field)
+		}
+		if seen[column.Field] {
+			return nil, fmt.Errorf("字段 %s 重复映射", column.Field)
+		}
+		seen[column.Field] = true
+		if len(column.Headers) == 0 && column.Column == "" {
+			return nil, fmt.Errorf("字段 %s 未指定表头或列", column.Field)
+		}
+		if column.Column != "" {
+			if _, err := excelize.ColumnNameToNumber(column.Column); err != nil {
+				return nil, fmt.Errorf("字段 %s 的列 %s 无效", column.Field, column.Column)
+			}
+		}
+	}
+	return &parsed, nil
+}
+
+// loadImportProfile 加载导入使用的映射方案：指定方案ID时使用该方案，否则由组织自身逐级向上查找启用的方案，其次全局，都没有时使用内置方案
+func loadImportProfile(profileId int64, orgId int64) (*importProfile, error) {
+	profileDao := dao.NewImportProfileDao(config.DB)
+	var profile *models.ImportProfile
+	if profileId != 0 {
+		found, err := profileDao.FindByID(profileId)
+		if err != nil {
+			return nil, err
+		}
+		if found == nil {
+			return nil, errors.New("映射方案不存在")
+		}
+		profile = found
+	} else {
+		lookup, err := orgLookupIDs(orgId)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range lookup {
+			if profile, err = profileDao.FindActive(id); err != nil {
+				return nil, err
+			}
+			if profile != nil {
+				break
+			}
+		}
+	}
+	if profile == nil {
+		return defaultImportProfile, nil
+	}
+	content, err := parseImportProfileContent(profile.Content)
+	if err != nil {
+		return nil, err
+	}
+	return &importProfile{ID: profile.ID, Name: profile.Name, content: content}, nil
+}
+
+// importSheets 确定要读取的工作表：sheet、allSheets 不为空时覆盖方案中的设置
+func importSheets(f *excelize.File, content *models.ImportProfileContent, sheet string, allSheets bool) ([]string, error) {
+	sheets := f.GetSheetList()
+	if len(sheets) == 0 {
+		return nil, errors.New("文件中没有工作表")
+	}
+	if allSheets || (sheet == "" && content.AllSheets) {
+		return sheets, nil
+	}
+	if sheet == "" {
+		sheet = content.Sheet
+	}
+	if sheet == "" {
+		return sheets[:1], nil
+	}
+	for _, name := range sheets {
+		if name == sheet {
+			return []string{sheet}, nil
+		}
+	}
+	return nil, fmt.Errorf("文件中没有名为 %s 的工作表", sheet)
+}
+
+// normalizeHeader 表头匹配时忽略空白和大小写
+func normalizeHeader(s string) string {
+	return strings.ToLower(strings.Join(strings.Fields(s), ""))
+}
+
+// sclItemHeaders 第 n 题可识别的表头：第n题、题n、Qn、n
+func sclItemHeaders(n int) []string {
+	return []string{fmt.Sprintf("第%d题", n), fmt.Sprintf("题%d", n), fmt.Sprintf("q%d", n), strconv.Itoa(n)}
+}
+
+// importLayout 一个工作表中各字段所在的列（从 0 开始）
+type importLayout struct {
+	columns map[string]int
+	items   []int // 90 道题所在的列，为空时按因子分导入
+}
+
+// resolveImportLayout 按表头定位各字段所在的列：表头中能找到全部 90 道题时按逐题作答导入，
+// 否则按方案的逐题作答起始列（表头列数足够时），再否则按十个因子分导入
+func resolveImportLayout(header []string, profile *importProfile) (*importLayout, error) {
+	index := make(map[string]int)
+	for i, cell := range header {
+		key := normalizeHeader(cell)
+		if _, ok := index[key]; !ok && key != "" {
+			index[key] = i
+		}
+	}
+	layout := &importLayout{columns: make(map[string]int)}
+	for _, field := range importFields() {
+		column := profile.column(field)
+		pos := -1
+		for _, name := range column.Headers {
+			if i, ok := index[normalizeHeader(name)]; ok {
+				pos = i
+				break
+			}
+		}
+		if pos < 0 && column.Column != "" {
+			number, _ := excelize.ColumnNameToNumber(column.Column)
+			pos = number - 1
+		}
+		if pos >= 0 {
+			layout.columns[field] = pos
+		}
+	}
+
+	items := make([]int, 0, sclItemCount)
+	for n := 1; n <= sclItemCount; n++ {
+		for _, name := range sclItemHeaders(n) {
+			if i, ok := index[name]; ok {
+				items = append(items, i)
+				break
+			}
+		}
+	}
+	if len(items) == sclItemCount {
+		layout.items = items
+	} else if start := profile.content.ItemsStart; start != "" {
+		number, _ := excelize.ColumnNameToNumber(start)
+		if len(header) >= number-1+sclItemCount {
+			layout.items = make([]int, sclItemCount)
+			for i := range layout.items {
+				layout.items[i] = number - 1 + i
+			}
+		}
+	}
+
+	required := append([]string{}, sclImportRequired...)
+	if layout.items == nil {
+		for _, factor := range sclFactors {
+			required = append(required, factor.Key)
+		}
+	}
+	for _, field := range required {
+		if _, ok := layout.columns[field]; !ok {
+			return nil, fmt.Errorf("表头缺少“%s”列", profile.column(field).Headers[0])
+		}
+	}
+	return layout, nil
+}
+
+// errorColumn 校验错误对应的列（从 0 开始），无法定位时返回 -1
+func (l *importLayout) errorColumn(err error) int {
+	var fieldErr *sclFieldError
+	if !errors.As(err, &fieldErr) {
+		return -1
+	}
+	if fieldErr.Item > 0 && fieldErr.Item <= len(l.items) {
+		return l.items[fieldErr.Item-1]
+	}
+	if i, ok := l.columns[fieldErr.Field]; ok {
+		return i
+	}
+	return -1
+}
+
+// cell 取一行中字段对应的单元格，超出行长度时为空
+func (l *importLayout) cell(row []string, field string) string {
+	i, ok := l.columns[field]
+	if !ok || i >= len(row) {
+		return ""
+	}
+	return strings.TrimSpace(row[i])
+}
+
+// parseSCLRow 按列映射将一行数据解析为测评记录（未计算派生字段）
+func parseSCLRow(row []string, layout *importLayout) (*models.SCL, error) {
+	age, err := strconv.Atoi(layout.cell(row, "age"))
+	if err != nil {
+		return nil, &sclFieldError{Field: "age", Err: fmt.Errorf("年龄格式错误: %v", err)}
+	}
+	gender, err := parseGender(layout.cell(row, "gender"))
+	if err != nil {
+		return nil, &sclFieldError{Field: "gender", Err: err}
+	}
+	testDate, err := parseImportDate(layout.cell(row, "test_date"))
+	if err != nil {
+		return nil, &sclFieldError{Field: "test_date", Err: err}
+	}
+	scl := &models.SCL{
+		StudentID: parseInt64Ptr(layout.cell(row, "student_id")),
+		Name:      layout.cell(row, "name"),
+		Gender:    gender,
+		Age:       age,
+		TestDate:  models.CustomTime(testDate),
+	}
+	if layout.items != nil {
+		cells := make([]string, sclItemCount)
+		for i, column := range layout.items {
+			if column < len(row) {
+				cells[i] = row[column]
+			}
+		}
+		if scl.Items, err = parseItems(cells); err != nil {
+			return nil, err
+		}
+		return scl, nil
+	}
+	for _, factor := range sclFactors {
+		*factor.Field(scl) = parseFloat(layout.cell(row, factor.Key))
+	}
+	return scl, nil
+}
+
+// parseImportDate 解析测评日期，支持 2006-01-02、2006/01/02、2006.01.02 和 2006年1月2日
+func parseImportDate(s string) (time.Time, error) {
+	for _, layout := range sclImportDateLayouts {
+		if date, err := time.Parse(layout, s); err == nil {
+			return date, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("测评日期格式错误: %q", s)
+}
+
+// isBlankRow 判断是否为空行
+func isBlankRow(row []string) bool {
+	for _, cell := range row {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// ImportProfileService Excel 导入列映射方案业务
+type ImportProfileService struct {
+}
+
+// NewImportProfileService 创建新的 ImportProfileService
+func NewImportProfileService() *ImportProfileService {
+	return &ImportProfileService{}
+}
+
+// CreateProfile 新建映射方案，新建后默认不启用；仅管理员或方案所属组织的负责人可操作，全局方案仅管理员可新建
+func (s *ImportProfileService) CreateProfile(profile *models.ImportProfile, operator *dao.DataScope) error {
+	if err := requireOrgManager(profile.OrgID, operator); err != nil {
+		return err
+	}
+	if profile.Name == "" {
+		return errors.New("方案名称不能为空")
+	}
+	if _, err := parseImportProfileContent(profile.Content); err != nil {
+		return err
+	}
+	profile.ID = 0
+	profile.Active = false
+	profile.ActivatedAt = nil
+	return dao.NewImportProfileDao(config.DB).Save(profile)
+}
+
+// UpdateProfile 修改映射方案的名称、内容和备注；仅管理员或方案所属组织的负责人可操作
+func (s *ImportProfileService) UpdateProfile(profile *models.ImportProfile, operator *dao.DataScope) error {
+	if profile.Name == "" {
+		return errors.New("方案名称不能为空")
+	}
+	if _, err := parseImportProfileContent(profile.Content); err != nil {
+		return err
+	}
+	profileDao := dao.NewImportProfileDao(config.DB)
+	existing, err := profileDao.FindByID(profile.ID)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return errors.New("映射方案不存在")
+	}
+	if err := requireOrgManager(existing.OrgID, operator); err != nil {
+		return err
+	}
+	return profileDao.Update(profile)
+}
+
+// ListProfiles 列出指定组织的全部映射方案；仅管理员或该组织的负责人可查询，全局方案仅管理员可查询
+func (s *ImportProfileService) ListProfiles(orgId int64, operator *dao.DataScope) ([]models.ImportProfile, error) {
+	if err := requireOrgManager(orgId, operator); err != nil {
+		return nil, err
+	}
+	return dao.NewImportProfileDao(config.DB).ListByOrg(orgId)
+}
+
+// ActivateProfile 启用指定映射方案，之后该组织（及未单独配置的下级组织）的导入均使用该方案；仅管理员或方案所属组织的负责人可操作
+func (s *ImportProfileService) ActivateProfile(id int64, operator *dao.DataScope) error {
+	profileDao := dao.NewImportProfileDao(config.DB)
+	profile, err := profileDao.FindByID(id)
+	if err != nil {
+		return err
+	}
+	if profile == nil {
+		return errors.New("映射方案不存在")
+	}
+	if err := requireOrgManager(profile.OrgID, operator); err != nil {
+		return err
+	}
+	return profileDao.Activate(profile)
+}
+
+// Template 按映射方案生成空白导入模板写入 w：profileId 为 0 时使用组织当前启用的方案；
+// 表头取各字段的首个表头名称，items 为 true 时以 90 道题的逐题作答列代替十个因子分；
+// 仅管理员或方案所属组织（未指定方案时为 orgId）的负责人可下载，全局方案和内置方案仅管理员可下载
+func (s *ImportProfileService) Template(profileId int64, orgId int64, items bool, operator *dao.DataScope, w io.Writer) error {
+	if profileId != 0 {
+		found, err := dao.NewImportProfileDao(config.DB).FindByID(profileId)
+		if err != nil {
+			return err
+		}
+		if found == nil {
+			return errors.New("映射方案不存在")
+		}
+		orgId = found.OrgID
+	}
+	if err := requireOrgManager(orgId, operator); err != nil {
+		return err
+	}
+	profile, err := loadImportProfile(profileId, orgId)
+	if err != nil {
+		return err
+	}
+	var headers []interface{}
+	for _, field := range importFields() {
+		if items && len(headers) == len(sclImportBaseColumns) {
+			break
+		}
+		headers = append(headers, profile.column(field).Headers[0])
+	}
+	if items {
+		for n := 1; n <= sclItemCount; n++ {
+			headers = append(headers, sclItemHeaders(n)[0])
+		}
+	}
+
+	f := excelize.NewFile()
+	defer f.Close()
+	sheet := profile.content.Sheet
+	if sheet == "" {
+		sheet = "Sheet1"
+	}
+	if err := f.SetSheetName("Sheet1", sheet); err != nil {
+		return err
+	}
+	cell, err := excelize.CoordinatesToCellName(1, profile.content.HeaderRow)
+	if err != nil {
+		return err
+	}
+	if err := f.SetSheetRow(sheet, cell, &headers); err != nil {
+		return err
+	}
+	style, err := f.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}})
+	if err != nil {
+		return err
+	}
+	if err := f.SetRowStyle(sheet, profile.content.HeaderRow, profile.content.HeaderRow, style); err != nil {
+		return err
+	}
+	return f.Write(w)
+}
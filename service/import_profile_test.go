@@ -0,0 +1,76 @@
+package service
+
+import (
+	"bytes"
+	"errors"
+	"mental/config"
+	"mental/dao"
+	"mental/models"
+	"testing"
+)
+
+func TestImportProfileRequiresOrgManager(t *testing.T) {
+	setupTestEnv(t)
+	orgDao := dao.NewOrgDao(config.DB)
+	school := &models.OrgUnit{Type: "school", Name: "学校", Code: "S1", Path: "1/"}
+	class := &models.OrgUnit{ParentID: 1, Type: "class", Name: "一班", Code: "C1", Path: "1/2/"}
+	other := &models.OrgUnit{ParentID: 1, Type: "class", Name: "二班", Code: "C2", Path: "1/3/"}
+	for _, org := range []*models.OrgUnit{school, class, other} {
+		if err := orgDao.Save(org); err != nil {
+			t.Fatalf("保存组织失败: %v", err)
+		}
+	}
+	if err := dao.NewDataScopeDao(config.DB).AddManager(&models.OrgManager{UserID: 10, OrgID: class.ID}); err != nil {
+		t.Fatalf("保存组织负责人失败: %v", err)
+	}
+
+	profileService := NewImportProfileService()
+	content := `{"header_row": 1}`
+	manager, outsider := selfScope(10), selfScope(20)
+	admin := adminScope()
+
+	if err := profileService.CreateProfile(&models.ImportProfile{Name: "全局", Content: content}, manager); !errors.Is(err, ErrPermissionDenied) {
+		t.Errorf("非管理员新建全局方案应返回 ErrPermissionDenied，实际为 %v", err)
+	}
+	if err := profileService.CreateProfile(&models.ImportProfile{Name: "二班", OrgID: other.ID, Content: content}, manager); !errors.Is(err, ErrPermissionDenied) {
+		t.Errorf("为非负责的组织新建方案应返回 ErrPermissionDenied，实际为 %v", err)
+	}
+	profile := &models.ImportProfile{Name: "一班", OrgID: class.ID, Content: content}
+	if err := profileService.CreateProfile(profile, manager); err != nil {
+		t.Fatalf("组织负责人新建方案失败: %v", err)
+	}
+
+	update := &models.ImportProfile{ID: profile.ID, Name: "一班（修改）", Content: content}
+	if err := profileService.UpdateProfile(update, outsider); !errors.Is(err, ErrPermissionDenied) {
+		t.Errorf("非负责人修改方案应返回 ErrPermissionDenied，实际为 %v", err)
+	}
+	if err := profileService.ActivateProfile(profile.ID, outsider); !errors.Is(err, ErrPermissionDenied) {
+		t.Errorf("非负责人启用方案应返回 ErrPermissionDenied，实际为 %v", err)
+	}
+	if err := profileService.UpdateProfile(update, manager); err != nil {
+		t.Errorf("组织负责人修改方案失败: %v", err)
+	}
+	if err := profileService.ActivateProfile(profile.ID, admin); err != nil {
+		t.Errorf("管理员启用方案失败: %v", err)
+	}
+	if err := profileService.CreateProfile(&models.ImportProfile{Name: "全局", Content: content}, admin); err != nil {
+		t.Errorf("管理员新建全局方案失败: %v", err)
+	}
+
+	if _, err := profileService.ListProfiles(class.ID, outsider); !errors.Is(err, ErrPermissionDenied) {
+		t.Errorf("非负责人查询方案应返回 ErrPermissionDenied，实际为 %v", err)
+	}
+	if list, err := profileService.ListProfiles(class.ID, manager); err != nil || len(list) != 1 {
+		t.Errorf("组织负责人查询方案应返回 1 个方案，实际为 %d 个，错误 %v", len(list), err)
+	}
+	if _, err := profileService.ListProfiles(0, manager); !errors.Is(err, ErrPermissionDenied) {
+		t.Errorf("非管理员查询全局方案应返回 ErrPermissionDenied，实际为 %v", err)
+	}
+	var template bytes.Buffer
+	if err := profileService.Template(profile.ID, 0, false, outsider, &template); !errors.Is(err, ErrPermissionDenied) {
+		t.Errorf("非负责人下载方案模板应返回 ErrPermissionDenied，实际为 %v", err)
+	}
+	if err := profileService.Template(profile.ID, 0, false, manager, &template); err != nil {
+		t.Errorf("组织负责人下载方案模板失败: %v", err)
+	}
+}
@@ -0,0 +1,309 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"mental/config"
+	"mental/dao"
+	"mental/models"
+	"mental/vo"
+	"strconv"
+	"strings"
+)
+
+// sclMetric 可与常模比较的指标
+type sclMetric struct {
+	Key  string // 字段名
+	Name string // 中文名
+}
+
+// sclGlobalMetrics 常模中除十个因子外可用的全局指标
+var sclGlobalMetrics = []sclMetric{
+	{"total_score", "总分"},
+	{"positive_items", "阳性项目数"},
+	{"gsi", "总症状指数"},
+}
+
+// builtinNormGroup 内置全国成人常模（1986 年全国协作组，n=1388），数据库中没有默认常模时使用
+var builtinNormGroup = &models.NormGroup{
+	Code:        "cn_adult_1986",
+	Name:        "全国成人常模（内置）",
+	Description: "SCL-90 全国常模协作组 1986，年龄、性别不限",
+	Entries: []models.NormEntry{
+		{Factor: "somatization", Gender: -1, AgeMin: 0, AgeMax: 150, Mean: 1.37, SD: 0.48},
+		{Factor: "obsession", Gender: -1, AgeMin: 0, AgeMax: 150, Mean: 1.62, SD: 0.58},
+		{Factor: "interpersonal", Gender: -1, AgeMin: 0, AgeMax: 150, Mean: 1.65, SD: 0.61},
+		{Factor: "depression", Gender: -1, AgeMin: 0, AgeMax: 150, Mean: 1.50, SD: 0.59},
+		{Factor: "anxiety", Gender: -1, AgeMin: 0, AgeMax: 150, Mean: 1.39, SD: 0.43},
+		{Factor: "hostility", Gender: -1, AgeMin: 0, AgeMax: 150, Mean: 1.48, SD: 0.56},
+		{Factor: "phobia", Gender: -1, AgeMin: 0, AgeMax: 150, Mean: 1.23, SD: 0.41},
+		{Factor: "paranoia", Gender: -1, AgeMin: 0, AgeMax: 150, Mean: 1.43, SD: 0.57},
+		{Factor: "psychoticism", Gender: -1, AgeMin: 0, AgeMax: 150, Mean: 1.29, SD: 0.42},
+		{Factor: "total_score", Gender: -1, AgeMin: 0, AgeMax: 150, Mean: 129.96, SD: 38.76},
+		{Factor: "positive_items", Gender: -1, AgeMin: 0, AgeMax: 150, Mean: 24.92, SD: 18.41},
+	},
+}
+
+// sclNorm 已加载的常模组
+type sclNorm struct {
+	Group *models.NormGroup
+}
+
+// lookup 查找与性别、年龄匹配的常模条目：性别精确匹配优先于“不限”，同等条件下年龄段越窄越优先
+func (n *sclNorm) lookup(factor string, gender int, age int) *models.NormEntry {
+	var best *models.NormEntry
+	for i := range n.Group.Entries {
+		entry := &n.Group.Entries[i]
+		if entry.Factor != factor || age < entry.AgeMin || age > entry.AgeMax {
+			continue
+		}
+		if entry.Gender != -1 && entry.Gender != gender {
+			continue
+		}
+		if best == nil ||
+			(best.Gender == -1 && entry.Gender != -1) ||
+			(best.Gender == entry.Gender && entry.AgeMax-entry.AgeMin < best.AgeMax-best.AgeMin) {
+			best = entry
+		}
+	}
+	return best
+}
+
+// score 计算记录各指标相对常模的 z 分、T 分和百分位，常模中没有的指标跳过
+func (n *sclNorm) score(scl models.SCL) vo.SCLNormResult {
+	result := vo.SCLNormResult{Code: n.Group.Code, Name: n.Group.Name, Scores: []vo.SCLNormScore{}}
+	for _, metric := range sclMetrics() {
+		entry := n.lookup(metric.Key, scl.Gender, scl.Age)
+		if entry == nil || entry.SD <= 0 {
+			continue
+		}
+		raw := sclMetricValue(scl, metric.Key)
+		z := (raw - entry.Mean) / entry.SD
+		result.Scores = append(result.Scores, vo.SCLNormScore{
+			Key:        metric.Key,
+			Name:       metric.Name,
+			Raw:        raw,
+			Mean:       entry.Mean,
+			SD:         entry.SD,
+			Z:          roundIndex(z),
+			T:          roundIndex(50 + 10*z),
+			Percentile: roundIndex(50 * (1 + math.Erf(z/math.Sqrt2))),
+		})
+	}
+	return result
+}
+
+// tScore 取指定指标的 T 分，常模中没有该指标时返回 false
+func (n *sclNorm) tScore(scl models.SCL, key string) (float64, bool) {
+	entry := n.lookup(key, scl.Gender, scl.Age)
+	if entry == nil || entry.SD <= 0 {
+		return 0, false
+	}
+	return 50 + 10*(sclMetricValue(scl, key)-entry.Mean)/entry.SD, true
+}
+
+// sclMetrics 可与常模比较的全部指标：十个因子 + 全局指标
+func sclMetrics() []sclMetric {
+	metrics := make([]sclMetric, 0, len(sclFactors)+len(sclGlobalMetrics))
+	for _, factor := range sclFactors {
+		metrics = append(metrics, sclMetric{Key: factor.Key, Name: factor.Name})
+	}
+	return append(metrics, sclGlobalMetrics...)
+}
+
+// sclMetricValue 取记录中指定指标的原始值
+func sclMetricValue(scl models.SCL, key string) float64 {
+	switch key {
+	case "total_score":
+		return scl.TotalScore
+	case "positive_items":
+		return scl.PositiveItems
+	case "gsi":
+		return scl.GSI
+	}
+	for _, factor := range sclFactors {
+		if factor.Key == key {
+			return float64(*factor.Field(&scl))
+		}
+	}
+	return 0
+}
+
+// sclMetricKey 将指标名称（字段名或中文名）转换为字段名，无法识别时返回空串
+func sclMetricKey(name string) string {
+	name = strings.TrimSpace(name)
+	for _, metric := range sclMetrics() {
+		if metric.Key == name || metric.Name == name {
+			return metric.Key
+		}
+	}
+	return ""
+}
+
+// loadNorm 加载常模组：code 为空时取默认常模，数据库中没有默认常模时使用内置常模
+func loadNorm(code string) (*sclNorm, error) {
+	normDao := dao.NewNormDao(config.DB)
+	if code == "" {
+		group, err := normDao.FindDefault()
+		if err != nil {
+			return nil, err
+		}
+		if group == nil {
+			group = builtinNormGroup
+		}
+		return &sclNorm{Group: group}, nil
+	}
+	if code == builtinNormGroup.Code {
+		return &sclNorm{Group: builtinNormGroup}, nil
+	}
+	group, err := normDao.FindByCode(code)
+	if err != nil {
+		return nil, err
+	}
+	if group == nil {
+		return nil, fmt.Errorf("常模组 %s 不存在", code)
+	}
+	return &sclNorm{Group: group}, nil
+}
+
+// NormService 常模业务
+type NormService struct {
+}
+
+// NewNormService 创建新的 NormService
+func NewNormService() *NormService {
+	return &NormService{}
+}
+
+// CreateGroup 校验并保存常模组及其条目；常模影响全部学生的 T 分、百分位和报告，仅管理员可操作
+func (s *NormService) CreateGroup(group *models.NormGroup, operator *dao.DataScope) error {
+	if err := requireAdmin(operator); err != nil {
+		return err
+	}
+	if group.Code == "" || group.Name == "" {
+		return errors.New("常模组编码和名称不能为空")
+	}
+	if group.Code == builtinNormGroup.Code {
+		return errors.New("常模组编码与内置常模重复")
+	}
+	if len(group.Entries) == 0 {
+		return errors.New("常模条目不能为空")
+	}
+	for i := range group.Entries {
+		entry := &group.Entries[i]
+		key := sclMetricKey(entry.Factor)
+		if key == "" {
+			return fmt.Errorf("第 %d 条常模的指标 %s 无法识别", i+1, entry.Factor)
+		}
+		entry.Factor = key
+		if entry.Gender != -1 && entry.Gender != 0 && entry.Gender != 1 {
+			return fmt.Errorf("第 %d 条常模的性别只能是 -1（不限）、0（女）或 1（男）", i+1)
+		}
+		if entry.AgeMin < 0 || entry.AgeMax < entry.AgeMin {
+			return fmt.Errorf("第 %d 条常模的年龄段无效", i+1)
+		}
+		if entry.SD <= 0 {
+			return fmt.Errorf("第 %d 条常模的标准差必须大于 0", i+1)
+		}
+	}
+
+	normDao := dao.NewNormDao(config.DB)
+	existing, err := normDao.FindByCode(group.Code)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		return fmt.Errorf("常模组编码 %s 已存在", group.Code)
+	}
+	group.ID = 0
+	group.IsDefault = false
+	return normDao.SaveGroup(group)
+}
+
+// ImportFromFileId 从已上传的 Excel 导入常模组
+// 表格第一个工作表，首行为表头，列依次为：指标、性别（不限/女/男 或 -1/0/1）、年龄下限、年龄上限、均值、标准差；仅管理员可操作
+func (s *NormService) ImportFromFileId(fileId string, group *models.NormGroup, operator *dao.DataScope) error {
+	if err := requireAdmin(operator); err != nil {
+		return err
+	}
+	var fileService FileService
+	objectPath, exists := fileService.CheckFileIsExist(fileId)
+	if !exists {
+		return fmt.Errorf("文件ID %s 不存在", fileId)
+	}
+	f, err := openExcel(objectPath)
+	if err != nil {
+		return err
+	}
+	rows, err := f.GetRows(f.GetSheetName(0))
+	if err != nil {
+		return fmt.Errorf("读取工作表失败: %v", err)
+	}
+	if len(rows) < 2 {
+		return errors.New("常模表格没有数据")
+	}
+
+	for i, row := range rows[1:] {
+		rowNum := i + 2
+		if len(row) < 6 {
+			return fmt.Errorf("第 %d 行数据列数不足（当前列数：%d）", rowNum, len(row))
+		}
+		gender, err := parseNormGender(row[1])
+		if err != nil {
+			return fmt.Errorf("第 %d 行%v", rowNum, err)
+		}
+		values := make([]float64, 4)
+		for j, cell := range row[2:6] {
+			if values[j], err = strconv.ParseFloat(strings.TrimSpace(cell), 64); err != nil {
+				return fmt.Errorf("第 %d 行第 %d 列数值格式错误: %q", rowNum, j+3, cell)
+			}
+		}
+		group.Entries = append(group.Entries, models.NormEntry{
+			Factor: row[0],
+			Gender: gender,
+			AgeMin: int(values[0]),
+			AgeMax: int(values[1]),
+			Mean:   values[2],
+			SD:     values[3],
+		})
+	}
+	return s.CreateGroup(group, operator)
+}
+
+// parseNormGender 解析常模表中的性别
+func parseNormGender(s string) (int, error) {
+	switch strings.TrimSpace(s) {
+	case "", "不限", "全部", "-1":
+		return -1, nil
+	case "女", "0":
+		return 0, nil
+	case "男", "1":
+		return 1, nil
+	}
+	return 0, fmt.Errorf("性别格式错误: %q", s)
+}
+
+// ListGroups 列出全部常模组，内置常模排在首位
+func (s *NormService) ListGroups() ([]models.NormGroup, error) {
+	normDao := dao.NewNormDao(config.DB)
+	list, err := normDao.ListGroups()
+	if err != nil {
+		return nil, err
+	}
+	builtin := *builtinNormGroup
+	builtin.Entries = nil
+	return append([]models.NormGroup{builtin}, list...), nil
+}
+
+// SetDefault 设置默认常模组；仅管理员可操作
+func (s *NormService) SetDefault(id int64, operator *dao.DataScope) error {
+	if err := requireAdmin(operator); err != nil {
+		return err
+	}
+	normDao := dao.NewNormDao(config.DB)
+	if err := normDao.SetDefault(id); err != nil {
+		return errors.New("常模组不存在")
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package service
+
+import (
+	"gorm.io/gorm"
+	"mental/config"
+	"mental/dao"
+	"mental/models"
+)
+
+// notify 在事务中发送站内通知
+func notify(tx *gorm.DB, notifications ...models.Notification) error {
+	return dao.NewNotificationDao(tx).SaveBatch(notifications)
+}
+
+// NotificationService 站内通知业务
+type NotificationService struct {
+}
+
+// NewNotificationService 创建新的 NotificationService
+func NewNotificationService() *NotificationService {
+	return &NotificationService{}
+}
+
+// List 查询用户的通知，unreadOnly 为 true 时只返回未读通知
+func (s *NotificationService) List(userId int64, unreadOnly bool) ([]models.Notification, error) {
+	return dao.NewNotificationDao(config.DB).ListByUser(userId, unreadOnly)
+}
+
+// MarkRead 将用户的通知标记为已读，ids 为空时标记全部
+func (s *NotificationService) MarkRead(userId int64, ids []int64) error {
+	return dao.NewNotificationDao(config.DB).MarkRead(userId, ids)
+}
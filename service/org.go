@@ -0,0 +1,550 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"mental/config"
+	"mental/dao"
+	"mental/models"
+	"mental/vo"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// orgChildTypes 各类型组织允许的下级类型，空串为根（只能是学校）
+var orgChildTypes = map[string][]string{
+	"":                    {models.OrgTypeSchool},
+	models.OrgTypeSchool:  {models.OrgTypeCollege, models.OrgTypeGrade},
+	models.OrgTypeCollege: {models.OrgTypeGrade, models.OrgTypeClass},
+	models.OrgTypeGrade:   {models.OrgTypeClass},
+}
+
+// rosterHeaders 花名册导入的表头：学号、姓名、性别（男/女 或 1/0）、班级编码
+var rosterHeaders = []string{"学号", "姓名", "性别", "班级编码"}
+
+// studentOrgID 查询学生所在班级的组织ID，学生为空或不在花名册中时返回 0（全局）
+func studentOrgID(studentId *int64) (int64, error) {
+	if studentId == nil {
+		return 0, nil
+	}
+	member, err := dao.NewOrgDao(config.DB).FindMemberByUserID(*studentId)
+	if err != nil || member == nil {
+		return 0, err
+	}
+	return member.OrgID, nil
+}
+
+// orgLookupIDs 按组织配置的查找顺序返回组织ID：自身、上级……学校，最后为全局（0）
+func orgLookupIDs(orgId int64) ([]int64, error) {
+	var ancestors []int64
+	if orgId != 0 {
+		org, err := dao.NewOrgDao(config.DB).FindByID(orgId)
+		if err != nil {
+			return nil, err
+		}
+		if org != nil {
+			ancestors = org.AncestorIDs()
+		}
+	}
+	lookup := make([]int64, 0, len(ancestors)+1)
+	for i := len(ancestors) - 1; i >= 0; i-- {
+		lookup = append(lookup, ancestors[i])
+	}
+	return append(lookup, 0), nil
+}
+
+// parseGender 解析性别：男/1 为 1，女/0 为 0
+func parseGender(s string) (int, error) {
+	switch strings.TrimSpace(s) {
+	case "男", "1":
+		return 1, nil
+	case "女", "0":
+		return 0, nil
+	}
+	return 0, fmt.Errorf("性别 %q 无法识别", s)
+}
+
+// OrgService 组织树与班级花名册业务
+type OrgService struct {
+}
+
+// NewOrgService 创建新的 OrgService
+func NewOrgService() *OrgService {
+	return &OrgService{}
+}
+
+// CreateOrg 新建组织单元，校验上级存在且类型层级合法，编码全局唯一；仅管理员或上级组织的负责人可操作，学校只能由管理员新建
+func (s *OrgService) CreateOrg(org *models.OrgUnit, operator *dao.DataScope) error {
+	if err := requireOrgManager(org.ParentID, operator); err != nil {
+		return err
+	}
+	org.Name = strings.TrimSpace(org.Name)
+	org.Code = strings.TrimSpace(org.Code)
+	if org.Name == "" || org.Code == "" {
+		return errors.New("组织名称和编码不能为空")
+	}
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		orgDao := dao.NewOrgDao(tx)
+		parentType, parentPath := "", "/"
+		if org.ParentID != 0 {
+			parent, err := orgDao.FindByID(org.ParentID)
+			if err != nil {
+				return err
+			}
+			if parent == nil {
+				return errors.New("上级组织不存在")
+			}
+			parentType, parentPath = parent.Type, parent.Path
+		}
+		allowed := false
+		for _, childType := range orgChildTypes[parentType] {
+			if childType == org.Type {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			if parentType == "" {
+				return errors.New("顶级组织只能是学校")
+			}
+			return fmt.Errorf("%s 下不能创建 %s 类型的组织", parentType, org.Type)
+		}
+		existing, err := orgDao.FindByCode(org.Code)
+		if err != nil {
+			return err
+		}
+		if existing != nil {
+			return fmt.Errorf("组织编码 %s 已存在", org.Code)
+		}
+
+		org.ID = 0
+		if err := orgDao.Save(org); err != nil {
+			return err
+		}
+		org.Path = parentPath + strconv.FormatInt(org.ID, 10) + "/"
+		return orgDao.UpdatePath(org.ID, org.Path)
+	})
+}
+
+// UpdateOrg 修改组织单元的名称、编码和排序，上级和类型不可修改；仅管理员或该组织（含上级组织）的负责人可操作
+func (s *OrgService) UpdateOrg(org *models.OrgUnit, operator *dao.DataScope) error {
+	if err := requireOrgManager(org.ID, operator); err != nil {
+		return err
+	}
+	org.Name = strings.TrimSpace(org.Name)
+	org.Code = strings.TrimSpace(org.Code)
+	if org.Name == "" || org.Code == "" {
+		return errors.New("组织名称和编码不能为空")
+	}
+	orgDao := dao.NewOrgDao(config.DB)
+	existing, err := orgDao.FindByID(org.ID)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return errors.New("组织不存在")
+	}
+	if sameCode, err := orgDao.FindByCode(org.Code); err != nil {
+		return err
+	} else if sameCode != nil && sameCode.ID != org.ID {
+		return fmt.Errorf("组织编码 %s 已存在", org.Code)
+	}
+	if err := orgDao.Update(org); err != nil {
+		return err
+	}
+	invalidateCohortStats()
+	return nil
+}
+
+// DeleteOrg 删除没有下级组织且花名册为空的组织单元；仅管理员或上级组织的负责人可操作，学校只能由管理员删除
+func (s *OrgService) DeleteOrg(id int64, operator *dao.DataScope) error {
+	orgDao := dao.NewOrgDao(config.DB)
+	org, err := orgDao.FindByID(id)
+	if err != nil {
+		return err
+	}
+	if org == nil {
+		return errors.New("组织不存在")
+	}
+	if err := requireOrgManager(org.ParentID, operator); err != nil {
+		return err
+	}
+	children, err := orgDao.CountChildren(id)
+	if err != nil {
+		return err
+	}
+	if children > 0 {
+		return errors.New("请先删除下级组织")
+	}
+	members, err := orgDao.CountMembers(id)
+	if err != nil {
+		return err
+	}
+	if members > 0 {
+		return errors.New("请先移出班级中的学生")
+	}
+	return orgDao.DeleteByID(id)
+}
+
+// Tree 查询完整的组织树，同级按排序号、ID 排列
+func (s *OrgService) Tree() ([]*models.OrgUnit, error) {
+	units, err := dao.NewOrgDao(config.DB).ListSubtree("")
+	if err != nil {
+		return nil, err
+	}
+	nodes := make(map[int64]*models.OrgUnit, len(units))
+	for i := range units {
+		nodes[units[i].ID] = &units[i]
+	}
+	roots := []*models.OrgUnit{}
+	for i := range units {
+		node := &units[i]
+		if parent, ok := nodes[node.ParentID]; ok {
+			parent.Children = append(parent.Children, node)
+		} else {
+			roots = append(roots, node)
+		}
+	}
+	return roots, nil
+}
+
+// findOrg 查询组织单元，不存在时返回错误
+func findOrg(orgId int64) (*models.OrgUnit, error) {
+	org, err := dao.NewOrgDao(config.DB).FindByID(orgId)
+	if err != nil {
+		return nil, err
+	}
+	if org == nil {
+		return nil, errors.New("组织不存在")
+	}
+	return org, nil
+}
+
+// ListMembers 查询组织（含全部下级班级）的花名册
+func (s *OrgService) ListMembers(orgId int64) ([]models.OrgMember, error) {
+	org, err := findOrg(orgId)
+	if err != nil {
+		return nil, err
+	}
+	return dao.NewOrgDao(config.DB).ListMembers(org.Path)
+}
+
+// RemoveMember 将学生移出花名册；仅管理员或学生所在班级（含上级组织）的负责人可操作
+func (s *OrgService) RemoveMember(id int64, operator *dao.DataScope) error {
+	orgDao := dao.NewOrgDao(config.DB)
+	member, err := orgDao.FindMemberByID(id)
+	if err != nil {
+		return err
+	}
+	if member == nil {
+		return errors.New("花名册记录不存在")
+	}
+	if err := requireOrgManager(member.OrgID, operator); err != nil {
+		return err
+	}
+	if err := orgDao.DeleteMember(id); err != nil {
+		return err
+	}
+	invalidateCohortStats()
+	return nil
+}
+
+// ImportRoster 从已上传的 Excel 文件导入花名册
+// 首个工作表的首行为表头，须包含 学号、姓名、性别、班级编码 四列（顺序不限）；学号已存在时更新其班级和信息
+// 账号与学号一致的用户自动关联，其测评记录随之归入该班级；有效行在同一事务中写入，返回导入人数和错误行
+// 非管理员只能导入自己负责的组织（含下级）中的班级，其余班级的行记为错误行
+func (s *OrgService) ImportRoster(fileId string, operator *dao.DataScope) (int, []string) {
+	fileService := FileService{}
+	objectPath, exists := fileService.CheckFileIsExist(fileId)
+	if !exists {
+		return 0, []string{fmt.Sprintf("文件ID %s 不存在", fileId)}
+	}
+	f, err := openExcel(objectPath)
+	if err != nil {
+		return 0, []string{err.Error()}
+	}
+	defer f.Close()
+	sheets := f.GetSheetList()
+	if len(sheets) == 0 {
+		return 0, []string{"文件中没有工作表"}
+	}
+	rows, err := f.GetRows(sheets[0])
+	if err != nil {
+		return 0, []string{fmt.Sprintf("读取工作表失败: %v", err)}
+	}
+	if len(rows) < 2 {
+		return 0, []string{"花名册中没有数据"}
+	}
+
+	// 按表头名称定位各列
+	columns := make([]int, len(rosterHeaders))
+	for i, header := range rosterHeaders {
+		columns[i] = -1
+		for j, cell := range rows[0] {
+			if strings.TrimSpace(cell) == header {
+				columns[i] = j
+				break
+			}
+		}
+		if columns[i] < 0 {
+			return 0, []string{fmt.Sprintf("表头缺少“%s”列", header)}
+		}
+	}
+	cell := func(row []string, i int) string {
+		if columns[i] >= len(row) {
+			return ""
+		}
+		return strings.TrimSpace(row[columns[i]])
+	}
+
+	orgDao := dao.NewOrgDao(config.DB)
+	classes := make(map[string]*models.OrgUnit)
+	permitted := make(map[int64]bool)
+	seen := make(map[string]int)
+	var (
+		members   []models.OrgMember
+		errorRows []string
+	)
+	for i, row := range rows[1:] {
+		rowNum := i + 2
+		studentNo, name, code := cell(row, 0), cell(row, 1), cell(row, 3)
+		if studentNo == "" && name == "" && code == "" {
+			continue // 跳过空行
+		}
+		if studentNo == "" || name == "" {
+			errorRows = append(errorRows, fmt.Sprintf("第 %d 行学号或姓名为空", rowNum))
+			continue
+		}
+		if first, ok := seen[studentNo]; ok {
+			errorRows = append(errorRows, fmt.Sprintf("第 %d 行学号 %s 与第 %d 行重复", rowNum, studentNo, first))
+			continue
+		}
+		gender, err := parseGender(cell(row, 2))
+		if err != nil {
+			errorRows = append(errorRows, fmt.Sprintf("第 %d 行%v", rowNum, err))
+			continue
+		}
+		class, ok := classes[code]
+		if !ok {
+			if class, err = orgDao.FindByCode(code); err != nil {
+				return 0, []string{fmt.Sprintf("查询班级失败: %v", err)}
+			}
+			classes[code] = class
+		}
+		if class == nil || class.Type != models.OrgTypeClass {
+			errorRows = append(errorRows, fmt.Sprintf("第 %d 行班级编码 %s 不存在", rowNum, code))
+			continue
+		}
+		allowed, ok := permitted[class.ID]
+		if !ok {
+			err := requireOrgManager(class.ID, operator)
+			if err != nil && !errors.Is(err, ErrPermissionDenied) {
+				return 0, []string{fmt.Sprintf("校验班级权限失败: %v", err)}
+			}
+			allowed = err == nil
+			permitted[class.ID] = allowed
+		}
+		if !allowed {
+			errorRows = append(errorRows, fmt.Sprintf("第 %d 行班级 %s 不在您负责的组织内", rowNum, code))
+			continue
+		}
+		seen[studentNo] = rowNum
+		members = append(members, models.OrgMember{OrgID: class.ID, StudentNo: studentNo, Name: name, Gender: gender})
+	}
+
+	// 调班时学生原所在班级也须在负责范围内，否则不能将其调出
+	if !operator.IsAdmin() {
+		numbers := make([]string, 0, len(members))
+		for _, member := range members {
+			numbers = append(numbers, member.StudentNo)
+		}
+		existing, err := orgDao.ListMembersByStudentNos(numbers)
+		if err != nil {
+			return 0, []string{fmt.Sprintf("查询花名册失败: %v", err)}
+		}
+		current := make(map[string]int64, len(existing))
+		for _, member := range existing {
+			current[member.StudentNo] = member.OrgID
+		}
+		kept := members[:0]
+		for _, member := range members {
+			if orgId, ok := current[member.StudentNo]; ok && orgId != member.OrgID {
+				if err := requireOrgManager(orgId, operator); err != nil {
+					errorRows = append(errorRows, fmt.Sprintf("第 %d 行学号 %s 所在的原班级不在您负责的组织内，不能调班", seen[member.StudentNo], member.StudentNo))
+					continue
+				}
+			}
+			kept = append(kept, member)
+		}
+		members = kept
+	}
+
+	// 关联账号与学号一致的用户
+	accounts := make([]string, 0, len(members))
+	for _, member := range members {
+		accounts = append(accounts, member.StudentNo)
+	}
+	users, err := dao.NewUserDao(config.DB).ListByAccounts(accounts)
+	if err != nil {
+		return 0, []string{fmt.Sprintf("查询用户失败: %v", err)}
+	}
+	userIds := make(map[string]int64, len(users))
+	for _, user := range users {
+		userIds[user.Account] = int64(user.Id)
+	}
+	for i := range members {
+		if id, ok := userIds[members[i].StudentNo]; ok {
+			members[i].UserID = &id
+		}
+	}
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		return dao.NewOrgDao(tx).UpsertMembers(members)
+	})
+	if err != nil {
+		return 0, append(errorRows, fmt.Sprintf("写入花名册失败: %v", err))
+	}
+	invalidateCohortStats()
+	return len(members), errorRows
+}
+
+// orgStatAcc 汇总组织测评指标的累加器
+type orgStatAcc struct {
+	stat   vo.OrgStat
+	sums   []float64
+	orgIds map[int64]bool
+}
+
+// newOrgStatAcc 创建组织的累加器
+func newOrgStatAcc(org models.OrgUnit) *orgStatAcc {
+	return &orgStatAcc{
+		stat: vo.OrgStat{OrgID: org.ID, Name: org.Name, Type: org.Type, LevelCounts: map[string]int{
+			models.SCLLevelNormal:   0,
+			models.SCLLevelMild:     0,
+			models.SCLLevelModerate: 0,
+			models.SCLLevelSevere:   0,
+		}},
+		sums: make([]float64, len(sclMetrics())),
+	}
+}
+
+// add 累加一名学生的测评记录及其等级
+func (a *orgStatAcc) add(scl models.SCL, level string) {
+	a.stat.AssessedCount++
+	a.stat.LevelCounts[level]++
+	if level != models.SCLLevelNormal {
+		a.stat.PositiveCount++
+	}
+	for i, metric := range sclMetrics() {
+		a.sums[i] += sclMetricValue(scl, metric.Key)
+	}
+}
+
+// result 计算均值和比率
+func (a *orgStatAcc) result() vo.OrgStat {
+	stat := a.stat
+	stat.Means = make([]vo.OrgMetricMean, 0, len(a.sums))
+	for i, metric := range sclMetrics() {
+		mean := 0.0
+		if stat.AssessedCount > 0 {
+			mean = roundIndex(a.sums[i] / float64(stat.AssessedCount))
+		}
+		stat.Means = append(stat.Means, vo.OrgMetricMean{Key: metric.Key, Name: metric.Name, Mean: mean})
+	}
+	if stat.MemberCount > 0 {
+		stat.CoverageRate = roundIndex(float64(stat.AssessedCount) * 100 / float64(stat.MemberCount))
+	}
+	if stat.AssessedCount > 0 {
+		stat.PositiveRate = roundIndex(float64(stat.PositiveCount) * 100 / float64(stat.AssessedCount))
+	}
+	return stat
+}
+
+// Stats 汇总组织及其直接下级的测评情况：花名册学生在 [from, to) 内最近一次有效测评的因子均值、等级分布和阳性检出率
+// 等级按记录生成时所用的规则集判断；from、to 为 nil 时不限；组织须在当前用户的数据范围内
+func (s *OrgService) Stats(orgId int64, from *time.Time, to *time.Time, scope *dao.DataScope) (*vo.OrgStats, error) {
+	org, err := findOrg(orgId)
+	if err != nil {
+		return nil, err
+	}
+	if !scope.CoversOrg(org.Path) {
+		return nil, ErrPermissionDenied
+	}
+	orgDao := dao.NewOrgDao(config.DB)
+	units, err := orgDao.ListSubtree(org.Path)
+	if err != nil {
+		return nil, err
+	}
+	members, err := orgDao.ListMembers(org.Path)
+	if err != nil {
+		return nil, err
+	}
+	scls, err := dao.NewSCLDao(config.DB).WithScope(scope).SelectAllByOrgPath(org.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	// 每个下级组织对应一个累加器，子树中的组织归入其所在的直接下级
+	total := newOrgStatAcc(*org)
+	children := []*orgStatAcc{}
+	childOf := make(map[int64]*orgStatAcc, len(units))
+	depth := len(org.AncestorIDs())
+	sort.SliceStable(units, func(i, j int) bool {
+		return strings.Count(units[i].Path, "/") < strings.Count(units[j].Path, "/") // 先处理上级，保持同级的排序
+	})
+	for _, unit := range units {
+		ancestors := unit.AncestorIDs()
+		if len(ancestors) <= depth {
+			continue
+		}
+		if len(ancestors) == depth+1 {
+			acc := newOrgStatAcc(unit)
+			children = append(children, acc)
+			childOf[unit.ID] = acc
+			continue
+		}
+		childOf[unit.ID] = childOf[ancestors[depth]]
+	}
+
+	studentOrg := make(map[int64]int64, len(members))
+	for _, member := range members {
+		total.stat.MemberCount++
+		if acc := childOf[member.OrgID]; acc != nil {
+			acc.stat.MemberCount++
+		}
+		if member.UserID != nil {
+			studentOrg[*member.UserID] = member.OrgID
+		}
+	}
+
+	// 记录按测评日期倒序，每名学生取第一条符合条件的有效记录
+	counted := make(map[int64]bool)
+	for _, scl := range scls {
+		if scl.Invalid || scl.StudentID == nil || counted[*scl.StudentID] {
+			continue
+		}
+		testDate := time.Time(scl.TestDate)
+		if (from != nil && testDate.Before(*from)) || (to != nil && !testDate.Before(*to)) {
+			continue
+		}
+		counted[*scl.StudentID] = true
+		rules, err := loadSCLRules(scl.RuleSetID)
+		if err != nil {
+			return nil, err
+		}
+		level := analyzeSCL(scl, rules).Level
+		total.add(scl, level)
+		if acc := childOf[studentOrg[*scl.StudentID]]; acc != nil {
+			acc.add(scl, level)
+		}
+	}
+
+	result := &vo.OrgStats{OrgStat: total.result(), Children: make([]vo.OrgStat, 0, len(children))}
+	for _, acc := range children {
+		result.Children = append(result.Children, acc.result())
+	}
+	return result, nil
+}
@@ -0,0 +1,60 @@
+package service
+
+import (
+	"errors"
+	"mental/config"
+	"mental/dao"
+	"mental/models"
+	"testing"
+)
+
+func TestOrgChangesRequireManager(t *testing.T) {
+	setupTestEnv(t)
+	admin := adminScope()
+	manager, student := selfScope(10), selfScope(100)
+	orgService := NewOrgService()
+
+	school := &models.OrgUnit{Type: models.OrgTypeSchool, Name: "学校", Code: "S1"}
+	if err := orgService.CreateOrg(school, manager); !errors.Is(err, ErrPermissionDenied) {
+		t.Errorf("非管理员新建学校应返回 ErrPermissionDenied，实际为 %v", err)
+	}
+	if err := orgService.CreateOrg(school, admin); err != nil {
+		t.Fatalf("管理员新建学校失败: %v", err)
+	}
+	if err := dao.NewDataScopeDao(config.DB).AddManager(&models.OrgManager{UserID: 10, OrgID: school.ID}); err != nil {
+		t.Fatalf("保存组织负责人失败: %v", err)
+	}
+	college := &models.OrgUnit{ParentID: school.ID, Type: models.OrgTypeCollege, Name: "学院", Code: "C1"}
+	if err := orgService.CreateOrg(college, student); !errors.Is(err, ErrPermissionDenied) {
+		t.Errorf("学生新建组织应返回 ErrPermissionDenied，实际为 %v", err)
+	}
+	if err := orgService.CreateOrg(college, manager); err != nil {
+		t.Fatalf("上级组织的负责人新建组织失败: %v", err)
+	}
+
+	college.Name = "改名"
+	if err := orgService.UpdateOrg(college, student); !errors.Is(err, ErrPermissionDenied) {
+		t.Errorf("学生修改组织应返回 ErrPermissionDenied，实际为 %v", err)
+	}
+	if err := orgService.DeleteOrg(college.ID, student); !errors.Is(err, ErrPermissionDenied) {
+		t.Errorf("学生删除组织应返回 ErrPermissionDenied，实际为 %v", err)
+	}
+	if err := orgService.DeleteOrg(school.ID, manager); !errors.Is(err, ErrPermissionDenied) {
+		t.Errorf("非管理员删除学校应返回 ErrPermissionDenied，实际为 %v", err)
+	}
+
+	member := []models.OrgMember{{OrgID: college.ID, StudentNo: "2024001", Name: "学生"}}
+	if err := dao.NewOrgDao(config.DB).UpsertMembers(member); err != nil {
+		t.Fatalf("写入花名册失败: %v", err)
+	}
+	stored, err := dao.NewOrgDao(config.DB).ListMembersByStudentNos([]string{"2024001"})
+	if err != nil || len(stored) != 1 {
+		t.Fatalf("查询花名册失败: %v", err)
+	}
+	if err := orgService.RemoveMember(stored[0].ID, student); !errors.Is(err, ErrPermissionDenied) {
+		t.Errorf("学生移出花名册应返回 ErrPermissionDenied，实际为 %v", err)
+	}
+	if err := orgService.RemoveMember(stored[0].ID, manager); err != nil {
+		t.Errorf("组织负责人移出花名册失败: %v", err)
+	}
+}
@@ -0,0 +1,401 @@
+package service
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/jung-kurt/gofpdf"
+	"mental/config"
+	"mental/dao"
+	"mental/models"
+	"mental/utils"
+	"mental/vo"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// reportFont 报告中注册的中文字体名
+const reportFont = "cjk"
+
+// reportLevelLabels 等级的中文名
+var reportLevelLabels = map[string]string{
+	models.SCLLevelNormal:   "基本正常",
+	models.SCLLevelMild:     "轻度",
+	models.SCLLevelModerate: "中度",
+	models.SCLLevelSevere:   "重度",
+}
+
+// reportLevelColors 因子剖面图中各等级的条形颜色
+var reportLevelColors = map[string][3]int{
+	models.SCLLevelNormal:   {91, 155, 213},
+	models.SCLLevelMild:     {255, 192, 0},
+	models.SCLLevelModerate: {237, 125, 49},
+	models.SCLLevelSevere:   {192, 0, 0},
+}
+
+// reportGenders 性别的中文名
+var reportGenders = map[int]string{0: "女", 1: "男"}
+
+// ReportService PDF 测评报告业务
+type ReportService struct {
+}
+
+// NewReportService 创建新的 ReportService
+func NewReportService() *ReportService {
+	return &ReportService{}
+}
+
+// SCLReport 生成数据范围内指定测评记录的 PDF 报告，历次测评表包含该学生范围内的全部记录
+func (s *ReportService) SCLReport(sclId int64, normCode string, scope *dao.DataScope) ([]byte, string, error) {
+	scl, err := findSCLInScope(config.DB, sclId, scope)
+	if err != nil {
+		return nil, "", err
+	}
+	history := []models.SCL{*scl}
+	if scl.StudentID != nil {
+		if history, err = dao.NewSCLDao(config.DB).WithScope(scope).SelectAllByUserId(*scl.StudentID); err != nil {
+			return nil, "", err
+		}
+	}
+	data, err := renderSCLReport(*scl, history, normCode)
+	if err != nil {
+		return nil, "", err
+	}
+	return data, fmt.Sprintf("scl_report_%d.pdf", scl.ID), nil
+}
+
+// StudentReport 生成学生的 PDF 报告：以最近一次有效测评为主（没有有效测评时取最近一次），附历次测评表
+func (s *ReportService) StudentReport(studentId int64, normCode string, scope *dao.DataScope) ([]byte, string, error) {
+	scls, err := dao.NewSCLDao(config.DB).WithScope(scope).SelectAllByUserId(studentId)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(scls) == 0 {
+		return nil, "", errors.New("该学生没有可查看的测评记录")
+	}
+	latest := scls[0]
+	for _, scl := range scls {
+		if !scl.Invalid {
+			latest = scl
+			break
+		}
+	}
+	data, err := renderSCLReport(latest, scls, normCode)
+	if err != nil {
+		return nil, "", err
+	}
+	return data, fmt.Sprintf("student_report_%d.pdf", studentId), nil
+}
+
+// Store 将报告保存到 MinIO，返回下载链接
+func (s *ReportService) Store(data []byte) (string, error) {
+	file, err := os.CreateTemp("", "report-*.pdf")
+	if err != nil {
+		return "", fmt.Errorf("创建临时文件失败: %v", err)
+	}
+	defer os.Remove(file.Name())
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return "", fmt.Errorf("写入临时文件失败: %v", err)
+	}
+	if err := file.Close(); err != nil {
+		return "", fmt.Errorf("写入临时文件失败: %v", err)
+	}
+	return utils.UploadFileToMinio(file.Name())
+}
+
+// newReportPDF 创建 A4 报告文档，注册配置的中文字体并设置页眉页脚
+func newReportPDF(title string) (*gofpdf.Fpdf, error) {
+	font, err := os.ReadFile(config.ReportSettings.FontPath)
+	if err != nil {
+		return nil, fmt.Errorf("读取报告字体 %s 失败，请在 [report] font_path 配置中文 TrueType 字体: %v", config.ReportSettings.FontPath, err)
+	}
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.AddUTF8FontFromBytes(reportFont, "", font)
+	if pdf.Err() {
+		return nil, fmt.Errorf("加载报告字体失败: %v", pdf.Error())
+	}
+	pdf.SetTitle(title, true)
+	pdf.SetCreator(config.ReportSettings.Org, true)
+	pdf.SetMargins(15, 20, 15)
+	pdf.SetAutoPageBreak(true, 18)
+	pdf.AliasNbPages("{nb}")
+	pdf.SetHeaderFunc(func() {
+		pdf.SetFont(reportFont, "", 9)
+		pdf.SetTextColor(128, 128, 128)
+		pdf.SetY(10)
+		pdf.CellFormat(0, 5, config.ReportSettings.Org+" · "+title, "B", 1, "L", false, 0, "")
+		pdf.SetTextColor(0, 0, 0)
+		pdf.Ln(4)
+	})
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-14)
+		pdf.SetFont(reportFont, "", 8)
+		pdf.SetTextColor(128, 128, 128)
+		pdf.CellFormat(0, 5, "本报告仅供心理咨询专业人员参考，不作为临床诊断依据", "", 0, "L", false, 0, "")
+		pdf.CellFormat(0, 5, fmt.Sprintf("第 %d 页 / 共 {nb} 页", pdf.PageNo()), "", 0, "R", false, 0, "")
+		pdf.SetTextColor(0, 0, 0)
+	})
+	return pdf, nil
+}
+
+// reportHeading 输出小节标题
+func reportHeading(pdf *gofpdf.Fpdf, text string) {
+	pdf.Ln(3)
+	pdf.SetFont(reportFont, "", 12)
+	pdf.SetFillColor(230, 238, 247)
+	pdf.CellFormat(0, 8, text, "", 1, "L", true, 0, "")
+	pdf.Ln(2)
+	pdf.SetFont(reportFont, "", 10)
+}
+
+// reportTable 输出表格，首行为表头，数值列右对齐
+func reportTable(pdf *gofpdf.Fpdf, widths []float64, headers []string, rows [][]string) {
+	pdf.SetFont(reportFont, "", 9)
+	pdf.SetFillColor(242, 242, 242)
+	for i, header := range headers {
+		pdf.CellFormat(widths[i], 7, header, "1", 0, "C", true, 0, "")
+	}
+	pdf.Ln(-1)
+	for _, row := range rows {
+		for i, cell := range row {
+			align := "C"
+			if _, err := strconv.ParseFloat(cell, 64); err == nil {
+				align = "R"
+			}
+			pdf.CellFormat(widths[i], 6.5, cell, "1", 0, align, false, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+	pdf.SetFont(reportFont, "", 10)
+}
+
+// drawFactorProfile 绘制因子剖面图：各因子均分的横向条形图（1-5 分），条形颜色表示因子等级，虚线为阳性界值 2 分
+func drawFactorProfile(pdf *gofpdf.Fpdf, factors []vo.SCLFactorResult) {
+	const (
+		labelWidth = 32.0
+		chartWidth = 130.0
+		barHeight  = 5.0
+		rowHeight  = 7.5
+	)
+	left, _, _, _ := pdf.GetMargins()
+	x0 := left + labelWidth
+	height := rowHeight*float64(len(factors)) + 8
+	if _, pageHeight := pdf.GetPageSize(); pdf.GetY()+height > pageHeight-20 {
+		pdf.AddPage()
+	}
+	y0 := pdf.GetY()
+	scaleX := func(score float64) float64 {
+		return x0 + (score-1)/4*chartWidth
+	}
+
+	// 刻度与网格
+	pdf.SetFont(reportFont, "", 8)
+	pdf.SetDrawColor(210, 210, 210)
+	pdf.SetLineWidth(0.2)
+	bottom := y0 + rowHeight*float64(len(factors))
+	for score := 1.0; score <= 5; score++ {
+		x := scaleX(score)
+		pdf.Line(x, y0, x, bottom)
+		pdf.SetXY(x-5, bottom+1)
+		pdf.CellFormat(10, 4, strconv.Itoa(int(score)), "", 0, "C", false, 0, "")
+	}
+
+	for i, factor := range factors {
+		y := y0 + rowHeight*float64(i)
+		pdf.SetXY(left, y+(rowHeight-barHeight)/2)
+		pdf.SetFont(reportFont, "", 9)
+		pdf.CellFormat(labelWidth-2, barHeight, factor.Name, "", 0, "R", false, 0, "")
+		color := reportLevelColors[factor.Level]
+		pdf.SetFillColor(color[0], color[1], color[2])
+		score := float64(factor.Score)
+		if score > 1 {
+			pdf.Rect(x0, y+(rowHeight-barHeight)/2, scaleX(score)-x0, barHeight, "F")
+		}
+		pdf.SetXY(scaleX(score)+1, y+(rowHeight-barHeight)/2)
+		pdf.SetFont(reportFont, "", 8)
+		pdf.CellFormat(12, barHeight, strconv.FormatFloat(score, 'f', 2, 32), "", 0, "L", false, 0, "")
+	}
+
+	// 阳性界值
+	pdf.SetDrawColor(192, 0, 0)
+	pdf.SetDashPattern([]float64{1, 1}, 0)
+	pdf.Line(scaleX(2), y0, scaleX(2), bottom)
+	pdf.SetDashPattern([]float64{}, 0)
+	pdf.SetDrawColor(0, 0, 0)
+	pdf.SetY(bottom + 7)
+
+	// 图例
+	pdf.SetX(x0)
+	for _, level := range []string{models.SCLLevelNormal, models.SCLLevelMild, models.SCLLevelModerate, models.SCLLevelSevere} {
+		color := reportLevelColors[level]
+		pdf.SetFillColor(color[0], color[1], color[2])
+		x, y := pdf.GetXY()
+		pdf.Rect(x, y+1, 4, 3, "F")
+		pdf.SetX(x + 5)
+		pdf.CellFormat(18, 5, reportLevelLabels[level], "", 0, "L", false, 0, "")
+	}
+	pdf.CellFormat(0, 5, "红色虚线：阳性界值 2 分", "", 1, "L", false, 0, "")
+	pdf.SetFont(reportFont, "", 10)
+}
+
+// renderSCLReport 生成单次测评的 PDF 报告：基本信息、因子剖面图、常模比较、结论解释和历次测评
+func renderSCLReport(scl models.SCL, history []models.SCL, normCode string) ([]byte, error) {
+	norm, err := loadNorm(normCode)
+	if err != nil {
+		return nil, err
+	}
+	record, err := buildSCLRecordVO(scl, norm)
+	if err != nil {
+		return nil, err
+	}
+	scl = record.SCL
+
+	pdf, err := newReportPDF("SCL-90 心理测评报告")
+	if err != nil {
+		return nil, err
+	}
+	pdf.AddPage()
+	pdf.SetFont(reportFont, "", 18)
+	pdf.CellFormat(0, 12, "SCL-90 心理测评报告", "", 1, "C", false, 0, "")
+	pdf.SetFont(reportFont, "", 9)
+	pdf.CellFormat(0, 5, "生成时间："+time.Now().Format("2006-01-02 15:04"), "", 1, "C", false, 0, "")
+
+	// 基本信息
+	reportHeading(pdf, "一、基本信息")
+	studentNo, className := "-", "-"
+	if scl.StudentID != nil {
+		orgDao := dao.NewOrgDao(config.DB)
+		member, err := orgDao.FindMemberByUserID(*scl.StudentID)
+		if err != nil {
+			return nil, err
+		}
+		if member != nil {
+			studentNo = member.StudentNo
+			class, err := orgDao.FindByID(member.OrgID)
+			if err != nil {
+				return nil, err
+			}
+			if class != nil {
+				className = class.Name
+			}
+		}
+	}
+	validity := "有效"
+	if scl.Invalid {
+		validity = "无效（作答有效性检查未通过，结果仅供参考）"
+	}
+	info := [][2]string{
+		{"姓名", scl.Name}, {"学号", studentNo},
+		{"性别", reportGenders[scl.Gender]}, {"年龄", strconv.Itoa(scl.Age)},
+		{"班级", className}, {"测评日期", time.Time(scl.TestDate).Format("2006-01-02")},
+		{"记录编号", strconv.FormatInt(scl.ID, 10)}, {"作答有效性", validity},
+	}
+	pdf.SetFont(reportFont, "", 10)
+	for i, item := range info {
+		pdf.SetFillColor(242, 242, 242)
+		pdf.CellFormat(25, 7, item[0], "1", 0, "C", true, 0, "")
+		ln := 0
+		if i%2 == 1 {
+			ln = 1
+		}
+		pdf.CellFormat(65, 7, item[1], "1", ln, "L", false, 0, "")
+	}
+
+	// 因子剖面图
+	reportHeading(pdf, "二、因子剖面图")
+	drawFactorProfile(pdf, record.Analysis.Factors)
+
+	// 常模比较
+	reportHeading(pdf, "三、常模比较")
+	if record.Norm == nil || len(record.Norm.Scores) == 0 {
+		pdf.MultiCell(0, 6, "未配置常模，无法与常模比较。", "", "L", false)
+	} else {
+		pdf.MultiCell(0, 6, "比较常模："+record.Norm.Name+"（T 分 = 50 + 10z，T 分 ≥ 60 提示高于常模一个标准差以上）", "", "L", false)
+		pdf.Ln(1)
+		rows := make([][]string, 0, len(record.Norm.Scores))
+		for _, score := range record.Norm.Scores {
+			rows = append(rows, []string{
+				score.Name,
+				strconv.FormatFloat(score.Raw, 'f', 2, 64),
+				strconv.FormatFloat(score.Mean, 'f', 2, 64),
+				strconv.FormatFloat(score.SD, 'f', 2, 64),
+				strconv.FormatFloat(score.T, 'f', 1, 64),
+				strconv.FormatFloat(score.Percentile, 'f', 1, 64),
+			})
+		}
+		reportTable(pdf, []float64{40, 28, 28, 28, 28, 28}, []string{"指标", "原始分", "常模均值", "常模标准差", "T 分", "百分位"}, rows)
+	}
+
+	// 结论解释
+	reportHeading(pdf, "四、结果解释")
+	pdf.MultiCell(0, 6, fmt.Sprintf("总分 %.0f，阳性项目数 %.0f，总症状指数（GSI）%.2f，阳性症状均分（PSDI）%.2f。",
+		scl.TotalScore, scl.PositiveItems, scl.GSI, scl.PSDI), "", "L", false)
+	pdf.MultiCell(0, 6, "总体等级："+reportLevelLabels[record.Analysis.Level], "", "L", false)
+	pdf.MultiCell(0, 6, "综合结论："+record.HealthStatus, "", "L", false)
+	if record.Analysis.Summary != "" {
+		pdf.MultiCell(0, 6, record.Analysis.Summary, "", "L", false)
+	}
+	if len(record.Analysis.TriggeredRules) > 0 {
+		pdf.Ln(1)
+		rows := make([][]string, 0, len(record.Analysis.TriggeredRules))
+		for _, rule := range record.Analysis.TriggeredRules {
+			name := map[string]string{"total": "总分", "positive": "阳性项目数"}[rule.Type]
+			for _, factor := range record.Analysis.Factors {
+				if rule.Factor != "" && factor.Key == rule.Factor {
+					name = factor.Name
+				}
+			}
+			rows = append(rows, []string{
+				name,
+				strconv.FormatFloat(rule.Value, 'f', 2, 64),
+				strconv.FormatFloat(rule.Threshold, 'f', 2, 64),
+				reportLevelLabels[rule.Level],
+				rule.Result,
+			})
+		}
+		reportTable(pdf, []float64{30, 22, 22, 22, 84}, []string{"命中指标", "实际值", "阈值", "等级", "结论"}, rows)
+	}
+	pdf.SetFont(reportFont, "", 8)
+	pdf.SetTextColor(128, 128, 128)
+	pdf.MultiCell(0, 5, fmt.Sprintf("结论依据规则集：%s（版本 %d）", record.RuleSet.Name, record.RuleSet.Version), "", "L", false)
+	pdf.SetTextColor(0, 0, 0)
+	pdf.SetFont(reportFont, "", 10)
+
+	// 历次测评
+	reportHeading(pdf, "五、历次测评")
+	sort.SliceStable(history, func(i, j int) bool {
+		return time.Time(history[i].TestDate).After(time.Time(history[j].TestDate))
+	})
+	rows := make([][]string, 0, len(history))
+	for _, item := range history {
+		past, err := buildSCLRecordVO(item, nil)
+		if err != nil {
+			return nil, err
+		}
+		mark := "有效"
+		if past.Invalid {
+			mark = "无效"
+		}
+		if past.ID == scl.ID {
+			mark += "（本次）"
+		}
+		rows = append(rows, []string{
+			time.Time(past.TestDate).Format("2006-01-02"),
+			strconv.FormatFloat(past.TotalScore, 'f', 0, 64),
+			strconv.FormatFloat(past.PositiveItems, 'f', 0, 64),
+			strconv.FormatFloat(past.GSI, 'f', 2, 64),
+			reportLevelLabels[past.Analysis.Level],
+			mark,
+		})
+	}
+	reportTable(pdf, []float64{32, 26, 26, 26, 30, 40}, []string{"测评日期", "总分", "阳性项目数", "GSI", "等级", "有效性"}, rows)
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, fmt.Errorf("生成PDF报告失败: %v", err)
+	}
+	return buf.Bytes(), nil
+}
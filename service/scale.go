@@ -0,0 +1,515 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"math"
+	"mental/config"
+	"mental/dao"
+	"mental/models"
+	"mental/vo"
+	"sort"
+	"strings"
+	"time"
+)
+
+// scaleDef 已解析的量表：量表信息 + 结构化定义
+type scaleDef struct {
+	models.Scale
+	Def models.ScaleDefinition
+}
+
+// scaleScore 一次作答的计分结果
+type scaleScore struct {
+	RawScore  float64
+	Score     float64
+	Level     string
+	Result    string
+	Subscores []models.ScaleSubscore
+	Flags     []models.ScaleFlag
+}
+
+// options 取题目的选项，未单独指定时使用通用选项
+func (d *scaleDef) options(item models.ScaleItem) []models.ScaleOption {
+	if len(item.Options) > 0 {
+		return item.Options
+	}
+	return d.Def.Options
+}
+
+// validateAnswers 校验作答：题数与量表一致，每题分值必须是该题选项之一
+func (d *scaleDef) validateAnswers(answers []int) error {
+	if len(answers) != len(d.Def.Items) {
+		return fmt.Errorf("%s 作答必须为 %d 题（当前 %d 题）", d.Name, len(d.Def.Items), len(answers))
+	}
+	for i, item := range d.Def.Items {
+		valid := false
+		for _, option := range d.options(item) {
+			if option.Score == answers[i] {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return fmt.Errorf("第 %d 题分值 %d 不是有效选项", item.No, answers[i])
+		}
+	}
+	return nil
+}
+
+// score 按量表定义计分：反向题换算后求粗分与分量表分，粗分按公式换算后按界值分级，并检查单题预警
+func (d *scaleDef) score(answers []int) (scaleScore, error) {
+	if err := d.validateAnswers(answers); err != nil {
+		return scaleScore{}, err
+	}
+
+	scored := d.scoredItems(answers)
+	all := make([]int, len(d.Def.Items))
+	for i := range all {
+		all[i] = i + 1
+	}
+	result := scaleScore{
+		RawScore:  roundIndex(aggregate(scored, all, d.Def.Scoring.Method)),
+		Subscores: make([]models.ScaleSubscore, 0, len(d.Def.Subscales)),
+		Flags:     []models.ScaleFlag{},
+		Level:     models.SCLLevelNormal,
+		Result:    d.Def.NormalResult,
+	}
+	for _, subscale := range d.Def.Subscales {
+		result.Subscores = append(result.Subscores, models.ScaleSubscore{
+			Key:   subscale.Key,
+			Name:  subscale.Name,
+			Score: roundIndex(aggregate(scored, subscale.Items, subscale.Method)),
+		})
+	}
+
+	multiplier := d.Def.Scoring.Multiplier
+	if multiplier == 0 {
+		multiplier = 1
+	}
+	converted := result.RawScore * multiplier
+	switch d.Def.Scoring.Round {
+	case models.ScaleRoundFloor:
+		converted = math.Floor(converted + 1e-9) // 避免 1.25 倍换算的浮点误差
+	case models.ScaleRoundRound:
+		converted = math.Round(converted)
+	}
+	result.Score = roundIndex(converted)
+
+	for _, cutoff := range d.Def.Cutoffs { // 已按 min 从高到低排序
+		if result.Score >= cutoff.Min {
+			result.Level = cutoff.Level
+			result.Result = cutoff.Result
+			break
+		}
+	}
+
+	for _, flag := range d.Def.ItemFlags {
+		if answers[flag.Item-1] >= flag.Min {
+			result.Flags = append(result.Flags, models.ScaleFlag{Item: flag.Item, Score: answers[flag.Item-1], Level: flag.Level, Message: flag.Message})
+		}
+	}
+	return result, nil
+}
+
+// scoredItems 反向计分换算：反向题计分 = 最低分 + 最高分 - 作答分值
+func (d *scaleDef) scoredItems(answers []int) []float64 {
+	scored := make([]float64, len(answers))
+	for i, item := range d.Def.Items {
+		scored[i] = float64(answers[i])
+		if item.Reverse {
+			low, high := optionRange(d.options(item))
+			scored[i] = float64(low + high - answers[i])
+		}
+	}
+	return scored
+}
+
+// optionRange 选项分值的最小值与最大值
+func optionRange(options []models.ScaleOption) (int, int) {
+	low, high := options[0].Score, options[0].Score
+	for _, option := range options[1:] {
+		low = int(math.Min(float64(low), float64(option.Score)))
+		high = int(math.Max(float64(high), float64(option.Score)))
+	}
+	return low, high
+}
+
+// aggregate 按计分方式汇总指定题目（题号从 1 开始）的得分
+func aggregate(scored []float64, items []int, method string) float64 {
+	sum := 0.0
+	for _, no := range items {
+		sum += scored[no-1]
+	}
+	if method == models.ScaleMethodMean && len(items) > 0 {
+		return sum / float64(len(items))
+	}
+	return sum
+}
+
+// parseScaleDefinition 解析并校验量表定义，界值按 min 从高到低排序
+func parseScaleDefinition(content string) (models.ScaleDefinition, error) {
+	var def models.ScaleDefinition
+	if err := json.Unmarshal([]byte(content), &def); err != nil {
+		return def, fmt.Errorf("量表定义格式错误: %v", err)
+	}
+	if len(def.Items) == 0 {
+		return def, errors.New("量表至少需要一道题目")
+	}
+	for i, item := range def.Items {
+		if item.No != i+1 {
+			return def, fmt.Errorf("题号必须从 1 开始连续编号（第 %d 题题号为 %d）", i+1, item.No)
+		}
+		if strings.TrimSpace(item.Text) == "" {
+			return def, fmt.Errorf("第 %d 题题干不能为空", item.No)
+		}
+		if len(item.Options) == 0 && len(def.Options) == 0 {
+			return def, fmt.Errorf("第 %d 题没有可用的选项", item.No)
+		}
+	}
+	validMethod := func(method string) bool {
+		return method == models.ScaleMethodSum || method == models.ScaleMethodMean
+	}
+	for _, subscale := range def.Subscales {
+		if subscale.Key == "" || subscale.Name == "" {
+			return def, errors.New("分量表编码和名称不能为空")
+		}
+		if !validMethod(subscale.Method) {
+			return def, fmt.Errorf("分量表 %s 的计分方式只能是 sum 或 mean", subscale.Name)
+		}
+		if len(subscale.Items) == 0 {
+			return def, fmt.Errorf("分量表 %s 未包含题目", subscale.Name)
+		}
+		for _, no := range subscale.Items {
+			if no < 1 || no > len(def.Items) {
+				return def, fmt.Errorf("分量表 %s 的题号 %d 超出范围", subscale.Name, no)
+			}
+		}
+	}
+	if !validMethod(def.Scoring.Method) {
+		return def, errors.New("总分计分方式只能是 sum 或 mean")
+	}
+	if def.TimeLimit < 0 {
+		return def, errors.New("作答时限不能为负数")
+	}
+	if def.Scoring.Multiplier < 0 {
+		return def, errors.New("换算系数不能为负数")
+	}
+	switch def.Scoring.Round {
+	case models.ScaleRoundNone, models.ScaleRoundFloor, models.ScaleRoundRound:
+	default:
+		return def, fmt.Errorf("取整方式 %s 无法识别", def.Scoring.Round)
+	}
+	for _, cutoff := range def.Cutoffs {
+		if !isSCLLevel(cutoff.Level) || cutoff.Level == models.SCLLevelNormal {
+			return def, fmt.Errorf("界值等级 %s 无效", cutoff.Level)
+		}
+	}
+	for _, pair := range def.ConsistencyPairs {
+		if pair.A < 1 || pair.A > len(def.Items) || pair.B < 1 || pair.B > len(def.Items) || pair.A == pair.B {
+			return def, fmt.Errorf("一致性题对 %d-%d 的题号无效", pair.A, pair.B)
+		}
+		if pair.MaxDiff < 0 {
+			return def, fmt.Errorf("一致性题对 %d-%d 的允许分差不能为负数", pair.A, pair.B)
+		}
+	}
+	for _, flag := range def.ItemFlags {
+		if flag.Item < 1 || flag.Item > len(def.Items) {
+			return def, fmt.Errorf("单题预警的题号 %d 超出范围", flag.Item)
+		}
+		if !isSCLLevel(flag.Level) {
+			return def, fmt.Errorf("单题预警等级 %s 无效", flag.Level)
+		}
+	}
+	sort.SliceStable(def.Cutoffs, func(i, j int) bool {
+		return def.Cutoffs[i].Min > def.Cutoffs[j].Min
+	})
+	return def, nil
+}
+
+// findBuiltinScale 按编码查找内置量表
+func findBuiltinScale(code string) *scaleDef {
+	for _, scale := range builtinScales {
+		if scale.Code == code {
+			return scale
+		}
+	}
+	return nil
+}
+
+// loadScale 按编码加载量表：内置量表优先，其次查数据库
+func loadScale(code string) (*scaleDef, error) {
+	if scale := findBuiltinScale(code); scale != nil {
+		return scale, nil
+	}
+	scale, err := dao.NewScaleDao(config.DB).FindScaleByCode(code)
+	if err != nil {
+		return nil, err
+	}
+	if scale == nil {
+		return nil, fmt.Errorf("量表 %s 不存在", code)
+	}
+	def, err := parseScaleDefinition(scale.Definition)
+	if err != nil {
+		return nil, err
+	}
+	return &scaleDef{Scale: *scale, Def: def}, nil
+}
+
+// ScaleService 通用量表业务，SCL-90 的作答与结果仍由 SCLService 存入 scl 表
+type ScaleService struct {
+}
+
+// NewScaleService 创建新的 ScaleService
+func NewScaleService() *ScaleService {
+	return &ScaleService{}
+}
+
+// ListScales 列出全部量表（不含题目），内置量表在前
+func (s *ScaleService) ListScales() ([]models.Scale, error) {
+	list, err := dao.NewScaleDao(config.DB).ListScales()
+	if err != nil {
+		return nil, err
+	}
+	scales := make([]models.Scale, 0, len(builtinScales)+len(list))
+	for _, scale := range builtinScales {
+		scales = append(scales, scale.Scale)
+	}
+	for _, scale := range list {
+		scale.Definition = ""
+		scales = append(scales, scale)
+	}
+	return scales, nil
+}
+
+// GetScale 查询量表的完整定义
+func (s *ScaleService) GetScale(code string) (*vo.ScaleVO, error) {
+	scale, err := loadScale(code)
+	if err != nil {
+		return nil, err
+	}
+	info := scale.Scale
+	info.Definition = ""
+	return &vo.ScaleVO{Scale: info, Definition: scale.Def}, nil
+}
+
+// CreateScale 校验并保存新的量表定义；界值和单题预警决定结论与预警，仅管理员可操作
+func (s *ScaleService) CreateScale(scale *models.Scale, operator *dao.DataScope) error {
+	if err := requireAdmin(operator); err != nil {
+		return err
+	}
+	if scale.Code == "" || scale.Name == "" {
+		return errors.New("量表编码和名称不能为空")
+	}
+	if findBuiltinScale(scale.Code) != nil {
+		return fmt.Errorf("量表编码 %s 与内置量表重复", scale.Code)
+	}
+	def, err := parseScaleDefinition(scale.Definition)
+	if err != nil {
+		return err
+	}
+	scaleDao := dao.NewScaleDao(config.DB)
+	existing, err := scaleDao.FindScaleByCode(scale.Code)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		return fmt.Errorf("量表编码 %s 已存在", scale.Code)
+	}
+	content, _ := json.Marshal(def) // 保存排序后的定义
+	scale.ID = 0
+	scale.Definition = string(content)
+	return scaleDao.SaveScale(scale)
+}
+
+// Submit 提交一次量表作答并计分，命中单题预警（如 PHQ-9 第 9 题）时在同一事务中新建高风险预警
+// SCL-90 作答转为 SCL 记录，与 POST /scl 提交原始作答的结果完全一致
+func (s *ScaleService) Submit(submission *vo.ScaleSubmission) (*vo.ScaleResultVO, error) {
+	if submission.ScaleCode == sclScaleCode {
+		scl := models.SCL{
+			StudentID:       submission.StudentID,
+			Name:            submission.Name,
+			Gender:          submission.Gender,
+			Age:             submission.Age,
+			TestDate:        submission.TestDate,
+			Items:           submission.Answers,
+			DurationSeconds: submission.Duration,
+		}
+		if err := NewSCLService().CreateSCL(&scl); err != nil {
+			return nil, err
+		}
+		return sclToScaleResult(scl)
+	}
+
+	scale, err := loadScale(submission.ScaleCode)
+	if err != nil {
+		return nil, err
+	}
+	result, err := scale.score(submission.Answers)
+	if err != nil {
+		return nil, err
+	}
+	invalid, validity := encodeValidity(assessValidity(scale, submission.Answers, submission.Duration))
+	campaignId, err := matchCampaign(scale.Code, submission.StudentID, submission.TestDate)
+	if err != nil {
+		return nil, err
+	}
+	subscores, _ := json.Marshal(result.Subscores)
+	flags, _ := json.Marshal(result.Flags)
+	response := models.ScaleResponse{
+		ScaleCode:  scale.Code,
+		StudentID:  submission.StudentID,
+		TestDate:   submission.TestDate,
+		RawScore:   result.RawScore,
+		Score:      result.Score,
+		Level:      result.Level,
+		Result:     result.Result,
+		Subscores:  string(subscores),
+		Flags:      string(flags),
+		Invalid:    invalid,
+		Validity:   validity,
+		CampaignID: campaignId,
+		Answers:    submission.Answers,
+	}
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		scaleDao := dao.NewScaleDao(tx)
+		if err := scaleDao.SaveResponse(&response); err != nil {
+			return err
+		}
+		answers := make([]models.ScaleAnswer, 0, len(submission.Answers))
+		for i, score := range submission.Answers {
+			answers = append(answers, models.ScaleAnswer{ResponseID: response.ID, ItemNo: i + 1, Score: score})
+		}
+		if err := scaleDao.SaveAnswers(answers); err != nil {
+			return err
+		}
+		return raiseScaleAlert(tx, scale, &response, submission.Name, result.Flags)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return responseToScaleResult(scale, response)
+}
+
+// ListResults 查询数据范围内的作答结果，按测评日期倒序；code 为空时包含全部量表（含 scl 表中的 SCL-90 记录），studentId 为 nil 时不限学生
+func (s *ScaleService) ListResults(code string, studentId *int64, scope *dao.DataScope) ([]vo.ScaleResultVO, error) {
+	results := []vo.ScaleResultVO{}
+	if code == "" || code == sclScaleCode {
+		sclDao := dao.NewSCLDao(config.DB).WithScope(scope)
+		var (
+			scls []models.SCL
+			err  error
+		)
+		if studentId != nil {
+			scls, err = sclDao.SelectAllByUserId(*studentId)
+		} else {
+			scls, err = sclDao.SelectAll()
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, scl := range scls {
+			result, err := sclToScaleResult(scl)
+			if err != nil {
+				return nil, err
+			}
+			results = append(results, *result)
+		}
+		if code == sclScaleCode {
+			return results, nil
+		}
+	}
+
+	responses, err := dao.NewScaleDao(config.DB).WithScope(scope).ListResponses(code, studentId)
+	if err != nil {
+		return nil, err
+	}
+	scales := make(map[string]*scaleDef)
+	for _, response := range responses {
+		scale, ok := scales[response.ScaleCode]
+		if !ok {
+			if scale, err = loadScale(response.ScaleCode); err != nil {
+				return nil, err
+			}
+			scales[response.ScaleCode] = scale
+		}
+		result, err := responseToScaleResult(scale, response)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, *result)
+	}
+
+	// 合并后统一按测评日期倒序
+	sort.SliceStable(results, func(i, j int) bool {
+		return time.Time(results[i].TestDate).After(time.Time(results[j].TestDate))
+	})
+	return results, nil
+}
+
+// responseToScaleResult 将 scale_response 记录转换为统一的结果结构
+func responseToScaleResult(scale *scaleDef, response models.ScaleResponse) (*vo.ScaleResultVO, error) {
+	result := &vo.ScaleResultVO{
+		ID:        response.ID,
+		ScaleCode: response.ScaleCode,
+		ScaleName: scale.Name,
+		StudentID: response.StudentID,
+		TestDate:  response.TestDate,
+		RawScore:  response.RawScore,
+		Score:     response.Score,
+		Level:     response.Level,
+		Result:    response.Result,
+		Subscores: []models.ScaleSubscore{},
+		Flags:     []models.ScaleFlag{},
+		Invalid:   response.Invalid,
+	}
+	var err error
+	if result.Validity, err = decodeValidity(response.Validity); err != nil {
+		return nil, err
+	}
+	if response.Subscores != "" {
+		if err := json.Unmarshal([]byte(response.Subscores), &result.Subscores); err != nil {
+			return nil, fmt.Errorf("作答记录 %d 的分量表得分格式错误: %v", response.ID, err)
+		}
+	}
+	if response.Flags != "" {
+		if err := json.Unmarshal([]byte(response.Flags), &result.Flags); err != nil {
+			return nil, fmt.Errorf("作答记录 %d 的单题预警格式错误: %v", response.ID, err)
+		}
+	}
+	return result, nil
+}
+
+// sclToScaleResult 将 scl 表记录转换为统一的结果结构：因子分作为分量表，等级与结论按记录生成时的规则集判断
+func sclToScaleResult(scl models.SCL) (*vo.ScaleResultVO, error) {
+	record, err := buildSCLRecordVO(scl, nil)
+	if err != nil {
+		return nil, err
+	}
+	scl = record.SCL
+	result := &vo.ScaleResultVO{
+		ID:        scl.ID,
+		ScaleCode: sclScaleCode,
+		ScaleName: findBuiltinScale(sclScaleCode).Name,
+		StudentID: scl.StudentID,
+		TestDate:  scl.TestDate,
+		RawScore:  scl.TotalScore,
+		Score:     scl.TotalScore,
+		Level:     record.Analysis.Level,
+		Result:    record.HealthStatus,
+		Subscores: make([]models.ScaleSubscore, 0, len(sclFactors)),
+		Flags:     []models.ScaleFlag{},
+		Invalid:   scl.Invalid,
+		Validity:  record.Validity,
+	}
+	for _, factor := range sclFactors {
+		result.Subscores = append(result.Subscores, models.ScaleSubscore{
+			Key:   factor.Key,
+			Name:  factor.Name,
+			Score: roundIndex(float64(*factor.Field(&scl))),
+		})
+	}
+	return result, nil
+}
@@ -0,0 +1,210 @@
+package service
+
+import "mental/models"
+
+// sclScaleCode SCL-90 在量表引擎中的编码，其作答与结果仍保存在 scl 表
+const sclScaleCode = "scl90"
+
+// frequencyOptions PHQ-9、GAD-7 通用选项（过去两周）
+var frequencyOptions = []models.ScaleOption{
+	{Score: 0, Label: "完全不会"},
+	{Score: 1, Label: "好几天"},
+	{Score: 2, Label: "一半以上的天数"},
+	{Score: 3, Label: "几乎每天"},
+}
+
+// zungOptions SDS、SAS 通用选项（过去一周）
+var zungOptions = []models.ScaleOption{
+	{Score: 1, Label: "没有或很少时间"},
+	{Score: 2, Label: "小部分时间"},
+	{Score: 3, Label: "相当多时间"},
+	{Score: 4, Label: "绝大部分或全部时间"},
+}
+
+// scaleItems 按题干顺序生成题目，reverse 为反向计分的题号
+func scaleItems(texts []string, reverse ...int) []models.ScaleItem {
+	reversed := make(map[int]bool, len(reverse))
+	for _, no := range reverse {
+		reversed[no] = true
+	}
+	items := make([]models.ScaleItem, 0, len(texts))
+	for i, text := range texts {
+		items = append(items, models.ScaleItem{No: i + 1, Text: text, Reverse: reversed[i+1]})
+	}
+	return items
+}
+
+// builtinScales 内置量表，顺序即列表顺序
+var builtinScales = []*scaleDef{
+	{
+		Scale: models.Scale{Code: sclScaleCode, Name: "症状自评量表（SCL-90）", Description: "90 题，1-5 级评分，结果按当前启用的解释规则集判断", Builtin: true},
+		Def: models.ScaleDefinition{
+			Instruction: "以下列出了有些人可能会有的问题，请仔细阅读每一条，然后根据最近一星期以内下列问题影响您或使您感到苦恼的程度，选择最合适的一项。",
+			Options: []models.ScaleOption{
+				{Score: 1, Label: "没有"},
+				{Score: 2, Label: "很轻"},
+				{Score: 3, Label: "中等"},
+				{Score: 4, Label: "偏重"},
+				{Score: 5, Label: "严重"},
+			},
+			Items:        scaleItems(sclItemTexts),
+			Subscales:    sclSubscales(),
+			Scoring:      models.ScaleScoring{Method: models.ScaleMethodSum},
+			NormalResult: "心理状态基本正常",
+			TimeLimit:    60,
+			ConsistencyPairs: []models.ScaleConsistencyPair{
+				{A: 23, B: 72, MaxDiff: 2}, // 突然感到害怕 / 一阵阵恐惧或惊恐
+				{A: 44, B: 66, MaxDiff: 2}, // 难以入睡 / 睡得不稳不深
+				{A: 29, B: 77, MaxDiff: 2}, // 感到孤独 / 和别人在一起也感到孤单
+				{A: 24, B: 81, MaxDiff: 2}, // 不能控制地大发脾气 / 大叫或摔东西
+				{A: 18, B: 83, MaxDiff: 2}, // 大多数人都不可信任 / 别人想占您的便宜
+			},
+		},
+	},
+	{
+		Scale: models.Scale{Code: "phq9", Name: "患者健康问卷抑郁量表（PHQ-9）", Description: "9 题，0-3 级评分，总分 0-27", Builtin: true},
+		Def: models.ScaleDefinition{
+			Instruction: "在过去两周里，你生活中以下症状出现的频率有多少？",
+			Options:     frequencyOptions,
+			Items: scaleItems([]string{
+				"做事时提不起劲或没有兴趣",
+				"感到心情低落、沮丧或绝望",
+				"入睡困难、睡不安稳或睡眠过多",
+				"感觉疲倦或没有活力",
+				"食欲不振或吃太多",
+				"觉得自己很糟，或觉得自己很失败，或让自己或家人失望",
+				"对事物专注有困难，例如阅读报纸或看电视时",
+				"动作或说话速度缓慢到别人已经觉察，或正好相反——烦躁或坐立不安、动来动去的情况更胜于平常",
+				"有不如死掉或用某种方式伤害自己的念头",
+			}),
+			Scoring: models.ScaleScoring{Method: models.ScaleMethodSum},
+			Cutoffs: []models.ScaleCutoff{
+				{Min: 20, Level: models.SCLLevelSevere, Result: "重度抑郁"},
+				{Min: 15, Level: models.SCLLevelSevere, Result: "中重度抑郁"},
+				{Min: 10, Level: models.SCLLevelModerate, Result: "中度抑郁"},
+				{Min: 5, Level: models.SCLLevelMild, Result: "轻度抑郁"},
+			},
+			NormalResult: "无明显抑郁症状",
+			TimeLimit:    15,
+			ItemFlags: []models.ScaleItemFlag{
+				{Item: 9, Min: 1, Level: models.SCLLevelSevere, Message: "存在自伤或自杀念头，需立即评估"},
+			},
+		},
+	},
+	{
+		Scale: models.Scale{Code: "gad7", Name: "广泛性焦虑量表（GAD-7）", Description: "7 题，0-3 级评分，总分 0-21", Builtin: true},
+		Def: models.ScaleDefinition{
+			Instruction: "在过去两周里，你生活中以下症状出现的频率有多少？",
+			Options:     frequencyOptions,
+			Items: scaleItems([]string{
+				"感觉紧张、焦虑或急切",
+				"不能够停止或控制担忧",
+				"对各种各样的事情担忧过多",
+				"很难放松下来",
+				"由于不安而无法静坐",
+				"变得容易烦恼或急躁",
+				"感到似乎将有可怕的事情发生而害怕",
+			}),
+			Scoring: models.ScaleScoring{Method: models.ScaleMethodSum},
+			Cutoffs: []models.ScaleCutoff{
+				{Min: 15, Level: models.SCLLevelSevere, Result: "重度焦虑"},
+				{Min: 10, Level: models.SCLLevelModerate, Result: "中度焦虑"},
+				{Min: 5, Level: models.SCLLevelMild, Result: "轻度焦虑"},
+			},
+			NormalResult: "无明显焦虑症状",
+			TimeLimit:    15,
+		},
+	},
+	{
+		Scale: models.Scale{Code: "sds", Name: "抑郁自评量表（SDS）", Description: "20 题，1-4 级评分，标准分 = 粗分 × 1.25 取整", Builtin: true},
+		Def: models.ScaleDefinition{
+			Instruction: "下面有二十条文字，请仔细阅读每一条，然后根据您最近一星期的实际情况选择最合适的一项。",
+			Options:     zungOptions,
+			Items: scaleItems([]string{
+				"我觉得闷闷不乐，情绪低沉",
+				"我觉得一天之中早晨最好",
+				"我一阵阵哭出来或觉得想哭",
+				"我晚上睡眠不好",
+				"我吃得跟平常一样多",
+				"我与异性密切接触时和以往一样感到愉快",
+				"我发觉我的体重在下降",
+				"我有便秘的苦恼",
+				"我心跳比平常快",
+				"我无缘无故地感到疲乏",
+				"我的头脑跟平常一样清楚",
+				"我觉得经常做的事情并没有困难",
+				"我觉得不安而平静不下来",
+				"我对将来抱有希望",
+				"我比平常容易生气激动",
+				"我觉得作出决定是容易的",
+				"我觉得自己是个有用的人，有人需要我",
+				"我的生活过得很有意思",
+				"我认为如果我死了，别人会生活得好些",
+				"平常感兴趣的事我仍然照样感兴趣",
+			}, 2, 5, 6, 11, 12, 14, 16, 17, 18, 20),
+			Scoring: models.ScaleScoring{Method: models.ScaleMethodSum, Multiplier: 1.25, Round: models.ScaleRoundFloor},
+			Cutoffs: []models.ScaleCutoff{
+				{Min: 73, Level: models.SCLLevelSevere, Result: "重度抑郁"},
+				{Min: 63, Level: models.SCLLevelModerate, Result: "中度抑郁"},
+				{Min: 53, Level: models.SCLLevelMild, Result: "轻度抑郁"},
+			},
+			NormalResult: "无明显抑郁症状",
+			TimeLimit:    20,
+			ConsistencyPairs: []models.ScaleConsistencyPair{
+				{A: 1, B: 18, MaxDiff: 2},  // 闷闷不乐 / 生活过得很有意思（反向）
+				{A: 19, B: 17, MaxDiff: 2}, // 死了别人会生活得好些 / 自己是个有用的人（反向）
+				{A: 10, B: 11, MaxDiff: 2}, // 无故感到疲乏 / 头脑跟平常一样清楚（反向）
+			},
+		},
+	},
+	{
+		Scale: models.Scale{Code: "sas", Name: "焦虑自评量表（SAS）", Description: "20 题，1-4 级评分，标准分 = 粗分 × 1.25 取整", Builtin: true},
+		Def: models.ScaleDefinition{
+			Instruction: "下面有二十条文字，请仔细阅读每一条，然后根据您最近一星期的实际情况选择最合适的一项。",
+			Options:     zungOptions,
+			Items: scaleItems([]string{
+				"我觉得比平常容易紧张和着急",
+				"我无缘无故地感到害怕",
+				"我容易心里烦乱或觉得惊恐",
+				"我觉得我可能将要发疯",
+				"我觉得一切都很好，也不会发生什么不幸",
+				"我手脚发抖打颤",
+				"我因为头痛、颈痛和背痛而苦恼",
+				"我感觉容易衰弱和疲乏",
+				"我觉得心平气和，并且容易安静坐着",
+				"我觉得心跳得很快",
+				"我因为一阵阵头晕而苦恼",
+				"我有晕倒发作或觉得要晕倒似的",
+				"我呼气吸气都感到很容易",
+				"我手脚麻木和刺痛",
+				"我因为胃痛和消化不良而苦恼",
+				"我常常要小便",
+				"我的手常常是干燥温暖的",
+				"我脸红发热",
+				"我容易入睡并且一夜睡得很好",
+				"我做恶梦",
+			}, 5, 9, 13, 17, 19),
+			Scoring: models.ScaleScoring{Method: models.ScaleMethodSum, Multiplier: 1.25, Round: models.ScaleRoundFloor},
+			Cutoffs: []models.ScaleCutoff{
+				{Min: 70, Level: models.SCLLevelSevere, Result: "重度焦虑"},
+				{Min: 60, Level: models.SCLLevelModerate, Result: "中度焦虑"},
+				{Min: 50, Level: models.SCLLevelMild, Result: "轻度焦虑"},
+			},
+			NormalResult: "无明显焦虑症状",
+			TimeLimit:    20,
+			ConsistencyPairs: []models.ScaleConsistencyPair{
+				{A: 1, B: 9, MaxDiff: 2}, // 容易紧张和着急 / 心平气和（反向）
+				{A: 2, B: 5, MaxDiff: 2}, // 无缘无故地感到害怕 / 一切都很好（反向）
+			},
+		},
+	},
+}
+
+// sclSubscales 由 SCL-90 因子表生成分量表定义
+func sclSubscales() []models.ScaleSubscale {
+	subscales := make([]models.ScaleSubscale, 0, len(sclFactors))
+	for _, factor := range sclFactors {
+		subscales = append(subscales, models.ScaleSubscale{Key: factor.Key, Name: factor.Name, Items: factor.Items, Method: models.ScaleMethodMean})
+	}
+	return subscales
+}
@@ -0,0 +1,64 @@
+package service
+
+import (
+	"mental/config"
+	"mental/models"
+	"mental/vo"
+	"testing"
+	"time"
+)
+
+func TestSubmitPHQ9ItemNineRaisesAlert(t *testing.T) {
+	setupTestEnv(t)
+	studentId := int64(100)
+	submission := &vo.ScaleSubmission{
+		ScaleCode: "phq9",
+		TestDate:  models.CustomTime(time.Now()),
+		Answers:   []int{0, 0, 0, 0, 0, 0, 0, 0, 1},
+		StudentID: &studentId,
+		Name:      "测试学生",
+	}
+	result, err := NewScaleService().Submit(submission)
+	if err != nil {
+		t.Fatalf("提交作答失败: %v", err)
+	}
+	if len(result.Flags) != 1 {
+		t.Fatalf("第 9 题作答大于 0 应命中单题预警，实际为 %d 项", len(result.Flags))
+	}
+
+	var alerts []models.Alert
+	if err := config.DB.Find(&alerts).Error; err != nil {
+		t.Fatalf("查询预警失败: %v", err)
+	}
+	if len(alerts) != 1 {
+		t.Fatalf("应生成 1 条预警，实际为 %d 条", len(alerts))
+	}
+	alert := alerts[0]
+	if alert.ResponseID == nil || *alert.ResponseID != result.ID || alert.SCLID != 0 {
+		t.Errorf("预警应关联作答记录 %d: %+v", result.ID, alert)
+	}
+	if alert.Level != models.SCLLevelSevere || alert.Status != models.AlertStatusNew || alert.StudentID == nil || *alert.StudentID != studentId {
+		t.Errorf("预警应为学生 %d 的新建重度预警: %+v", studentId, alert)
+	}
+}
+
+func TestSubmitPHQ9WithoutItemNineRaisesNoAlert(t *testing.T) {
+	setupTestEnv(t)
+	studentId := int64(100)
+	submission := &vo.ScaleSubmission{
+		ScaleCode: "phq9",
+		TestDate:  models.CustomTime(time.Now()),
+		Answers:   []int{3, 3, 3, 3, 3, 3, 3, 3, 0},
+		StudentID: &studentId,
+	}
+	if _, err := NewScaleService().Submit(submission); err != nil {
+		t.Fatalf("提交作答失败: %v", err)
+	}
+	var count int64
+	if err := config.DB.Model(&models.Alert{}).Count(&count).Error; err != nil {
+		t.Fatalf("查询预警失败: %v", err)
+	}
+	if count != 0 {
+		t.Errorf("未命中单题预警时不应生成预警，实际为 %d 条", count)
+	}
+}
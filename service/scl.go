@@ -3,11 +3,18 @@ package service
 import (
 	"errors"
 	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
 	"mental/config"
 	"mental/dao"
 	"mental/models"
 	"mental/vo"
-	"strings"
+	"time"
+)
+
+// SCL 记录访问错误，控制器据此区分 404 与 403
+var (
+	ErrSCLNotFound  = errors.New("SCL记录不存在")
+	ErrSCLForbidden = errors.New("无权操作该SCL记录")
 )
 
 // SCLService 结构体
@@ -24,81 +31,215 @@ func NewSCLService() *SCLService {
 
 // CreateSCL 插入 SCL 记录并进行数据校验
 func (sclService *SCLService) CreateSCL(scl *models.SCL) error {
+	scl.ImportBatchID = nil // 导入批次只由 Excel 导入记录
+	if err := sclService.validateSCL(scl); err != nil {
+		return err
+	}
+
+	// 存入数据库
+	return saveSCL(scl)
+}
+
+// validateSCL 校验必填字段、测评日期和因子分范围，并计算派生字段，不落库；Excel 导入与预览使用同一校验
+func (sclService *SCLService) validateSCL(scl *models.SCL) error {
 	// **手动检查某些必须字段**
 	if scl.Name == "" {
-		return errors.New("姓名不能为空")
+		return &sclFieldError{Field: "name", Err: errors.New("姓名不能为空")}
 	}
 	if scl.Age <= 0 {
-		return errors.New("年龄必须为正整数")
+		return &sclFieldError{Field: "age", Err: errors.New("年龄必须为正整数")}
 	}
 	if scl.Gender != 0 && scl.Gender != 1 {
-		return errors.New("性别只能是 0（女）或 1（男）")
+		return &sclFieldError{Field: "gender", Err: errors.New("性别只能是 0（女）或 1（男）")}
+	}
+	if time.Time(scl.TestDate).After(time.Now()) {
+		return &sclFieldError{Field: "test_date", Err: errors.New("测评日期不能晚于今天")}
+	}
+
+	// 计算派生字段（提交原始作答时，因子分以作答为准重新计算）
+	if err := prepareSCL(scl); err != nil {
+		return err
 	}
 
 	// *校验评分字段范围
-	fields := []struct {
-		value float32
-		name  string
-	}{
-		{scl.Somatization, "躯体化"},
-		{scl.Obsession, "强迫症状"},
-		{scl.Interpersonal, "人际关系敏感"},
-		{scl.Depression, "抑郁"},
-		{scl.Anxiety, "焦虑"},
-		{scl.Hostility, "敌对"},
-		{scl.Phobia, "恐怖"},
-		{scl.Paranoia, "偏执"},
-		{scl.Psychoticism, "精神病性"},
-		{scl.Other, "其他"},
-	}
-
-	for _, field := range fields {
-		if field.value < 0 || field.value > 5 {
-			return errors.New(field.name + " 分数必须在 0.0 - 5.0 之间")
+	for _, factor := range sclFactors {
+		if value := *factor.Field(scl); value < 0 || value > 5 {
+			return &sclFieldError{Field: factor.Key, Err: errors.New(factor.Name + " 分数必须在 0.0 - 5.0 之间")}
 		}
 	}
 
 	// 使用 Validator 自动校验
-	err := sclService.Validator.Struct(scl)
+	return sclService.Validator.Struct(scl)
+}
+
+// sclFieldError 指明出错字段的校验错误，Excel 导入据此定位出错的单元格；Item 不为 0 时表示第 Item 题的作答
+type sclFieldError struct {
+	Field string
+	Item  int
+	Err   error
+}
+
+func (e *sclFieldError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *sclFieldError) Unwrap() error {
+	return e.Err
+}
+
+// prepareSCL 保存前的统一处理：计算派生字段和作答有效性，记录生成结论所用的规则集及总体等级，并关联筛查批次
+func prepareSCL(scl *models.SCL) error {
+	if err := applySCLScoring(scl); err != nil {
+		return err
+	}
+	scl.Invalid, scl.Validity = false, "" // 仅逐题作答的记录做有效性检查，不接受客户端提交的标记
+	if len(scl.Items) > 0 {
+		scl.Invalid, scl.Validity = encodeValidity(assessValidity(findBuiltinScale(sclScaleCode), scl.Items, scl.DurationSeconds))
+	}
+	orgId, err := studentOrgID(scl.StudentID)
 	if err != nil {
 		return err
 	}
-
-	// 存入数据库
-	sclDao := dao.NewSCLDao(config.DB)
-	if err := sclDao.Save(scl); err != nil {
+	rules, err := activeSCLRules(orgId)
+	if err != nil {
 		return err
 	}
+	scl.RuleSetID = rules.ID
+	scl.Level = analyzeSCL(*scl, rules).Level
+	scl.CampaignID, err = matchCampaign(sclScaleCode, scl.StudentID, scl.TestDate) // 筛查批次按开放窗口和班级关联，不接受客户端提交的值
+	return err
+}
 
+// saveSCL 在同一事务中保存测评记录及其原始作答，并评估高风险预警；保存后使群体统计缓存失效
+func saveSCL(scl *models.SCL) error {
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		return saveSCLTx(tx, scl)
+	})
+	if err != nil {
+		return err
+	}
+	invalidateCohortStats()
 	return nil
 }
 
-// SelectAllByUserId 根据用户id查询历史所有的评测记录返回
-func (s *SCLService) SelectAllByUserId(userId int64) (*vo.UserSCLResult, error) {
+// saveSCLTx 在调用方的事务 tx 中保存测评记录及其原始作答，并评估高风险预警；群体统计缓存由调用方在提交后使其失效
+func saveSCLTx(tx *gorm.DB, scl *models.SCL) error {
+	if err := dao.NewSCLDao(tx).Save(scl); err != nil {
+		return err
+	}
+	if err := dao.NewSCLAnswerDao(tx).SaveBatch(buildSCLAnswers(scl.ID, scl.Items)); err != nil {
+		return err
+	}
+	return raiseSCLAlert(tx, scl)
+}
+
+// findSCLInScope 查询数据范围内的测评记录：记录不存在返回 ErrSCLNotFound，存在但不在范围内（非本人且不在负责的组织内）返回 ErrSCLForbidden
+func findSCLInScope(db *gorm.DB, id int64, scope *dao.DataScope) (*models.SCL, error) {
+	sclDao := dao.NewSCLDao(db)
+	scl, err := sclDao.FindByID(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrSCLNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if _, err := sclDao.WithScope(scope).FindByID(id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSCLForbidden
+		}
+		return nil, err
+	}
+	return scl, nil
+}
+
+// SelectAnswers 查询数据范围内指定测评记录的逐题原始作答
+func (sclService *SCLService) SelectAnswers(sclId int64, scope *dao.DataScope) ([]models.SCLAnswer, error) {
+	if _, err := findSCLInScope(config.DB, sclId, scope); err != nil {
+		return nil, err
+	}
+	answerDao := dao.NewSCLAnswerDao(config.DB)
+	return answerDao.FindBySCLID(sclId)
+}
+
+// buildSCLRecordVO 构造单条记录的分析结果：按记录生成时的规则集判断健康状态，并与常模比较
+func buildSCLRecordVO(scl models.SCL, norm *sclNorm) (vo.SCLRecordAnalysisVO, error) {
+	ensureSCLIndices(&scl)
+	rules, err := loadSCLRules(scl.RuleSetID)
+	if err != nil {
+		return vo.SCLRecordAnalysisVO{}, err
+	}
+	validity, err := decodeValidity(scl.Validity)
+	if err != nil {
+		return vo.SCLRecordAnalysisVO{}, err
+	}
+	analysis := analyzeSCL(scl, rules)
+	record := vo.SCLRecordAnalysisVO{
+		SCL:          scl,
+		HealthStatus: analysis.HealthStatus,
+		Analysis:     analysis.SCLAnalysis,
+		RuleSet:      rules.Info(),
+		Validity:     validity,
+	}
+	if norm != nil {
+		normResult := norm.score(scl)
+		record.Norm = &normResult
+	}
+	return record, nil
+}
+
+// SelectAllByUserId 分页查询用户符合条件的评测记录，normCode 为比较的常模组编码（为空时使用默认常模）
+// 整体心理状态按用户全部记录计算，不受筛选和分页影响；无效作答的记录照常返回并标记 invalid，默认不计入整体心理状态，includeInvalid 为 true 时计入
+func (s *SCLService) SelectAllByUserId(userId int64, query vo.SCLQuery, normCode string, includeInvalid bool) (*vo.UserSCLResult, error) {
+	filter, err := sclFilterOf(query)
+	if err != nil {
+		return nil, err
+	}
+	filter.StudentID = &userId
+	sort, err := sclPageOf(&query)
+	if err != nil {
+		return nil, err
+	}
 	sclDao := dao.NewSCLDao(config.DB)
 	scls, err := sclDao.SelectAllByUserId(userId)
 	if err != nil {
 		return nil, err
 	}
+	norm, err := loadNorm(normCode)
+	if err != nil {
+		return nil, err
+	}
+	page, total, err := sclDao.Page(filter, sort, query.Page, query.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	results, err := buildSCLRecordVOs(page, norm)
+	if err != nil {
+		return nil, err
+	}
 
 	var (
-		results          []vo.SCLRecordAnalysisVO
 		recordCount      int
+		excludedCount    int
 		sumTotalScore    float64
 		sumPositiveItems float64
+		sumNegativeItems float64
+		sumGSI           float64
+		sumPSDI          float64
 		sumFactors       = make([]float32, 10) // 因子总和
 	)
 
 	for _, scl := range scls {
-		// 每条记录计算单独健康状态
-		healthStatus := calculateHealthStatus(scl)
-		results = append(results, vo.SCLRecordAnalysisVO{
-			SCL:          scl,
-			HealthStatus: healthStatus,
-		})
+		if scl.Invalid && !includeInvalid {
+			excludedCount++
+			continue
+		}
+		ensureSCLIndices(&scl) // 补算全局指数
 
 		sumTotalScore += scl.TotalScore
 		sumPositiveItems += scl.PositiveItems
+		sumNegativeItems += scl.NegativeItems
+		sumGSI += scl.GSI
+		sumPSDI += scl.PSDI
 		recordCount++
 
 		// 累加每个因子
@@ -114,8 +255,19 @@ func (s *SCLService) SelectAllByUserId(userId int64) (*vo.UserSCLResult, error)
 		sumFactors[9] += scl.Other
 	}
 
-	// 构造“平均测评”
-	var overallHealth string
+	// 构造“平均测评”，使用当前启用的规则集
+	var (
+		overallHealth   string
+		overallAnalysis *vo.SCLAnalysis
+	)
+	orgId, err := studentOrgID(&userId)
+	if err != nil {
+		return nil, err
+	}
+	overallRules, err := activeSCLRules(orgId)
+	if err != nil {
+		return nil, err
+	}
 	if recordCount > 0 {
 		avgFactors := make([]float32, 10)
 		for i := 0; i < 10; i++ {
@@ -124,6 +276,9 @@ func (s *SCLService) SelectAllByUserId(userId int64) (*vo.UserSCLResult, error)
 		avgSCL := models.SCL{
 			TotalScore:    sumTotalScore / float64(recordCount),
 			PositiveItems: sumPositiveItems / float64(recordCount),
+			NegativeItems: sumNegativeItems / float64(recordCount),
+			GSI:           roundIndex(sumGSI / float64(recordCount)),
+			PSDI:          roundIndex(sumPSDI / float64(recordCount)),
 			Somatization:  avgFactors[0],
 			Obsession:     avgFactors[1],
 			Interpersonal: avgFactors[2],
@@ -136,87 +291,152 @@ func (s *SCLService) SelectAllByUserId(userId int64) (*vo.UserSCLResult, error)
 			Other:         avgFactors[9],
 		}
 		// 计算总体心理状态
-		overallHealth = calculateHealthStatus(avgSCL)
+		analysis := analyzeSCL(avgSCL, overallRules)
+		overallHealth = analysis.HealthStatus
+		overallAnalysis = &analysis.SCLAnalysis
 	}
 
 	return &vo.UserSCLResult{
 		Records:           results,
 		UserOverallHealth: overallHealth,
+		OverallAnalysis:   overallAnalysis,
+		RuleSet:           overallRules.Info(),
+		ExcludedCount:     excludedCount,
+		Pagination:        vo.Pagination{Page: query.Page, PageSize: query.PageSize, Total: total},
 	}, nil
 }
 
-// SelectAll 查询所有用户的scl数据
-func (sclService *SCLService) SelectAll() ([]models.SCL, error) {
-	sclDao := dao.NewSCLDao(config.DB)
-	return sclDao.SelectAll()
-}
-
-// calculateHealthStatus 综合判断心理健康状态，优先级：总分 > 阳性项目数 > 因子得分
-func calculateHealthStatus(scl models.SCL) string {
-	// 总分
-	totalScore := scl.TotalScore
-	// 阳性项目数
-	positiveCount := scl.PositiveItems
-	// 因子映射
-	factorMap := map[string]float32{
-		"躯体化":    scl.Somatization,
-		"强迫症状":   scl.Obsession,
-		"人际关系敏感": scl.Interpersonal,
-		"抑郁":     scl.Depression,
-		"焦虑":     scl.Anxiety,
-		"敌对":     scl.Hostility,
-		"恐怖":     scl.Phobia,
-		"偏执":     scl.Paranoia,
-		"精神病性":   scl.Psychoticism,
-		"其他":     scl.Other,
-	}
-	// 第一优先级：总分判断
-	switch {
-	case totalScore > 200:
-		return "心理状态较差"
-	case totalScore > 160:
-		return "存在明显心理困扰"
-	}
-	// 第二优先级：阳性项目数判断
-	if positiveCount > 43 {
-		return "需关注，阳性项目数较多"
-	}
-	// 第三优先级：因子均值判断（定位具体问题方向）
-	var (
-		severeFactors   []string
-		moderateFactors []string
-		mildFactors     []string
-	)
-	for name, score := range factorMap {
-		switch {
-		case score >= 4:
-			severeFactors = append(severeFactors, name)
-		case score >= 3:
-			moderateFactors = append(moderateFactors, name)
-		case score >= 2:
-			mildFactors = append(mildFactors, name)
-		}
+// SelectAll 分页查询数据范围内符合条件的scl数据，附带每条记录的分析结果和常模比较
+func (sclService *SCLService) SelectAll(query vo.SCLQuery, normCode string, scope *dao.DataScope) (*vo.SCLPage, error) {
+	filter, err := sclFilterOf(query)
+	if err != nil {
+		return nil, err
 	}
-	if len(severeFactors) > 0 {
-		return "重度" + strings.Join(severeFactors, "、")
+	sort, err := sclPageOf(&query)
+	if err != nil {
+		return nil, err
 	}
-	if len(moderateFactors) > 0 {
-		return "中度" + strings.Join(moderateFactors, "、")
+	norm, err := loadNorm(normCode)
+	if err != nil {
+		return nil, err
 	}
-	if len(mildFactors) > 0 {
-		return "轻度" + strings.Join(mildFactors, "、")
+	scls, total, err := dao.NewSCLDao(config.DB).WithScope(scope).Page(filter, sort, query.Page, query.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	results, err := buildSCLRecordVOs(scls, norm)
+	if err != nil {
+		return nil, err
 	}
-	return "心理状态基本正常"
+	return &vo.SCLPage{
+		Records:    results,
+		Pagination: vo.Pagination{Page: query.Page, PageSize: query.PageSize, Total: total},
+	}, nil
 }
 
-// DeleteSCL 删除指定SCL记录
-func (sclService *SCLService) DeleteSCL(id int64) error {
+// buildSCLRecordVOs 逐条生成记录的分析结果，每条记录使用其生成时记录的规则集
+func buildSCLRecordVOs(scls []models.SCL, norm *sclNorm) ([]vo.SCLRecordAnalysisVO, error) {
+	results := make([]vo.SCLRecordAnalysisVO, 0, len(scls))
+	for _, scl := range scls {
+		record, err := buildSCLRecordVO(scl, norm)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, record)
+	}
+	return results, nil
+}
+
+// BackfillSCLLevels 为尚未记录总体等级的历史记录按其所用规则集补算等级，启动时调用
+func BackfillSCLLevels() error {
 	sclDao := dao.NewSCLDao(config.DB)
-	return sclDao.DeleteByID(id)
+	var afterId int64
+	for {
+		scls, err := sclDao.SelectWithoutLevel(afterId, 500)
+		if err != nil {
+			return err
+		}
+		if len(scls) == 0 {
+			return nil
+		}
+		for _, scl := range scls {
+			afterId = scl.ID
+			ensureSCLIndices(&scl)
+			rules, err := loadSCLRules(scl.RuleSetID)
+			if err != nil {
+				return err
+			}
+			if err := sclDao.UpdateLevel(scl.ID, analyzeSCL(scl, rules).Level); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// BackfillSCLEstimated 将没有逐题作答的历史记录标记为全局指数由因子分估算，启动时调用
+func BackfillSCLEstimated() error {
+	_, err := dao.NewSCLDao(config.DB).MarkEstimatedWithoutAnswers()
+	return err
 }
 
-// UpdateSCL 更新指定SCL记录
-func (s *SCLService) UpdateSCL(scl *models.SCL) error {
-	dao := dao.NewSCLDao(config.DB)
-	return dao.UpdateByID(scl.ID, scl)
+// calculateHealthStatus 按规则集综合判断心理健康状态，优先级：总分 > 阳性项目数 > 因子得分
+func calculateHealthStatus(scl models.SCL, rules *sclRules) string {
+	return analyzeSCL(scl, rules).HealthStatus
+}
+
+// DeleteSCL 删除数据范围内的指定SCL记录，并在同一事务中关闭其未关闭的预警
+func (sclService *SCLService) DeleteSCL(id int64, scope *dao.DataScope) error {
+	if _, err := findSCLInScope(config.DB, id, scope); err != nil {
+		return err
+	}
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := closeSCLAlerts(tx, []int64{id}, "测评记录已删除，自动关闭"); err != nil {
+			return err
+		}
+		return dao.NewSCLDao(tx).WithScope(scope).DeleteByID(id)
+	})
+	if err != nil {
+		return err
+	}
+	invalidateCohortStats()
+	return nil
+}
+
+// UpdateSCL 更新数据范围内的指定SCL记录，按新增时的规则校验并重新计算总分、阳性项目数等派生字段，并重新评估高风险预警
+// 记录的所属学生不可修改：请求中的 student_id 为空时沿用原值，与原值不同时拒绝
+// 只提交因子分时，原有的逐题作答和有效性标记随之清除，记录按因子分记录处理
+func (s *SCLService) UpdateSCL(scl *models.SCL, scope *dao.DataScope) error {
+	existing, err := findSCLInScope(config.DB, scl.ID, scope)
+	if err != nil {
+		return err
+	}
+	if scl.StudentID != nil && (existing.StudentID == nil || *scl.StudentID != *existing.StudentID) {
+		return errors.New("不能修改测评记录的所属学生")
+	}
+	scl.StudentID = existing.StudentID
+	if err := s.validateSCL(scl); err != nil {
+		return err
+	}
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := dao.NewSCLDao(tx).WithScope(scope).UpdateByID(scl.ID, scl); err != nil {
+			return err
+		}
+		if err := raiseSCLAlert(tx, scl); err != nil {
+			return err
+		}
+		// 原有作答和有效性指标与新的因子分不再对应：提交了原始作答时整体替换，只提交因子分时删除原有作答并清除有效性标记
+		if err := dao.NewSCLDao(tx).UpdateValidity(scl.ID, scl.Invalid, scl.Validity); err != nil {
+			return err
+		}
+		answerDao := dao.NewSCLAnswerDao(tx)
+		if err := answerDao.DeleteBySCLID(scl.ID); err != nil {
+			return err
+		}
+		return answerDao.SaveBatch(buildSCLAnswers(scl.ID, scl.Items))
+	})
+	if err != nil {
+		return err
+	}
+	invalidateCohortStats()
+	return nil
 }
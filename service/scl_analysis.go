@@ -0,0 +1,131 @@
+package service
+
+import (
+	"fmt"
+	"mental/models"
+	"mental/vo"
+	"strings"
+)
+
+// 命中规则类型
+const (
+	sclRuleTypeTotal    = "total"    // 总分规则
+	sclRuleTypePositive = "positive" // 阳性项目数规则
+	sclRuleTypeFactor   = "factor"   // 因子分级规则
+)
+
+// sclLevelRank 等级严重程度，数值越大越严重
+func sclLevelRank(level string) int {
+	switch level {
+	case models.SCLLevelMild:
+		return 1
+	case models.SCLLevelModerate:
+		return 2
+	case models.SCLLevelSevere:
+		return 3
+	}
+	return 0
+}
+
+// criterionOf 比较依据为空时按原始分处理
+func criterionOf(criterion string) string {
+	if criterion == "" {
+		return models.SCLCriterionRaw
+	}
+	return criterion
+}
+
+// sclAnalysis 规则评估的完整结果：结构化分析与兼容旧版的结论字符串
+type sclAnalysis struct {
+	vo.SCLAnalysis
+	HealthStatus string // 旧版结论字符串（按 总分 > 阳性项目数 > 因子 的优先级取第一个命中）
+}
+
+// analyzeSCL 按规则集评估一条测评记录
+// 命中规则按 总分、阳性项目数、因子（固定因子顺序）排列；总体等级取所有命中规则中最严重的等级
+// 记录的全局指数为估算值时，总分、阳性项目数规则照常判断，但命中结果和摘要中标明为估算
+func analyzeSCL(scl models.SCL, rules *sclRules) sclAnalysis {
+	content := rules.Content
+	analysis := vo.SCLAnalysis{
+		Level:          models.SCLLevelNormal,
+		Factors:        make([]vo.SCLFactorResult, 0, len(sclFactors)),
+		TriggeredRules: []vo.SCLTriggeredRule{},
+	}
+	trigger := func(rule vo.SCLTriggeredRule) {
+		analysis.TriggeredRules = append(analysis.TriggeredRules, rule)
+		if sclLevelRank(rule.Level) > sclLevelRank(analysis.Level) {
+			analysis.Level = rule.Level
+		}
+	}
+
+	// 总分、阳性项目数各取第一个命中的规则
+	var healthStatus string
+	thresholds := []struct {
+		Type  string
+		Key   string
+		Rules []models.SCLThresholdRule
+	}{
+		{sclRuleTypeTotal, "total_score", content.TotalRules},
+		{sclRuleTypePositive, "positive_items", content.PositiveRules},
+	}
+	for _, group := range thresholds {
+		for _, rule := range group.Rules {
+			value, ok := rules.value(scl, group.Key, rule.Criterion)
+			if ok && value > rule.Min {
+				trigger(vo.SCLTriggeredRule{Type: group.Type, Criterion: criterionOf(rule.Criterion), Threshold: rule.Min, Value: roundIndex(value), Level: rule.Level, Result: rule.Result, Estimated: scl.IndicesEstimated})
+				if healthStatus == "" {
+					healthStatus = rule.Result
+				}
+				break
+			}
+		}
+	}
+
+	// 因子分级，按固定因子顺序输出，保证结果稳定
+	levelFactors := make([][]string, len(content.FactorLevels))
+	for _, factor := range sclFactors {
+		score := *factor.Field(&scl)
+		result := vo.SCLFactorResult{Key: factor.Key, Name: factor.Name, Score: score, Level: models.SCLLevelNormal}
+		for i, level := range content.FactorLevels {
+			value, ok := rules.value(scl, factor.Key, level.Criterion)
+			if ok && value >= level.Min {
+				result.Level = level.Level
+				levelFactors[i] = append(levelFactors[i], factor.Name)
+				trigger(vo.SCLTriggeredRule{Type: sclRuleTypeFactor, Factor: factor.Key, Criterion: criterionOf(level.Criterion), Threshold: level.Min, Value: roundIndex(value), Level: level.Level, Result: level.Label + factor.Name})
+				break
+			}
+		}
+		analysis.Factors = append(analysis.Factors, result)
+	}
+
+	// 可读摘要：总分/阳性项目数结论 + 各等级因子
+	var summary []string
+	for _, rule := range analysis.TriggeredRules {
+		if rule.Type != sclRuleTypeFactor {
+			summary = append(summary, rule.Result)
+		}
+	}
+	for i, level := range content.FactorLevels {
+		if len(levelFactors[i]) == 0 {
+			continue
+		}
+		group := level.Label + strings.Join(levelFactors[i], "、")
+		summary = append(summary, group)
+		if healthStatus == "" {
+			healthStatus = group // 旧版结论只取最高等级的因子组
+		}
+	}
+	if healthStatus == "" {
+		healthStatus = content.NormalResult
+	}
+	if len(summary) == 0 {
+		summary = append(summary, content.NormalResult)
+	}
+	if scl.IndicesEstimated {
+		analysis.Summary = fmt.Sprintf("总分约 %.0f，阳性项目约 %.0f 项（按因子分估算）：%s", scl.TotalScore, scl.PositiveItems, strings.Join(summary, "；"))
+	} else {
+		analysis.Summary = fmt.Sprintf("总分 %.0f，阳性项目 %.0f 项：%s", scl.TotalScore, scl.PositiveItems, strings.Join(summary, "；"))
+	}
+
+	return sclAnalysis{SCLAnalysis: analysis, HealthStatus: healthStatus}
+}
@@ -0,0 +1,133 @@
+package service
+
+import (
+	"mental/models"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// factorSCL 构造一条仅有因子分的记录：未指定的因子取 1 分，总分和阳性项目数按因子分估算
+func factorSCL(scores map[string]float32) models.SCL {
+	var scl models.SCL
+	for _, factor := range sclFactors {
+		*factor.Field(&scl) = 1
+		if score, ok := scores[factor.Key]; ok {
+			*factor.Field(&scl) = score
+		}
+	}
+	estimateSCLFromFactors(&scl)
+	return scl
+}
+
+func TestAnalyzeSCLIsDeterministic(t *testing.T) {
+	// 全部因子 2.5 分：总分、阳性项目数规则命中，十个因子并列为轻度
+	allFactors := make(map[string]float32, len(sclFactors))
+	allMild := make(map[string]string, len(sclFactors))
+	allNames := make([]string, 0, len(sclFactors))
+	allRuleTypes := []string{sclRuleTypeTotal, sclRuleTypePositive}
+	for _, factor := range sclFactors {
+		allFactors[factor.Key] = 2.5
+		allMild[factor.Key] = models.SCLLevelMild
+		allNames = append(allNames, factor.Name)
+		allRuleTypes = append(allRuleTypes, sclRuleTypeFactor)
+	}
+
+	cases := []struct {
+		name         string
+		scl          models.SCL
+		level        string
+		factorLevels map[string]string // 未列出的因子为 normal
+		ruleTypes    []string
+		summary      string
+		healthStatus string
+	}{
+		{
+			name:         "全部正常",
+			scl:          factorSCL(nil),
+			level:        models.SCLLevelNormal,
+			ruleTypes:    []string{},
+			summary:      "总分约 90，阳性项目约 0 项（按因子分估算）：心理状态基本正常",
+			healthStatus: "心理状态基本正常",
+		},
+		{
+			name:  "同一等级多个因子并列",
+			scl:   factorSCL(map[string]float32{"somatization": 3, "depression": 3, "anxiety": 3}),
+			level: models.SCLLevelModerate,
+			factorLevels: map[string]string{
+				"somatization": models.SCLLevelModerate,
+				"depression":   models.SCLLevelModerate,
+				"anxiety":      models.SCLLevelModerate,
+			},
+			ruleTypes:    []string{sclRuleTypeFactor, sclRuleTypeFactor, sclRuleTypeFactor},
+			summary:      "总分约 160，阳性项目约 35 项（按因子分估算）：中度躯体化、抑郁、焦虑",
+			healthStatus: "中度躯体化、抑郁、焦虑",
+		},
+		{
+			name:  "两个等级各有并列因子",
+			scl:   factorSCL(map[string]float32{"somatization": 4, "obsession": 2, "hostility": 2, "paranoia": 4}),
+			level: models.SCLLevelSevere,
+			factorLevels: map[string]string{
+				"somatization": models.SCLLevelSevere,
+				"obsession":    models.SCLLevelMild,
+				"hostility":    models.SCLLevelMild,
+				"paranoia":     models.SCLLevelSevere,
+			},
+			ruleTypes:    []string{sclRuleTypeFactor, sclRuleTypeFactor, sclRuleTypeFactor, sclRuleTypeFactor},
+			summary:      "总分约 160，阳性项目约 34 项（按因子分估算）：重度躯体化、偏执；轻度强迫症状、敌对",
+			healthStatus: "重度躯体化、偏执",
+		},
+		{
+			name:         "总分、阳性项目数与全部因子同时命中",
+			scl:          factorSCL(allFactors),
+			level:        models.SCLLevelSevere,
+			factorLevels: allMild,
+			ruleTypes:    allRuleTypes,
+			summary:      "总分约 225，阳性项目约 90 项（按因子分估算）：心理状态较差；需关注，阳性项目数较多；轻度" + strings.Join(allNames, "、"),
+			healthStatus: "心理状态较差",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			first := analyzeSCL(tc.scl, defaultSCLRules)
+			for i := 0; i < 20; i++ {
+				if again := analyzeSCL(tc.scl, defaultSCLRules); !reflect.DeepEqual(again, first) {
+					t.Fatalf("第 %d 次分析结果与首次不同:\n%+v\n%+v", i+2, again, first)
+				}
+			}
+
+			if first.Level != tc.level {
+				t.Errorf("总体等级应为 %s，实际为 %s", tc.level, first.Level)
+			}
+			if len(first.Factors) != len(sclFactors) {
+				t.Fatalf("应返回 %d 个因子，实际为 %d 个", len(sclFactors), len(first.Factors))
+			}
+			for i, factor := range first.Factors {
+				if factor.Key != sclFactors[i].Key {
+					t.Errorf("第 %d 个因子应为 %s，实际为 %s", i+1, sclFactors[i].Key, factor.Key)
+				}
+				want := models.SCLLevelNormal
+				if level, ok := tc.factorLevels[factor.Key]; ok {
+					want = level
+				}
+				if factor.Level != want {
+					t.Errorf("因子 %s 等级应为 %s，实际为 %s", factor.Key, want, factor.Level)
+				}
+			}
+			ruleTypes := make([]string, 0, len(first.TriggeredRules))
+			for _, rule := range first.TriggeredRules {
+				ruleTypes = append(ruleTypes, rule.Type)
+			}
+			if !reflect.DeepEqual(ruleTypes, tc.ruleTypes) {
+				t.Errorf("命中规则应为 %v，实际为 %v", tc.ruleTypes, ruleTypes)
+			}
+			if first.Summary != tc.summary {
+				t.Errorf("摘要应为 %q，实际为 %q", tc.summary, first.Summary)
+			}
+			if first.HealthStatus != tc.healthStatus {
+				t.Errorf("旧版结论应为 %q，实际为 %q", tc.healthStatus, first.HealthStatus)
+			}
+		})
+	}
+}
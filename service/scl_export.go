@@ -0,0 +1,165 @@
+package service
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"github.com/xuri/excelize/v2"
+	"io"
+	"mental/config"
+	"mental/dao"
+	"mental/models"
+	"mental/vo"
+	"time"
+)
+
+// 导出文件格式
+const (
+	SCLExportXLSX = "xlsx"
+	SCLExportCSV  = "csv"
+)
+
+// sclExportBatchSize 导出时每批从数据库读取的记录数
+const sclExportBatchSize = 1000
+
+// sclExportHeaders 导出的表头：前 15 列与 Excel 导入格式一致（学生ID、姓名、性别、年龄、测评日期、十个因子分），其后为计算列
+var sclExportHeaders = []string{
+	"学生ID", "姓名", "性别", "年龄", "测评日期",
+	"躯体化", "强迫症状", "人际关系敏感", "抑郁", "焦虑", "敌对", "恐怖", "偏执", "精神病性", "其他",
+	"总分", "阳性项目数", "阴性项目数", "总症状指数", "阳性症状均分", "指数来源", "等级", "健康状况", "有效性", "记录ID",
+}
+
+// SCLExport 一次测评数据导出，条件校验通过后再写出文件，便于先返回参数错误
+type SCLExport struct {
+	filter dao.SCLFilter
+	format string
+	scope  *dao.DataScope
+}
+
+// NewExport 校验筛选条件和导出格式（xlsx 或 csv，为空时为 xlsx），创建导出
+func (s *SCLService) NewExport(query vo.SCLQuery, format string, scope *dao.DataScope) (*SCLExport, error) {
+	if format == "" {
+		format = SCLExportXLSX
+	}
+	if format != SCLExportXLSX && format != SCLExportCSV {
+		return nil, errors.New("导出格式只能是 xlsx 或 csv")
+	}
+	filter, err := sclFilterOf(query)
+	if err != nil {
+		return nil, err
+	}
+	return &SCLExport{filter: filter, format: format, scope: scope}, nil
+}
+
+// FileName 导出文件名
+func (e *SCLExport) FileName() string {
+	return fmt.Sprintf("scl_%s.%s", time.Now().Format("20060102150405"), e.format)
+}
+
+// ContentType 导出文件的 MIME 类型
+func (e *SCLExport) ContentType() string {
+	if e.format == SCLExportCSV {
+		return "text/csv; charset=utf-8"
+	}
+	return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+}
+
+// each 分批读取数据范围内符合条件的记录，逐行计算分析结果后交给 fn
+func (e *SCLExport) each(fn func(record vo.SCLRecordAnalysisVO) error) error {
+	return dao.NewSCLDao(config.DB).WithScope(e.scope).EachBatch(e.filter, sclExportBatchSize, func(scls []models.SCL) error {
+		for _, scl := range scls {
+			record, err := buildSCLRecordVO(scl, nil)
+			if err != nil {
+				return err
+			}
+			if err := fn(record); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// sclExportRow 生成一行导出数据
+func sclExportRow(record vo.SCLRecordAnalysisVO) []interface{} {
+	scl := record.SCL
+	var studentId interface{} = ""
+	if scl.StudentID != nil {
+		studentId = *scl.StudentID
+	}
+	validity := "有效"
+	if scl.Invalid {
+		validity = "无效"
+	}
+	source := "逐题作答"
+	if scl.IndicesEstimated {
+		source = "因子分估算"
+	}
+	row := []interface{}{studentId, scl.Name, scl.Gender, scl.Age, time.Time(scl.TestDate).Format("2006-01-02")}
+	for _, factor := range sclFactors {
+		row = append(row, roundIndex(float64(*factor.Field(&scl))))
+	}
+	return append(row,
+		scl.TotalScore, scl.PositiveItems, scl.NegativeItems, scl.GSI, scl.PSDI, source,
+		reportLevelLabels[record.Analysis.Level], record.HealthStatus, validity, scl.ID,
+	)
+}
+
+// Write 将导出文件写入 w：xlsx 使用流式写入器，csv 逐批写出并带 UTF-8 BOM 以便 Excel 正确识别中文
+func (e *SCLExport) Write(w io.Writer) error {
+	if e.format == SCLExportCSV {
+		if _, err := w.Write([]byte("\xEF\xBB\xBF")); err != nil {
+			return err
+		}
+		writer := csv.NewWriter(w)
+		if err := writer.Write(sclExportHeaders); err != nil {
+			return err
+		}
+		cells := make([]string, len(sclExportHeaders))
+		err := e.each(func(record vo.SCLRecordAnalysisVO) error {
+			for i, cell := range sclExportRow(record) {
+				cells[i] = fmt.Sprint(cell)
+			}
+			return writer.Write(cells)
+		})
+		if err != nil {
+			return err
+		}
+		writer.Flush()
+		return writer.Error()
+	}
+
+	f := excelize.NewFile()
+	defer f.Close()
+	sheet := "SCL测评数据"
+	if err := f.SetSheetName("Sheet1", sheet); err != nil {
+		return err
+	}
+	stream, err := f.NewStreamWriter(sheet)
+	if err != nil {
+		return err
+	}
+	header := make([]interface{}, len(sclExportHeaders))
+	for i, title := range sclExportHeaders {
+		header[i] = title
+	}
+	if err := stream.SetRow("A1", header); err != nil {
+		return err
+	}
+	rowNum := 2
+	err = e.each(func(record vo.SCLRecordAnalysisVO) error {
+		cell, err := excelize.CoordinatesToCellName(1, rowNum)
+		if err != nil {
+			return err
+		}
+		rowNum++
+		return stream.SetRow(cell, sclExportRow(record))
+	})
+	if err != nil {
+		return err
+	}
+	if err := stream.Flush(); err != nil {
+		return err
+	}
+	return f.Write(w)
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"mental/dao"
+	"mental/vo"
+)
+
+// 分页默认值与上限
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// sclSortExprs 测评记录允许的排序字段及其排序表达式，等级按严重程度排序
+var sclSortExprs = map[string]string{
+	"id":             "scl.id",
+	"test_date":      "scl.test_date",
+	"name":           "scl.name",
+	"age":            "scl.age",
+	"total_score":    "scl.total_score",
+	"positive_items": "scl.positive_items",
+	"gsi":            "scl.gsi",
+	"psdi":           "scl.psdi",
+	"level":          "FIELD(scl.level, 'normal', 'mild', 'moderate', 'severe')",
+}
+
+func init() {
+	for _, factor := range sclFactors {
+		sclSortExprs[factor.Key] = "scl." + factor.Key
+	}
+}
+
+// sclFilterOf 将筛选条件转换为 DAO 查询条件，校验性别、等级和指标
+func sclFilterOf(query vo.SCLQuery) (dao.SCLFilter, error) {
+	filter := dao.SCLFilter{
+		Name:   query.Name,
+		Gender: query.Gender,
+		AgeMin: query.AgeMin,
+		AgeMax: query.AgeMax,
+		From:   query.From,
+		To:     query.To,
+		Level:  query.Level,
+	}
+	if query.Gender != nil && *query.Gender != 0 && *query.Gender != 1 {
+		return filter, errors.New("性别只能是 0（女）或 1（男）")
+	}
+	if query.Level != "" && reportLevelLabels[query.Level] == "" {
+		return filter, errors.New("等级只能是 normal、mild、moderate 或 severe")
+	}
+	for _, threshold := range query.Thresholds {
+		known := false
+		for _, metric := range sclMetrics() {
+			known = known || metric.Key == threshold.Key
+		}
+		if !known {
+			return filter, fmt.Errorf("不支持按 %s 筛选", threshold.Key)
+		}
+		filter.Ranges = append(filter.Ranges, dao.SCLRange{Column: threshold.Key, Min: threshold.Min, Max: threshold.Max})
+	}
+	if query.OrgID != 0 {
+		org, err := findOrg(query.OrgID)
+		if err != nil {
+			return filter, err
+		}
+		filter.OrgPath = org.Path
+	}
+	return filter, nil
+}
+
+// sclPageOf 校验排序字段和分页参数：默认按测评日期倒序，第 1 页，每页 20 条，最多 100 条
+func sclPageOf(query *vo.SCLQuery) (dao.SCLSort, error) {
+	if query.Sort == "" {
+		query.Sort = "test_date"
+	}
+	expr, ok := sclSortExprs[query.Sort]
+	if !ok {
+		return dao.SCLSort{}, fmt.Errorf("不支持按 %s 排序", query.Sort)
+	}
+	if query.Order == "" {
+		query.Order = "desc"
+	}
+	if query.Order != "asc" && query.Order != "desc" {
+		return dao.SCLSort{}, errors.New("排序方向只能是 asc 或 desc")
+	}
+	if query.Page <= 0 {
+		query.Page = 1
+	}
+	if query.PageSize <= 0 {
+		query.PageSize = defaultPageSize
+	}
+	if query.PageSize > maxPageSize {
+		query.PageSize = maxPageSize
+	}
+	return dao.SCLSort{Expr: expr, Desc: query.Order == "desc"}, nil
+}
@@ -0,0 +1,299 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mental/config"
+	"mental/dao"
+	"mental/models"
+	"mental/vo"
+	"sort"
+	"sync"
+)
+
+// sclRules 解析后的规则集
+type sclRules struct {
+	ID      int64
+	Name    string
+	Version int
+	Content models.SCLRuleContent
+	Norm    *sclNorm // 规则按 T 分判断时使用的常模，未使用 T 分时为 nil
+}
+
+// value 按比较依据取指标值；按 T 分比较但常模中没有该指标时返回 false
+func (r *sclRules) value(scl models.SCL, key string, criterion string) (float64, bool) {
+	if criterion == models.SCLCriterionTScore {
+		if r.Norm == nil {
+			return 0, false
+		}
+		return r.Norm.tScore(scl, key)
+	}
+	return sclMetricValue(scl, key), true
+}
+
+// Info 规则集概要信息，随分析结果返回
+func (r *sclRules) Info() vo.SCLRuleSetInfo {
+	return vo.SCLRuleSetInfo{ID: r.ID, Name: r.Name, Version: r.Version}
+}
+
+// defaultSCLRules 内置规则，未启用任何规则集时使用（与早期硬编码阈值一致）
+var defaultSCLRules = &sclRules{
+	ID:      0,
+	Name:    "内置规则",
+	Version: 0,
+	Content: models.SCLRuleContent{
+		TotalRules: []models.SCLThresholdRule{
+			{Min: 200, Level: models.SCLLevelSevere, Result: "心理状态较差"},
+			{Min: 160, Level: models.SCLLevelModerate, Result: "存在明显心理困扰"},
+		},
+		PositiveRules: []models.SCLThresholdRule{
+			{Min: 43, Level: models.SCLLevelMild, Result: "需关注，阳性项目数较多"},
+		},
+		FactorLevels: []models.SCLFactorLevel{
+			{Min: 4, Level: models.SCLLevelSevere, Label: "重度"},
+			{Min: 3, Level: models.SCLLevelModerate, Label: "中度"},
+			{Min: 2, Level: models.SCLLevelMild, Label: "轻度"},
+		},
+		NormalResult: "心理状态基本正常",
+	},
+}
+
+// sclRulesCache 规则集创建后内容不可修改，按 ID 缓存解析结果
+var sclRulesCache sync.Map
+
+// parseSCLRuleContent 解析并校验规则内容，阈值统一按从高到低排序
+func parseSCLRuleContent(content string) (*models.SCLRuleContent, error) {
+	var rules models.SCLRuleContent
+	if err := json.Unmarshal([]byte(content), &rules); err != nil {
+		return nil, fmt.Errorf("规则内容格式错误: %v", err)
+	}
+	if len(rules.FactorLevels) == 0 {
+		return nil, errors.New("因子分级规则不能为空")
+	}
+	if rules.NormalResult == "" {
+		return nil, errors.New("正常结论不能为空")
+	}
+	usesTScore := false
+	for _, group := range [][]models.SCLThresholdRule{rules.TotalRules, rules.PositiveRules} {
+		for _, rule := range group {
+			if !isSCLLevel(rule.Level) || rule.Result == "" || !isSCLCriterion(rule.Criterion) {
+				return nil, errors.New("总分/阳性项目数规则的等级、结论或比较依据无效")
+			}
+			usesTScore = usesTScore || rule.Criterion == models.SCLCriterionTScore
+		}
+	}
+	for _, level := range rules.FactorLevels {
+		if !isSCLLevel(level.Level) || level.Label == "" || !isSCLCriterion(level.Criterion) {
+			return nil, errors.New("因子分级规则的等级、名称或比较依据无效")
+		}
+		usesTScore = usesTScore || level.Criterion == models.SCLCriterionTScore
+	}
+	if usesTScore && rules.NormGroup == "" {
+		return nil, errors.New("按 T 分判断的规则必须指定常模组")
+	}
+
+	// 等级从重到轻、同等级阈值从高到低匹配（原始分与 T 分阈值量纲不同，不能只按阈值排序）
+	sort.SliceStable(rules.TotalRules, func(i, j int) bool {
+		return thresholdBefore(rules.TotalRules[i].Level, rules.TotalRules[i].Min, rules.TotalRules[j].Level, rules.TotalRules[j].Min)
+	})
+	sort.SliceStable(rules.PositiveRules, func(i, j int) bool {
+		return thresholdBefore(rules.PositiveRules[i].Level, rules.PositiveRules[i].Min, rules.PositiveRules[j].Level, rules.PositiveRules[j].Min)
+	})
+	sort.SliceStable(rules.FactorLevels, func(i, j int) bool {
+		return thresholdBefore(rules.FactorLevels[i].Level, rules.FactorLevels[i].Min, rules.FactorLevels[j].Level, rules.FactorLevels[j].Min)
+	})
+	return &rules, nil
+}
+
+// thresholdBefore 规则匹配顺序：等级更严重的在前，同等级阈值更高的在前
+func thresholdBefore(levelA string, minA float64, levelB string, minB float64) bool {
+	if sclLevelRank(levelA) != sclLevelRank(levelB) {
+		return sclLevelRank(levelA) > sclLevelRank(levelB)
+	}
+	return minA > minB
+}
+
+// isSCLCriterion 判断比较依据是否合法，为空时视为原始分
+func isSCLCriterion(criterion string) bool {
+	return criterion == "" || criterion == models.SCLCriterionRaw || criterion == models.SCLCriterionTScore
+}
+
+// newSCLRules 由解析后的规则内容构造规则集，需要时加载其指定的常模组
+func newSCLRules(id int64, name string, version int, content *models.SCLRuleContent) (*sclRules, error) {
+	rules := &sclRules{ID: id, Name: name, Version: version, Content: *content}
+	if content.NormGroup != "" {
+		norm, err := loadNorm(content.NormGroup)
+		if err != nil {
+			return nil, err
+		}
+		rules.Norm = norm
+	}
+	return rules, nil
+}
+
+// isSCLLevel 判断等级是否合法
+func isSCLLevel(level string) bool {
+	switch level {
+	case models.SCLLevelNormal, models.SCLLevelMild, models.SCLLevelModerate, models.SCLLevelSevere:
+		return true
+	}
+	return false
+}
+
+// loadSCLRules 根据 ID 加载规则集，ID 为 0 时返回内置规则
+func loadSCLRules(id int64) (*sclRules, error) {
+	if id == 0 {
+		return defaultSCLRules, nil
+	}
+	if cached, ok := sclRulesCache.Load(id); ok {
+		return cached.(*sclRules), nil
+	}
+
+	ruleSetDao := dao.NewSCLRuleSetDao(config.DB)
+	ruleSet, err := ruleSetDao.FindByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("规则集 %d 不存在", id)
+	}
+	content, err := parseSCLRuleContent(ruleSet.Content)
+	if err != nil {
+		return nil, err
+	}
+	rules, err := newSCLRules(ruleSet.ID, ruleSet.Name, ruleSet.Version, content)
+	if err != nil {
+		return nil, err
+	}
+	sclRulesCache.Store(id, rules)
+	return rules, nil
+}
+
+// activeSCLRules 查询组织当前启用的规则集：由组织自身逐级向上查找，其次全局，都没有时使用内置规则
+func activeSCLRules(orgId int64) (*sclRules, error) {
+	lookup, err := orgLookupIDs(orgId)
+	if err != nil {
+		return nil, err
+	}
+	ruleSetDao := dao.NewSCLRuleSetDao(config.DB)
+	for _, id := range lookup {
+		ruleSet, err := ruleSetDao.FindActive(id)
+		if err != nil {
+			return nil, err
+		}
+		if ruleSet != nil {
+			return loadSCLRules(ruleSet.ID)
+		}
+	}
+	return defaultSCLRules, nil
+}
+
+// SCLRuleService 结果解释规则集业务
+type SCLRuleService struct {
+}
+
+// NewSCLRuleService 创建新的 SCLRuleService
+func NewSCLRuleService() *SCLRuleService {
+	return &SCLRuleService{}
+}
+
+// CreateRuleSet 新建规则集，版本号在组织内自动递增，新建后默认不启用；仅管理员可操作
+func (s *SCLRuleService) CreateRuleSet(ruleSet *models.SCLRuleSet, operator *dao.DataScope) error {
+	if err := requireAdmin(operator); err != nil {
+		return err
+	}
+	if ruleSet.Name == "" {
+		return errors.New("规则集名称不能为空")
+	}
+	content, err := parseSCLRuleContent(ruleSet.Content)
+	if err != nil {
+		return err
+	}
+	if _, err := newSCLRules(0, ruleSet.Name, 0, content); err != nil {
+		return err // 校验指定的常模组存在
+	}
+
+	ruleSetDao := dao.NewSCLRuleSetDao(config.DB)
+	version, err := ruleSetDao.MaxVersion(ruleSet.OrgID)
+	if err != nil {
+		return err
+	}
+	ruleSet.ID = 0
+	ruleSet.Version = version + 1
+	ruleSet.Active = false
+	ruleSet.ActivatedAt = nil
+	return ruleSetDao.Save(ruleSet)
+}
+
+// ListRuleSets 列出指定组织的全部规则集；管理员可查询任意组织及全局规则集，组织负责人只能查询所负责组织（含下级）的规则集
+func (s *SCLRuleService) ListRuleSets(orgId int64, operator *dao.DataScope) ([]models.SCLRuleSet, error) {
+	if err := requireOrgManager(orgId, operator); err != nil {
+		return nil, err
+	}
+	ruleSetDao := dao.NewSCLRuleSetDao(config.DB)
+	return ruleSetDao.ListByOrg(orgId)
+}
+
+// ActivateRuleSet 启用指定规则集，之后新产生的测评结论均使用该规则集；仅管理员可操作
+func (s *SCLRuleService) ActivateRuleSet(id int64, operator *dao.DataScope) error {
+	if err := requireAdmin(operator); err != nil {
+		return err
+	}
+	ruleSetDao := dao.NewSCLRuleSetDao(config.DB)
+	ruleSet, err := ruleSetDao.FindByID(id)
+	if err != nil {
+		return errors.New("规则集不存在")
+	}
+	return ruleSetDao.Activate(ruleSet)
+}
+
+// PreviewRuleSet 使用指定规则（已保存的规则集或未保存的规则内容）对样例数据和数据范围内的已有记录进行试算，不落库；仅管理员可操作
+func (s *SCLRuleService) PreviewRuleSet(operator *dao.DataScope, ruleSetId int64, content string, samples []models.SCL, sclIds []int64) ([]vo.SCLRecordAnalysisVO, error) {
+	if err := requireAdmin(operator); err != nil {
+		return nil, err
+	}
+	var rules *sclRules
+	if content != "" {
+		parsed, err := parseSCLRuleContent(content)
+		if err != nil {
+			return nil, err
+		}
+		if rules, err = newSCLRules(0, "预览规则", 0, parsed); err != nil {
+			return nil, err
+		}
+	} else {
+		loaded, err := loadSCLRules(ruleSetId)
+		if err != nil {
+			return nil, err
+		}
+		rules = loaded
+	}
+
+	sclDao := dao.NewSCLDao(config.DB).WithScope(operator)
+	for _, id := range sclIds {
+		scl, err := sclDao.FindByID(id)
+		if err != nil {
+			return nil, fmt.Errorf("测评记录 %d 不存在", id)
+		}
+		samples = append(samples, *scl)
+	}
+
+	results := make([]vo.SCLRecordAnalysisVO, 0, len(samples))
+	for i := range samples {
+		sample := samples[i]
+		if sample.ID == 0 || len(sample.Items) > 0 {
+			// 样例数据需先计算派生字段
+			if err := applySCLScoring(&sample); err != nil {
+				return nil, fmt.Errorf("第 %d 条样例数据错误: %v", i+1, err)
+			}
+		} else {
+			ensureSCLIndices(&sample)
+		}
+		analysis := analyzeSCL(sample, rules)
+		results = append(results, vo.SCLRecordAnalysisVO{
+			SCL:          sample,
+			HealthStatus: analysis.HealthStatus,
+			Analysis:     analysis.SCLAnalysis,
+			RuleSet:      rules.Info(),
+		})
+	}
+	return results, nil
+}
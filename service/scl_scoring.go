@@ -0,0 +1,164 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"mental/models"
+)
+
+const (
+	sclItemCount = 90 // SCL-90 题目数量
+	sclMinScore  = 1  // 单题最低分（没有）
+	sclMaxScore  = 5  // 单题最高分（严重）
+)
+
+// sclFactor 描述 SCL-90 的一个因子：名称、对应的 SCL 字段以及包含的题号
+type sclFactor struct {
+	Key   string                         // 字段名，与 json 字段一致
+	Name  string                         // 因子中文名
+	Field func(scl *models.SCL) *float32 // 取得 SCL 中对应因子字段的指针
+	Items []int                          // 因子包含的题号
+}
+
+// sclFactors SCL-90 十个因子，顺序与 scl 表字段顺序一致
+var sclFactors = []sclFactor{
+	{"somatization", "躯体化", func(s *models.SCL) *float32 { return &s.Somatization }, []int{1, 4, 12, 27, 40, 42, 48, 49, 52, 53, 56, 58}},
+	{"obsession", "强迫症状", func(s *models.SCL) *float32 { return &s.Obsession }, []int{3, 9, 10, 28, 38, 45, 46, 51, 55, 65}},
+	{"interpersonal", "人际关系敏感", func(s *models.SCL) *float32 { return &s.Interpersonal }, []int{6, 21, 34, 36, 37, 41, 61, 69, 73}},
+	{"depression", "抑郁", func(s *models.SCL) *float32 { return &s.Depression }, []int{5, 14, 15, 20, 22, 26, 29, 30, 31, 32, 54, 71, 79}},
+	{"anxiety", "焦虑", func(s *models.SCL) *float32 { return &s.Anxiety }, []int{2, 17, 23, 33, 39, 57, 72, 78, 80, 86}},
+	{"hostility", "敌对", func(s *models.SCL) *float32 { return &s.Hostility }, []int{11, 24, 63, 67, 74, 81}},
+	{"phobia", "恐怖", func(s *models.SCL) *float32 { return &s.Phobia }, []int{13, 25, 47, 50, 70, 75, 82}},
+	{"paranoia", "偏执", func(s *models.SCL) *float32 { return &s.Paranoia }, []int{8, 18, 43, 68, 76, 83}},
+	{"psychoticism", "精神病性", func(s *models.SCL) *float32 { return &s.Psychoticism }, []int{7, 16, 35, 62, 77, 84, 85, 87, 88, 90}},
+	{"other", "其他", func(s *models.SCL) *float32 { return &s.Other }, []int{19, 44, 59, 60, 64, 66, 89}},
+}
+
+// validateSCLItems 校验原始作答：必须恰好 90 题，每题 1-5 分
+func validateSCLItems(items []int) error {
+	if len(items) != sclItemCount {
+		return fmt.Errorf("原始作答必须为 %d 题（当前 %d 题）", sclItemCount, len(items))
+	}
+	for i, score := range items {
+		if score < sclMinScore || score > sclMaxScore {
+			return &sclFieldError{Item: i + 1, Err: fmt.Errorf("第 %d 题分值必须在 %d - %d 之间", i+1, sclMinScore, sclMaxScore)}
+		}
+	}
+	return nil
+}
+
+// scoreSCLItems 根据 90 道题的原始作答计算十个因子均分、总分和阳性项目数，结果直接写回 scl
+func scoreSCLItems(scl *models.SCL) error {
+	if err := validateSCLItems(scl.Items); err != nil {
+		return err
+	}
+
+	for _, factor := range sclFactors {
+		sum := 0
+		for _, no := range factor.Items {
+			sum += scl.Items[no-1]
+		}
+		*factor.Field(scl) = roundFactor(float64(sum) / float64(len(factor.Items)))
+	}
+
+	total, positive := 0, 0
+	for _, score := range scl.Items {
+		total += score
+		if score >= 2 { // 单题 >= 2 分即为阳性项目
+			positive++
+		}
+	}
+	fillSCLIndices(scl, float64(total), positive)
+	scl.IndicesEstimated = false
+	return nil
+}
+
+// estimateSCLFromFactors 仅提交因子分时，由因子均分估算总分和阳性项目数，并标记全局指数为估算值
+// 没有逐题作答时无法得知单题得分，阳性项目数只能按因子整体估算：因子均分 >= 2 时该因子的题目全部计为阳性，否则全部计为阴性
+func estimateSCLFromFactors(scl *models.SCL) {
+	total, positive := 0.0, 0
+	for _, factor := range sclFactors {
+		mean := float64(*factor.Field(scl))
+		total += mean * float64(len(factor.Items))
+		if mean >= 2 { // 因子均分 >= 2 时，按该因子题目全部阳性估算
+			positive += len(factor.Items)
+		}
+	}
+	fillSCLIndices(scl, math.Round(total), positive)
+	scl.IndicesEstimated = true
+}
+
+// fillSCLIndices 根据总分和阳性项目数计算全局指数：
+// 阴性项目数 = 90 - 阳性项目数，GSI = 总分 / 90，PSDI = (总分 - 阴性项目数) / 阳性项目数
+func fillSCLIndices(scl *models.SCL, total float64, positive int) {
+	negative := sclItemCount - positive
+	scl.TotalScore = total
+	scl.PositiveItems = float64(positive)
+	scl.NegativeItems = float64(negative)
+	scl.GSI = roundIndex(total / sclItemCount)
+	scl.PSDI = 0
+	if positive > 0 {
+		scl.PSDI = roundIndex((total - float64(negative)) / float64(positive))
+	}
+}
+
+// ensureSCLIndices 历史记录未保存总分时，读取时按因子分补算全局指数（不回写数据库）
+func ensureSCLIndices(scl *models.SCL) {
+	if scl.TotalScore == 0 {
+		estimateSCLFromFactors(scl)
+	}
+}
+
+// applySCLScoring 计算派生字段：有原始作答时以作答为准，否则由因子分估算
+func applySCLScoring(scl *models.SCL) error {
+	if len(scl.Items) > 0 {
+		return scoreSCLItems(scl)
+	}
+	estimateSCLFromFactors(scl)
+	return nil
+}
+
+// buildSCLAnswers 将原始作答转换为 scl_answer 记录
+func buildSCLAnswers(sclId int64, items []int) []models.SCLAnswer {
+	answers := make([]models.SCLAnswer, 0, len(items))
+	for i, score := range items {
+		answers = append(answers, models.SCLAnswer{
+			SCLID:  sclId,
+			ItemNo: i + 1,
+			Score:  score,
+		})
+	}
+	return answers
+}
+
+// roundFactor 因子均分保留一位小数，与 scl 表 decimal(3,1) 一致
+func roundFactor(v float64) float32 {
+	return float32(math.Round(v*10) / 10)
+}
+
+// roundIndex 全局指数保留两位小数，与 scl 表 decimal(4,2) 一致
+func roundIndex(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// sclItemTexts SCL-90 题干，按题号顺序
+var sclItemTexts = []string{
+	"头痛", "神经过敏，心中不踏实", "头脑中有不必要的想法或字句盘旋", "头昏或昏倒", "对异性的兴趣减退",
+	"对旁人责备求全", "感到别人能控制您的思想", "责怪别人制造麻烦", "忘记性大", "担心自己的衣饰整齐及仪态的端正",
+	"容易烦恼和激动", "胸痛", "害怕空旷的场所或街道", "感到自己的精力下降，活动减慢", "想结束自己的生命",
+	"听到旁人听不到的声音", "发抖", "感到大多数人都不可信任", "胃口不好", "容易哭泣",
+	"同异性相处时感到害羞不自在", "感到受骗，中了圈套或有人想抓住您", "无缘无故地突然感到害怕", "自己不能控制地大发脾气", "怕单独出门",
+	"经常责怪自己", "腰痛", "感到难以完成任务", "感到孤独", "感到苦闷",
+	"过分担忧", "对事物不感兴趣", "感到害怕", "您的感情容易受到伤害", "旁人能知道您的私下想法",
+	"感到别人不理解您、不同情您", "感到人们对您不友好，不喜欢您", "做事必须做得很慢以保证做得正确", "心跳得很厉害", "恶心或胃部不舒服",
+	"感到比不上他人", "肌肉酸痛", "感到有人在监视您、谈论您", "难以入睡", "做事必须反复检查",
+	"难以作出决定", "怕乘电车、公共汽车、地铁或火车", "呼吸有困难", "一阵阵发冷或发热", "因为感到害怕而避开某些东西、场合或活动",
+	"脑子变空了", "身体发麻或刺痛", "喉咙有梗塞感", "感到前途没有希望", "不能集中注意力",
+	"感到身体的某一部分软弱无力", "感到紧张或容易紧张", "感到手或脚发重", "想到死亡的事", "吃得太多",
+	"当别人看着您或谈论您时感到不自在", "有一些不属于您自己的想法", "有想打人或伤害他人的冲动", "醒得太早", "必须反复洗手、点数目或触摸某些东西",
+	"睡得不稳不深", "有想摔坏或破坏东西的冲动", "有一些别人没有的想法或念头", "感到对别人神经过敏", "在商店或电影院等人多的地方感到不自在",
+	"感到任何事情都很困难", "一阵阵恐惧或惊恐", "感到在公共场合吃东西很不舒服", "经常与人争论", "单独一人时神经很紧张",
+	"别人对您的成绩没有作出恰当的评价", "即使和别人在一起也感到孤单", "感到坐立不安心神不定", "感到自己没有什么价值", "感到熟悉的东西变成陌生或不像是真的",
+	"大叫或摔东西", "害怕会在公共场合昏倒", "感到别人想占您的便宜", "为一些有关“性”的想法而很苦恼", "您认为应该因为自己的过错而受到惩罚",
+	"感到要赶快把事情做完", "感到自己的身体有严重问题", "从未感到和其他人很亲近", "感到自己有罪", "感到自己的脑子有毛病",
+}
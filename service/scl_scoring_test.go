@@ -0,0 +1,114 @@
+package service
+
+import (
+	"mental/models"
+	"testing"
+)
+
+// sclKnownItems 已知的 90 题作答：躯体化 3 分、强迫症状 2 分、人际关系敏感 1 分、抑郁 4 分、焦虑 5 分、
+// 敌对除第 11 题 1 分外均为 2 分、恐怖 1 分、偏执 3 分、精神病性 1 分、其他 2 分
+var sclKnownItems = []int{
+	3, 5, 2, 3, 4, 1, 1, 3, 2, 2,
+	1, 3, 1, 4, 4, 1, 5, 3, 2, 4,
+	1, 4, 5, 2, 1, 4, 3, 2, 4, 4,
+	4, 4, 5, 1, 1, 1, 1, 2, 5, 3,
+	1, 3, 3, 2, 2, 2, 1, 3, 3, 1,
+	2, 3, 3, 4, 2, 3, 5, 3, 2, 2,
+	1, 1, 2, 2, 2, 2, 2, 3, 1, 1,
+	4, 5, 1, 2, 1, 3, 1, 5, 4, 5,
+	2, 1, 3, 1, 1, 5, 1, 1, 2, 1,
+}
+
+func TestScoreSCLItems(t *testing.T) {
+	scl := &models.SCL{Items: sclKnownItems}
+	if err := scoreSCLItems(scl); err != nil {
+		t.Fatalf("计分失败: %v", err)
+	}
+
+	factors := map[string]float32{
+		"somatization":  3,
+		"obsession":     2,
+		"interpersonal": 1,
+		"depression":    4,
+		"anxiety":       5,
+		"hostility":     1.8, // 11 / 6 = 1.83，保留一位小数
+		"phobia":        1,
+		"paranoia":      3,
+		"psychoticism":  1,
+		"other":         2,
+	}
+	for _, factor := range sclFactors {
+		if got := *factor.Field(scl); got != factors[factor.Key] {
+			t.Errorf("因子 %s 均分应为 %v，实际为 %v", factor.Key, factors[factor.Key], got)
+		}
+	}
+
+	// 总分 227，阳性项目 63 项，阴性项目 27 项：GSI = 227 / 90，PSDI = (227 - 27) / 63
+	want := models.SCL{TotalScore: 227, PositiveItems: 63, NegativeItems: 27, GSI: 2.52, PSDI: 3.17}
+	if scl.TotalScore != want.TotalScore || scl.PositiveItems != want.PositiveItems || scl.NegativeItems != want.NegativeItems ||
+		scl.GSI != want.GSI || scl.PSDI != want.PSDI {
+		t.Errorf("全局指数应为 总分 %v 阳性 %v 阴性 %v GSI %v PSDI %v，实际为 总分 %v 阳性 %v 阴性 %v GSI %v PSDI %v",
+			want.TotalScore, want.PositiveItems, want.NegativeItems, want.GSI, want.PSDI,
+			scl.TotalScore, scl.PositiveItems, scl.NegativeItems, scl.GSI, scl.PSDI)
+	}
+}
+
+func TestScoreSCLItemsRejectsInvalidAnswers(t *testing.T) {
+	if err := scoreSCLItems(&models.SCL{Items: sclKnownItems[:89]}); err == nil {
+		t.Error("不足 90 题的作答应返回错误")
+	}
+	items := append([]int(nil), sclKnownItems...)
+	items[44] = 6
+	if err := scoreSCLItems(&models.SCL{Items: items}); err == nil {
+		t.Error("分值超出 1-5 的作答应返回错误")
+	}
+}
+
+func TestFillSCLIndices(t *testing.T) {
+	cases := []struct {
+		name     string
+		total    float64
+		positive int
+		gsi      float64
+		psdi     float64
+	}{
+		{"全部阴性", 90, 0, 1, 0},
+		{"全部最高分", 450, 90, 5, 5},
+		{"部分阳性", 227, 63, 2.52, 3.17},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var scl models.SCL
+			fillSCLIndices(&scl, tc.total, tc.positive)
+			if scl.TotalScore != tc.total || scl.PositiveItems != float64(tc.positive) || scl.NegativeItems != float64(sclItemCount-tc.positive) {
+				t.Errorf("总分、阳性、阴性项目数应为 %v/%d/%d，实际为 %v/%v/%v",
+					tc.total, tc.positive, sclItemCount-tc.positive, scl.TotalScore, scl.PositiveItems, scl.NegativeItems)
+			}
+			if scl.GSI != tc.gsi || scl.PSDI != tc.psdi {
+				t.Errorf("GSI/PSDI 应为 %v/%v，实际为 %v/%v", tc.gsi, tc.psdi, scl.GSI, scl.PSDI)
+			}
+		})
+	}
+}
+
+func TestEstimateSCLFromFactorsMarksEstimated(t *testing.T) {
+	scl := &models.SCL{Items: sclKnownItems}
+	if err := scoreSCLItems(scl); err != nil {
+		t.Fatalf("计分失败: %v", err)
+	}
+	if scl.IndicesEstimated {
+		t.Error("按逐题作答计分的记录不应标记为估算")
+	}
+
+	// 只保留因子分：均分 >= 2 的因子按全部题目阳性估算，敌对 1.8 分按全部阴性估算
+	scl.Items = nil
+	estimateSCLFromFactors(scl)
+	if !scl.IndicesEstimated {
+		t.Error("仅有因子分的记录应标记为估算")
+	}
+	// 总分 = Σ 因子均分 × 题数 = 36 + 20 + 9 + 52 + 50 + 10.8 + 7 + 18 + 10 + 14 ≈ 227
+	// 阳性项目 = 躯体化 12 + 强迫症状 10 + 抑郁 13 + 焦虑 10 + 偏执 6 + 其他 7 = 58
+	if scl.TotalScore != 227 || scl.PositiveItems != 58 || scl.NegativeItems != 32 {
+		t.Errorf("估算的总分/阳性/阴性项目数应为 227/58/32，实际为 %v/%v/%v", scl.TotalScore, scl.PositiveItems, scl.NegativeItems)
+	}
+}
@@ -0,0 +1,150 @@
+package service
+
+import (
+	"errors"
+	"mental/config"
+	"mental/dao"
+	"mental/models"
+	"testing"
+)
+
+func TestUpdateSCLForbiddenForOtherStudent(t *testing.T) {
+	setupTestEnv(t)
+	sclService := NewSCLService()
+	scl := testSCL(100, 1.5)
+	if err := sclService.CreateSCL(scl); err != nil {
+		t.Fatalf("新增测评记录失败: %v", err)
+	}
+
+	update := testSCL(100, 2)
+	update.ID = scl.ID
+	err := sclService.UpdateSCL(update, selfScope(200))
+	if !errors.Is(err, ErrSCLForbidden) {
+		t.Fatalf("修改他人的测评记录应返回 ErrSCLForbidden，实际为 %v", err)
+	}
+
+	stored, err := dao.NewSCLDao(config.DB).FindByID(scl.ID)
+	if err != nil {
+		t.Fatalf("查询测评记录失败: %v", err)
+	}
+	if stored.Depression != 1.5 {
+		t.Errorf("被拒绝的修改不应落库，抑郁因子为 %v", stored.Depression)
+	}
+}
+
+func TestDeleteSCLForbiddenForOtherStudent(t *testing.T) {
+	setupTestEnv(t)
+	sclService := NewSCLService()
+	scl := testSCL(100, 1.5)
+	if err := sclService.CreateSCL(scl); err != nil {
+		t.Fatalf("新增测评记录失败: %v", err)
+	}
+
+	if err := sclService.DeleteSCL(scl.ID, selfScope(200)); !errors.Is(err, ErrSCLForbidden) {
+		t.Fatalf("删除他人的测评记录应返回 ErrSCLForbidden，实际为 %v", err)
+	}
+	if _, err := dao.NewSCLDao(config.DB).FindByID(scl.ID); err != nil {
+		t.Errorf("被拒绝的删除不应删除记录: %v", err)
+	}
+}
+
+func TestUpdateAndDeleteSCLNotFound(t *testing.T) {
+	setupTestEnv(t)
+	sclService := NewSCLService()
+
+	update := testSCL(100, 2)
+	update.ID = 404
+	if err := sclService.UpdateSCL(update, selfScope(100)); !errors.Is(err, ErrSCLNotFound) {
+		t.Errorf("修改不存在的记录应返回 ErrSCLNotFound，实际为 %v", err)
+	}
+	if err := sclService.DeleteSCL(404, selfScope(100)); !errors.Is(err, ErrSCLNotFound) {
+		t.Errorf("删除不存在的记录应返回 ErrSCLNotFound，实际为 %v", err)
+	}
+}
+
+func TestUpdateSCLRejectsStudentChange(t *testing.T) {
+	setupTestEnv(t)
+	sclService := NewSCLService()
+	scl := testSCL(100, 1.5)
+	if err := sclService.CreateSCL(scl); err != nil {
+		t.Fatalf("新增测评记录失败: %v", err)
+	}
+
+	update := testSCL(101, 2)
+	update.ID = scl.ID
+	if err := sclService.UpdateSCL(update, &dao.DataScope{Scope: "all", All: true}); err == nil {
+		t.Fatal("修改测评记录的所属学生应被拒绝")
+	}
+
+	stored, err := dao.NewSCLDao(config.DB).FindByID(scl.ID)
+	if err != nil {
+		t.Fatalf("查询测评记录失败: %v", err)
+	}
+	if stored.StudentID == nil || *stored.StudentID != 100 {
+		t.Errorf("所属学生不应被修改，实际为 %v", stored.StudentID)
+	}
+}
+
+func TestUpdateSCLValidatesFields(t *testing.T) {
+	setupTestEnv(t)
+	sclService := NewSCLService()
+	scl := testSCL(100, 1.5)
+	if err := sclService.CreateSCL(scl); err != nil {
+		t.Fatalf("新增测评记录失败: %v", err)
+	}
+
+	update := testSCL(100, 2)
+	update.ID = scl.ID
+	update.Age = 0
+	var fieldErr *sclFieldError
+	if err := sclService.UpdateSCL(update, selfScope(100)); !errors.As(err, &fieldErr) || fieldErr.Field != "age" {
+		t.Fatalf("修改时应按新增的规则校验字段，实际为 %v", err)
+	}
+
+	update = testSCL(100, 6)
+	update.ID = scl.ID
+	if err := sclService.UpdateSCL(update, selfScope(100)); !errors.As(err, &fieldErr) {
+		t.Fatalf("因子分超出范围的修改应被拒绝，实际为 %v", err)
+	}
+
+	stored, err := dao.NewSCLDao(config.DB).FindByID(scl.ID)
+	if err != nil {
+		t.Fatalf("查询测评记录失败: %v", err)
+	}
+	if stored.Age != 18 || stored.Depression != 1.5 {
+		t.Errorf("校验失败的修改不应落库: %+v", models.SCL{Age: stored.Age, Depression: stored.Depression})
+	}
+}
+
+func TestUpdateSCLFactorsOnlyClearsAnswers(t *testing.T) {
+	setupTestEnv(t)
+	sclService := NewSCLService()
+	scl := testSCL(100, 0)
+	scl.Items = make([]int, sclItemCount)
+	for i := range scl.Items {
+		scl.Items[i] = 3
+	}
+	if err := sclService.CreateSCL(scl); err != nil {
+		t.Fatalf("新增测评记录失败: %v", err)
+	}
+	answers, err := dao.NewSCLAnswerDao(config.DB).FindBySCLID(scl.ID)
+	if err != nil || len(answers) != sclItemCount {
+		t.Fatalf("应保存 %d 题作答，实际为 %d 题: %v", sclItemCount, len(answers), err)
+	}
+
+	update := testSCL(100, 1.5)
+	update.ID = scl.ID
+	if err := sclService.UpdateSCL(update, selfScope(100)); err != nil {
+		t.Fatalf("修改测评记录失败: %v", err)
+	}
+	if answers, err = dao.NewSCLAnswerDao(config.DB).FindBySCLID(scl.ID); err != nil || len(answers) != 0 {
+		t.Errorf("只提交因子分时应删除原有作答，剩余 %d 题: %v", len(answers), err)
+	}
+	stored, err := dao.NewSCLDao(config.DB).FindByID(scl.ID)
+	if err != nil {
+		t.Fatalf("查询测评记录失败: %v", err)
+	}
+	if stored.Invalid || stored.Validity != "" || stored.Depression != 1.5 {
+		t.Errorf("应清除有效性标记并保存新的因子分: invalid=%v validity=%q depression=%v", stored.Invalid, stored.Validity, stored.Depression)
+	}
+}
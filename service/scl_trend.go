@@ -0,0 +1,195 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"mental/config"
+	"mental/dao"
+	"mental/models"
+	"mental/vo"
+	"sort"
+	"time"
+)
+
+// 趋势方向
+const (
+	trendImproving     = "improving"     // 好转（分数下降）
+	trendStable        = "stable"        // 稳定
+	trendDeteriorating = "deteriorating" // 恶化（分数上升）
+	trendInsufficient  = "insufficient"  // 测评次数不足，无法判断
+)
+
+const (
+	rciThreshold      = 1.96 // 可靠变化指数阈值（95% 置信）
+	trendSlopeDays    = 30.0 // 斜率单位：每 30 天的变化量
+	trendStableEffect = 0.2  // 整个观察期变化量小于 0.2 个标准差视为稳定
+)
+
+// sclTrendMetric 趋势分析指标的心理测量参数
+type sclTrendMetric struct {
+	sclMetric
+	Reliability float64 // 重测信度
+	FallbackSD  float64 // 常模中没有该指标时使用的标准差
+	Cutoff      float64 // 临床界值，达到即视为阳性
+}
+
+// sclTrendMetrics 参与趋势分析的指标，重测信度取自 Derogatis(1976)，标准差缺省值取自全国常模
+var sclTrendMetrics = []sclTrendMetric{
+	{sclMetric{"somatization", "躯体化"}, 0.86, 0.48, 2},
+	{sclMetric{"obsession", "强迫症状"}, 0.85, 0.58, 2},
+	{sclMetric{"interpersonal", "人际关系敏感"}, 0.83, 0.61, 2},
+	{sclMetric{"depression", "抑郁"}, 0.82, 0.59, 2},
+	{sclMetric{"anxiety", "焦虑"}, 0.80, 0.43, 2},
+	{sclMetric{"hostility", "敌对"}, 0.84, 0.56, 2},
+	{sclMetric{"phobia", "恐怖"}, 0.90, 0.41, 2},
+	{sclMetric{"paranoia", "偏执"}, 0.80, 0.57, 2},
+	{sclMetric{"psychoticism", "精神病性"}, 0.78, 0.42, 2},
+	{sclMetric{"other", "其他"}, 0.80, 0.50, 2},
+	{sclMetric{"total_score", "总分"}, 0.90, 38.76, 160},
+	{sclMetric{"gsi", "总症状指数"}, 0.90, 0.43, 2},
+}
+
+// Trend 分析学生历次测评的变化趋势：各指标按测评日期排列的时间序列、斜率与方向、
+// 相邻两次测评的可靠变化指数（RCI），以及有临床意义的恶化标记；只统计数据范围内的记录
+func (s *SCLService) Trend(studentId int64, normCode string, scope *dao.DataScope) (*vo.SCLTrendResult, error) {
+	sclDao := dao.NewSCLDao(config.DB).WithScope(scope)
+	scls, err := sclDao.SelectAllByUserId(studentId)
+	if err != nil {
+		return nil, err
+	}
+	norm, err := loadNorm(normCode)
+	if err != nil {
+		return nil, err
+	}
+
+	// 按测评日期升序，同一天按记录 ID 升序
+	sort.SliceStable(scls, func(i, j int) bool {
+		ti, tj := time.Time(scls[i].TestDate), time.Time(scls[j].TestDate)
+		if !ti.Equal(tj) {
+			return ti.Before(tj)
+		}
+		return scls[i].ID < scls[j].ID
+	})
+	for i := range scls {
+		ensureSCLIndices(&scls[i])
+	}
+
+	result := &vo.SCLTrendResult{
+		StudentID:          studentId,
+		RecordCount:        len(scls),
+		Metrics:            make([]vo.SCLMetricTrend, 0, len(sclTrendMetrics)),
+		DeteriorationFlags: []vo.SCLTrendFlag{},
+	}
+	for _, metric := range sclTrendMetrics {
+		trend := vo.SCLMetricTrend{
+			Key:     metric.Key,
+			Name:    metric.Name,
+			Points:  make([]vo.SCLTrendPoint, 0, len(scls)),
+			Changes: []vo.SCLReliableChange{},
+		}
+		for _, scl := range scls {
+			trend.Points = append(trend.Points, vo.SCLTrendPoint{
+				SCLID:    scl.ID,
+				TestDate: scl.TestDate,
+				Value:    sclMetricValue(scl, metric.Key),
+			})
+		}
+
+		for i := 1; i < len(scls); i++ {
+			change := reliableChange(scls[i-1], scls[i], metric, norm)
+			trend.Changes = append(trend.Changes, change)
+			if change.ClinicallySignificant {
+				result.DeteriorationFlags = append(result.DeteriorationFlags, vo.SCLTrendFlag{
+					Key:      metric.Key,
+					Name:     metric.Name,
+					SCLID:    change.ToSCLID,
+					TestDate: change.ToDate,
+					RCI:      change.RCI,
+					Message:  fmt.Sprintf("%s由 %.2f 升至 %.2f，超过临床界值 %.0f 且变化可靠（RCI=%.2f）", metric.Name, change.FromValue, change.ToValue, metric.Cutoff, change.RCI),
+				})
+			}
+		}
+
+		if len(scls) > 0 {
+			trend.Slope, trend.Direction = trendDirection(trend.Points, metricSD(scls[len(scls)-1], metric, norm))
+		} else {
+			trend.Direction = trendInsufficient
+		}
+		result.Metrics = append(result.Metrics, trend)
+	}
+	return result, nil
+}
+
+// metricSD 取指标的标准差：优先使用常模中与记录性别、年龄匹配的条目
+func metricSD(scl models.SCL, metric sclTrendMetric, norm *sclNorm) float64 {
+	if norm != nil {
+		if entry := norm.lookup(metric.Key, scl.Gender, scl.Age); entry != nil && entry.SD > 0 {
+			return entry.SD
+		}
+	}
+	return metric.FallbackSD
+}
+
+// reliableChange 计算相邻两次测评的可靠变化指数（Jacobson-Truax）：
+// RCI = (后测 - 前测) / Sdiff，Sdiff = √2 × SD × √(1 - r)
+// RCI >= 1.96 且后测越过临床界值（前测未达到）时视为有临床意义的恶化
+func reliableChange(from, to models.SCL, metric sclTrendMetric, norm *sclNorm) vo.SCLReliableChange {
+	fromValue := sclMetricValue(from, metric.Key)
+	toValue := sclMetricValue(to, metric.Key)
+	sDiff := math.Sqrt2 * metricSD(to, metric, norm) * math.Sqrt(1-metric.Reliability)
+	rci := (toValue - fromValue) / sDiff
+
+	change := vo.SCLReliableChange{
+		FromSCLID: from.ID,
+		ToSCLID:   to.ID,
+		FromDate:  from.TestDate,
+		ToDate:    to.TestDate,
+		FromValue: fromValue,
+		ToValue:   toValue,
+		Change:    roundIndex(toValue - fromValue),
+		RCI:       roundIndex(rci),
+		Reliable:  trendStable,
+	}
+	switch {
+	case rci >= rciThreshold:
+		change.Reliable = trendDeteriorating
+		change.ClinicallySignificant = fromValue < metric.Cutoff && toValue >= metric.Cutoff
+	case rci <= -rciThreshold:
+		change.Reliable = trendImproving
+	}
+	return change
+}
+
+// trendDirection 以距首次测评的天数为自变量做最小二乘回归，返回每 30 天的变化量和趋势方向；
+// 观察期内的总变化量不足 0.2 个标准差（sd）时视为稳定
+func trendDirection(points []vo.SCLTrendPoint, sd float64) (float64, string) {
+	if len(points) < 2 {
+		return 0, trendInsufficient
+	}
+	first := time.Time(points[0].TestDate)
+	var sumX, sumY, sumXY, sumXX float64
+	n := float64(len(points))
+	for _, p := range points {
+		x := time.Time(p.TestDate).Sub(first).Hours() / 24
+		sumX += x
+		sumY += p.Value
+		sumXY += x * p.Value
+		sumXX += x * x
+	}
+	denominator := n*sumXX - sumX*sumX
+	if denominator == 0 { // 所有测评在同一天
+		return 0, trendInsufficient
+	}
+	slopePerDay := (n*sumXY - sumX*sumY) / denominator
+	spanDays := time.Time(points[len(points)-1].TestDate).Sub(first).Hours() / 24
+
+	direction := trendStable
+	threshold := trendStableEffect * sd
+	switch {
+	case slopePerDay*spanDays >= threshold:
+		direction = trendDeteriorating
+	case slopePerDay*spanDays <= -threshold:
+		direction = trendImproving
+	}
+	return roundIndex(slopePerDay * trendSlopeDays), direction
+}
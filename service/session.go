@@ -0,0 +1,298 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"mental/constant"
+	"mental/models"
+	"mental/utils"
+	"mental/vo"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultSessionMinutes = 60               // 量表未设置时限时的默认作答时限（分钟）
+	sessionSubmitGrace    = 30 * time.Second // 截止后仍允许提交的宽限时间，避免网络延迟导致提交失败
+	defaultSessionPage    = 10               // 默认每页题数
+	maxSessionPage        = 90               // 每页最多题数
+)
+
+// SessionService 在线测评会话业务：开始/恢复会话、分页取题、自动保存、提交计分
+type SessionService struct {
+}
+
+// NewSessionService 创建新的 SessionService
+func NewSessionService() *SessionService {
+	return &SessionService{}
+}
+
+// studentSessionKey 学生在某量表上未完成会话的索引键
+func studentSessionKey(studentId int64, scaleCode string) string {
+	return constant.StudentSessionPrefix + strconv.FormatInt(studentId, 10) + ":" + scaleCode
+}
+
+// loadSession 从 Redis 读取会话，过期或不存在时返回错误
+func loadSession(sessionId string) (*models.AssessmentSession, error) {
+	value, err := utils.Get(constant.SessionPrefix + sessionId)
+	if errors.Is(err, redis.Nil) {
+		return nil, errors.New("测评会话不存在或已超时")
+	}
+	if err != nil {
+		return nil, err
+	}
+	var session models.AssessmentSession
+	if err := json.Unmarshal([]byte(value.(string)), &session); err != nil {
+		return nil, fmt.Errorf("测评会话数据损坏: %v", err)
+	}
+	return &session, nil
+}
+
+// storeSession 保存会话，过期时间为截止时间加提交宽限
+func storeSession(session *models.AssessmentSession) error {
+	ttl := time.Until(session.ExpiresAt) + sessionSubmitGrace
+	if ttl <= 0 {
+		return errors.New("测评会话已超时")
+	}
+	content, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+	if err := utils.Set(constant.SessionPrefix+session.ID, string(content), ttl); err != nil {
+		return err
+	}
+	return utils.Set(studentSessionKey(session.StudentID, session.ScaleCode), session.ID, ttl)
+}
+
+// removeSession 删除会话及其索引
+func removeSession(session *models.AssessmentSession) {
+	_ = utils.Delete(constant.SessionPrefix + session.ID)
+	_ = utils.Delete(studentSessionKey(session.StudentID, session.ScaleCode))
+}
+
+// ownSession 读取会话并校验归属
+func ownSession(studentId int64, sessionId string) (*models.AssessmentSession, *scaleDef, error) {
+	session, err := loadSession(sessionId)
+	if err != nil {
+		return nil, nil, err
+	}
+	if session.StudentID != studentId {
+		return nil, nil, errors.New("无权访问该测评会话")
+	}
+	scale, err := loadScale(session.ScaleCode)
+	if err != nil {
+		return nil, nil, err
+	}
+	return session, scale, nil
+}
+
+// buildSessionVO 构造会话进度
+func buildSessionVO(session *models.AssessmentSession, scale *scaleDef, resumed bool) *vo.SessionVO {
+	result := &vo.SessionVO{
+		ID:          session.ID,
+		ScaleCode:   session.ScaleCode,
+		ScaleName:   scale.Name,
+		Instruction: scale.Def.Instruction,
+		ItemCount:   len(session.Answers),
+		Answers:     session.Answers,
+		StartedAt:   session.StartedAt,
+		ExpiresAt:   session.ExpiresAt,
+		Resumed:     resumed,
+	}
+	for i, answer := range session.Answers {
+		if answer == models.SessionUnanswered {
+			if result.NextItem == 0 {
+				result.NextItem = i + 1
+			}
+			continue
+		}
+		result.AnsweredCount++
+	}
+	if remaining := time.Until(session.ExpiresAt); remaining > 0 {
+		result.RemainingSeconds = int64(remaining.Seconds())
+	}
+	return result
+}
+
+// Start 开始在线测评；该学生在同一量表上有未超时的会话时直接恢复
+func (s *SessionService) Start(studentId int64, req *vo.SessionStartRequest) (*vo.SessionVO, error) {
+	scale, err := loadScale(req.ScaleCode)
+	if err != nil {
+		return nil, err
+	}
+	if req.ScaleCode == sclScaleCode {
+		// SCL-90 提交后生成 SCL 记录，基本信息在开始时校验，避免作答完成后才发现无法提交
+		if req.Name == "" {
+			return nil, errors.New("姓名不能为空")
+		}
+		if req.Age <= 0 {
+			return nil, errors.New("年龄必须为正整数")
+		}
+		if req.Gender != 0 && req.Gender != 1 {
+			return nil, errors.New("性别只能是 0（女）或 1（男）")
+		}
+	}
+
+	// 断线重连：恢复未完成的会话
+	existingId, err := utils.Get(studentSessionKey(studentId, req.ScaleCode))
+	if err == nil {
+		if session, err := loadSession(existingId.(string)); err == nil && time.Now().Before(session.ExpiresAt) {
+			return buildSessionVO(session, scale, true), nil
+		}
+	} else if !errors.Is(err, redis.Nil) {
+		return nil, err
+	}
+
+	minutes := scale.Def.TimeLimit
+	if minutes == 0 {
+		minutes = defaultSessionMinutes
+	}
+	snowflake, _ := utils.NewSnowflake()
+	now := time.Now()
+	session := &models.AssessmentSession{
+		ID:        strconv.FormatInt(snowflake.GenerateID(), 10),
+		ScaleCode: req.ScaleCode,
+		StudentID: studentId,
+		Name:      req.Name,
+		Gender:    req.Gender,
+		Age:       req.Age,
+		Answers:   make([]int, len(scale.Def.Items)),
+		StartedAt: now,
+		UpdatedAt: now,
+		ExpiresAt: now.Add(time.Duration(minutes) * time.Minute),
+	}
+	for i := range session.Answers {
+		session.Answers[i] = models.SessionUnanswered
+	}
+	if err := storeSession(session); err != nil {
+		return nil, err
+	}
+	return buildSessionVO(session, scale, false), nil
+}
+
+// Get 查询会话进度
+func (s *SessionService) Get(studentId int64, sessionId string) (*vo.SessionVO, error) {
+	session, scale, err := ownSession(studentId, sessionId)
+	if err != nil {
+		return nil, err
+	}
+	return buildSessionVO(session, scale, false), nil
+}
+
+// Items 分页获取题目及已保存的作答
+func (s *SessionService) Items(studentId int64, sessionId string, page, size int) (*vo.SessionItemsVO, error) {
+	session, scale, err := ownSession(studentId, sessionId)
+	if err != nil {
+		return nil, err
+	}
+	if page < 1 {
+		page = 1
+	}
+	if size < 1 {
+		size = defaultSessionPage
+	}
+	if size > maxSessionPage {
+		size = maxSessionPage
+	}
+
+	result := &vo.SessionItemsVO{Page: page, Size: size, Total: len(scale.Def.Items), Items: []vo.SessionItem{}}
+	for i := (page - 1) * size; i < page*size && i < len(scale.Def.Items); i++ {
+		item := scale.Def.Items[i]
+		result.Items = append(result.Items, vo.SessionItem{
+			No:      item.No,
+			Text:    item.Text,
+			Options: scale.options(item),
+			Answer:  session.Answers[i],
+		})
+	}
+	return result, nil
+}
+
+// lockSession 对会话加锁，保存与提交串行执行
+func lockSession(sessionId string) (func(), error) {
+	lock, err := utils.TryLock(constant.SessionLockPrefix+sessionId, 10*time.Second)
+	if err != nil {
+		return nil, errors.New("测评会话正在处理中，请稍后重试")
+	}
+	return func() { _ = utils.Unlock(lock) }, nil
+}
+
+// Save 自动保存部分作答，超过截止时间后不再接受保存
+func (s *SessionService) Save(studentId int64, req *vo.SessionSaveRequest) (*vo.SessionVO, error) {
+	unlock, err := lockSession(req.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	session, scale, err := ownSession(studentId, req.ID)
+	if err != nil {
+		return nil, err
+	}
+	if time.Now().After(session.ExpiresAt) {
+		return nil, errors.New("测评已超过作答时限")
+	}
+	for no, score := range req.Answers {
+		if no < 1 || no > len(scale.Def.Items) {
+			return nil, fmt.Errorf("题号 %d 超出范围", no)
+		}
+		valid := false
+		for _, option := range scale.options(scale.Def.Items[no-1]) {
+			if option.Score == score {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return nil, fmt.Errorf("第 %d 题分值 %d 不是有效选项", no, score)
+		}
+		session.Answers[no-1] = score
+	}
+	session.UpdatedAt = time.Now()
+	if err := storeSession(session); err != nil {
+		return nil, err
+	}
+	return buildSessionVO(session, scale, false), nil
+}
+
+// Submit 提交会话：全部作答后计分，SCL-90 在一个事务中生成 SCL 记录及逐题作答，成功后删除会话
+func (s *SessionService) Submit(studentId int64, sessionId string) (*vo.ScaleResultVO, error) {
+	unlock, err := lockSession(sessionId)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	session, _, err := ownSession(studentId, sessionId)
+	if err != nil {
+		return nil, err
+	}
+	if time.Now().After(session.ExpiresAt.Add(sessionSubmitGrace)) {
+		return nil, errors.New("测评已超过作答时限")
+	}
+	for i, answer := range session.Answers {
+		if answer == models.SessionUnanswered {
+			return nil, fmt.Errorf("第 %d 题尚未作答", i+1)
+		}
+	}
+
+	duration := int(time.Since(session.StartedAt).Seconds())
+	submission := &vo.ScaleSubmission{
+		ScaleCode: session.ScaleCode,
+		TestDate:  models.CustomTime(time.Now()),
+		Answers:   session.Answers,
+		StudentID: &session.StudentID,
+		Duration:  &duration,
+		Name:      session.Name,
+		Gender:    session.Gender,
+		Age:       session.Age,
+	}
+	result, err := NewScaleService().Submit(submission)
+	if err != nil {
+		return nil, err
+	}
+	removeSession(session)
+	return result, nil
+}
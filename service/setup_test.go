@@ -0,0 +1,91 @@
+package service
+
+import (
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"log"
+	"mental/config"
+	"mental/dao"
+	"mental/models"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestMain 按随仓库发布的 config/app.ini 加载数据范围与预警配置，测试使用与部署相同的角色设置
+func TestMain(m *testing.M) {
+	wd, err := os.Getwd()
+	if err != nil {
+		log.Fatalf("获取工作目录失败: %v", err)
+	}
+	if err := os.Chdir(filepath.Dir(wd)); err != nil {
+		log.Fatalf("切换到项目根目录失败: %v", err)
+	}
+	config.LoadDataScopeConfig()
+	config.LoadAlertConfig()
+	if err := os.Chdir(wd); err != nil {
+		log.Fatalf("切换回测试目录失败: %v", err)
+	}
+	os.Exit(m.Run())
+}
+
+// setupTestEnv 为单个测试准备内存 SQLite 数据库和 miniredis，并替换全局的 config.DB 与 config.RDB
+func setupTestEnv(t *testing.T) {
+	t.Helper()
+	dsn := "file:" + strings.ReplaceAll(t.Name(), "/", "_") + "?mode=memory&cache=shared"
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("打开测试数据库失败: %v", err)
+	}
+	if err := dao.AutoMigrate(db); err != nil {
+		t.Fatalf("建表失败: %v", err)
+	}
+	redisServer := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: redisServer.Addr()})
+
+	oldDB, oldRDB := config.DB, config.RDB
+	config.DB, config.RDB = db, rdb
+	t.Cleanup(func() {
+		_ = rdb.Close()
+		if sqlDB, err := db.DB(); err == nil {
+			_ = sqlDB.Close()
+		}
+		config.DB, config.RDB = oldDB, oldRDB
+	})
+}
+
+// adminScope 拥有管理员角色的数据范围
+func adminScope() *dao.DataScope {
+	return &dao.DataScope{Scope: "all", UserID: 1, All: true, RoleIDs: []string{config.DataScopeSettings.AdminRoleID}}
+}
+
+// selfScope 只能访问本人数据的数据范围
+func selfScope(userId int64) *dao.DataScope {
+	return &dao.DataScope{Scope: "self", UserID: userId}
+}
+
+// testSCL 构造一条可通过校验的 SCL 记录，所有因子取相同分数
+func testSCL(studentId int64, factor float32) *models.SCL {
+	return &models.SCL{
+		StudentID:     &studentId,
+		Name:          "测试学生",
+		Gender:        1,
+		Age:           18,
+		TestDate:      models.CustomTime(time.Now().AddDate(0, 0, -1)),
+		Somatization:  factor,
+		Obsession:     factor,
+		Interpersonal: factor,
+		Depression:    factor,
+		Anxiety:       factor,
+		Hostility:     factor,
+		Phobia:        factor,
+		Paranoia:      factor,
+		Psychoticism:  factor,
+		Other:         factor,
+	}
+}
@@ -0,0 +1,92 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"mental/models"
+)
+
+const (
+	validityMinPatternItems   = 10  // 题数少于此值的量表不做直线作答检查（如 PHQ-9 全 0 属正常作答）
+	validityLongstringRatio   = 0.5 // 连续相同作答达到题数一半即视为直线作答
+	validityMinSecondsPerItem = 1.0 // 平均每题用时低于 1 秒视为作答过快
+	validityMaxInconsistent   = 2   // 前后矛盾的题对达到 2 对即视为不一致
+)
+
+// assessValidity 计算作答有效性指标：最长连续相同作答、方差、作答用时和一致性题对
+// duration 为作答用时（秒），未知时传 nil，不做速度检查
+func assessValidity(scale *scaleDef, answers []int, duration *int) models.ResponseValidity {
+	validity := models.ResponseValidity{DurationSeconds: duration, Flags: []string{}}
+	n := len(answers)
+	if n == 0 {
+		return validity
+	}
+
+	// 最长连续相同作答（longstring index）与方差
+	run, sum := 0, 0.0
+	for i, answer := range answers {
+		if i > 0 && answer == answers[i-1] {
+			run++
+		} else {
+			run = 1
+		}
+		if run > validity.Longstring {
+			validity.Longstring = run
+		}
+		sum += float64(answer)
+	}
+	mean := sum / float64(n)
+	for _, answer := range answers {
+		validity.Variance += (float64(answer) - mean) * (float64(answer) - mean)
+	}
+	validity.Variance = roundIndex(validity.Variance / float64(n))
+	if n >= validityMinPatternItems {
+		if float64(validity.Longstring) >= math.Ceil(float64(n)*validityLongstringRatio) {
+			validity.Flags = append(validity.Flags, models.ValidityLongstring)
+		}
+		if validity.Variance == 0 {
+			validity.Flags = append(validity.Flags, models.ValidityLowVariance)
+		}
+	}
+
+	// 作答速度
+	if duration != nil {
+		perItem := roundIndex(float64(*duration) / float64(n))
+		validity.SecondsPerItem = &perItem
+		if perItem < validityMinSecondsPerItem {
+			validity.Flags = append(validity.Flags, models.ValidityTooFast)
+		}
+	}
+
+	// 一致性题对，按反向计分换算后的分值比较
+	scored := scale.scoredItems(answers)
+	for _, pair := range scale.Def.ConsistencyPairs {
+		validity.PairsChecked++
+		if math.Abs(scored[pair.A-1]-scored[pair.B-1]) > float64(pair.MaxDiff) {
+			validity.InconsistentPairs++
+		}
+	}
+	if validity.PairsChecked > 0 && validity.InconsistentPairs >= validityMaxInconsistent {
+		validity.Flags = append(validity.Flags, models.ValidityInconsistent)
+	}
+	return validity
+}
+
+// encodeValidity 序列化有效性指标，返回是否无效及 JSON
+func encodeValidity(validity models.ResponseValidity) (bool, string) {
+	content, _ := json.Marshal(validity)
+	return len(validity.Flags) > 0, string(content)
+}
+
+// decodeValidity 解析记录中保存的有效性指标，未保存时返回 nil
+func decodeValidity(content string) (*models.ResponseValidity, error) {
+	if content == "" {
+		return nil, nil
+	}
+	var validity models.ResponseValidity
+	if err := json.Unmarshal([]byte(content), &validity); err != nil {
+		return nil, fmt.Errorf("作答有效性指标格式错误: %v", err)
+	}
+	return &validity, nil
+}
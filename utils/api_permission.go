@@ -18,10 +18,17 @@ func CacheAPIPermissions() error {
 		return fmt.Errorf("查询接口权限失败: %v", err)
 	}
 
-	// 将接口权限缓存到 Redis
+	// 将接口权限缓存到 Redis，先清空旧的缓存，使权限变更（如改为管理员接口）立即生效
+	cleared := make(map[string]bool)
 	for _, api := range apis {
 		permissionID := api.PermissionID
 		key := constant.APIPermissionPrefix + strconv.Itoa(permissionID)
+		if !cleared[key] {
+			if err := Delete(key); err != nil {
+				return fmt.Errorf("清空权限缓存失败: %v", err)
+			}
+			cleared[key] = true
+		}
 
 		// 添加接口信息到 Redis
 		if err := SAdd(key, api.Path+":"+api.Method); err != nil {
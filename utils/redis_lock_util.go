@@ -20,3 +20,8 @@ func TryLock(key string, ttl time.Duration) (*redislock.Lock, error) {
 func Unlock(lock *redislock.Lock) error {
 	return lock.Release(ctx)
 }
+
+// 续期分布式锁
+func Refresh(lock *redislock.Lock, ttl time.Duration) error {
+	return lock.Refresh(ctx, ttl, nil)
+}
@@ -56,3 +56,8 @@ func Exists(key string) (bool, error) {
 	}
 	return count > 0, nil
 }
+
+// Incr 将键的整数值加 1，键不存在时从 0 开始，返回加 1 后的值
+func Incr(key string) (int64, error) {
+	return config.RDB.Incr(ctx, key).Result()
+}
@@ -18,6 +18,29 @@ type Swagger struct {
 	} `json:"paths"`
 }
 
+// adminAPIs 仅管理员可访问的接口（路径:方法），导入时登记为管理员权限，已导入的接口同样改为管理员权限；
+// 其中组织维护等接口在服务层还允许组织负责人操作，负责人所在角色须另行授予该权限
+var adminAPIs = []string{
+	"/datascope/role:POST",
+	"/datascope/manager:POST",
+	"/datascope/manager:DELETE",
+	"/appointment/calendar:GET",
+	"/scl/rule:POST",
+	"/scl/rule/preview:POST",
+	"/scl/rule/activate:POST",
+	"/alert/rule:POST",
+	"/alert/rule/enable:POST",
+	"/org:POST",
+	"/org/update:POST",
+	"/org:DELETE",
+	"/org/member:DELETE",
+	"/org/roster/import:POST",
+	"/scale:POST",
+	"/norm:POST",
+	"/norm/import:POST",
+	"/norm/default:POST",
+}
+
 // InsertSwaggerAPIs 解析 swagger.json 并插入数据库
 func InsertSwaggerAPIs(filePath string) error {
 	// 读取文件内容
@@ -40,6 +63,9 @@ func InsertSwaggerAPIs(filePath string) error {
 				Description:  info.Summary + " - " + info.Description,
 				PermissionID: 2, // 默认是公用接口
 			}
+			if isAdminAPI(api.Path, api.Method) {
+				api.PermissionID = config.DataScopeSettings.AdminPermissionID
+			}
 			apis = append(apis, api)
 		}
 	}
@@ -55,6 +81,30 @@ func InsertSwaggerAPIs(filePath string) error {
 		fmt.Println("未发现可插入的 API 数据")
 	}
 
+	return markAdminAPIs()
+}
+
+// isAdminAPI 判断接口是否仅管理员可访问
+func isAdminAPI(path string, method string) bool {
+	for _, key := range adminAPIs {
+		if key == path+":"+method {
+			return true
+		}
+	}
+	return false
+}
+
+// markAdminAPIs 将已导入的管理员接口改为管理员权限，防止早先按公用接口导入的记录继续对所有用户开放
+func markAdminAPIs() error {
+	for _, key := range adminAPIs {
+		i := strings.LastIndex(key, ":")
+		err := config.DB.Model(&models.API{}).
+			Where("path = ? AND method = ?", key[:i], key[i+1:]).
+			Update("permission_id", config.DataScopeSettings.AdminPermissionID).Error
+		if err != nil {
+			return fmt.Errorf("更新管理员接口权限失败: %v", err)
+		}
+	}
 	return nil
 }
 
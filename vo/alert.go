@@ -0,0 +1,16 @@
+package vo
+
+import "mental/models"
+
+// AlertVO 预警及其解析后的触发原因
+type AlertVO struct {
+	models.Alert
+	Reasons []models.AlertReason `json:"reasons"`         // 触发原因
+	Notes   []models.AlertNote   `json:"notes,omitempty"` // 处理备注与状态变更，仅详情返回
+}
+
+// AlertRuleList 预警规则列表
+type AlertRuleList struct {
+	Rules     []models.AlertRule `json:"rules"`     // 数据库中的全部规则
+	Effective []models.AlertRule `json:"effective"` // 当前生效的规则（没有启用的规则时为内置规则）
+}
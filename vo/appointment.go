@@ -0,0 +1,29 @@
+package vo
+
+import (
+	"mental/models"
+	"time"
+)
+
+// SlotForm 发布咨询时段的表单
+type SlotForm struct {
+	StartAt  time.Time `json:"start_at"` // 开始时间（RFC3339）
+	EndAt    time.Time `json:"end_at"`   // 结束时间（RFC3339）
+	Location string    `json:"location"` // 咨询地点
+	Remark   string    `json:"remark"`   // 备注
+}
+
+// CalendarSlot 日历中的一个咨询时段及其预约
+type CalendarSlot struct {
+	models.AppointmentSlot
+	Appointment *models.Appointment `json:"appointment,omitempty"` // 时段上未取消的预约
+}
+
+// CalendarDay 日历中的一天
+type CalendarDay struct {
+	Date        string         `json:"date"`         // 日期 YYYY-MM-DD
+	Slots       []CalendarSlot `json:"slots"`        // 当天的咨询时段，按开始时间正序
+	BookedCount int            `json:"booked_count"` // 已预约（含已完成、未到）的时段数
+	OpenCount   int            `json:"open_count"`   // 可预约的时段数
+	ClosedCount int            `json:"closed_count"` // 已关闭的时段数
+}
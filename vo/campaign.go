@@ -0,0 +1,86 @@
+package vo
+
+import (
+	"mental/models"
+	"time"
+)
+
+// CampaignForm 新建或修改筛查批次的表单
+type CampaignForm struct {
+	ID        int64     `json:"id"`         // 批次ID，修改时必填
+	Name      string    `json:"name"`       // 批次名称
+	ScaleCode string    `json:"scale_code"` // 使用的量表编码
+	OpenAt    time.Time `json:"open_at"`    // 开放时间（RFC3339）
+	CloseAt   time.Time `json:"close_at"`   // 截止时间（RFC3339）
+	Remark    string    `json:"remark"`     // 备注
+	OrgIDs    []int64   `json:"org_ids"`    // 目标组织ID，含其全部下级班级
+}
+
+// CampaignStudent 筛查批次中一名花名册学生的完成情况
+type CampaignStudent struct {
+	MemberID  int64              `json:"member_id"`           // 花名册记录ID
+	UserID    *int64             `json:"user_id,omitempty"`   // 学生账号ID，未绑定账号时为空
+	StudentNo string             `json:"student_no"`          // 学号
+	Name      string             `json:"name"`                // 姓名
+	RecordID  int64              `json:"record_id,omitempty"` // 关联的测评记录ID（SCL-90 为 scl 表ID，其余为 scale_response 表ID）
+	TestDate  *models.CustomTime `json:"test_date,omitempty"` // 测评日期
+	Level     string             `json:"level,omitempty"`     // 等级
+	Invalid   bool               `json:"invalid"`             // 是否只有无效作答（仍计为未完成）
+}
+
+// CampaignClassProgress 筛查批次中一个班级的完成情况
+type CampaignClassProgress struct {
+	OrgID          int64             `json:"org_id"`          // 班级ID
+	OrgName        string            `json:"org_name"`        // 班级名称
+	OrgCode        string            `json:"org_code"`        // 班级编码
+	MemberCount    int               `json:"member_count"`    // 花名册人数
+	CompletedCount int               `json:"completed_count"` // 已完成（有有效作答）人数
+	CompletionRate float64           `json:"completion_rate"` // 完成率（%）
+	Completed      []CampaignStudent `json:"completed"`       // 已完成的学生
+	Pending        []CampaignStudent `json:"pending"`         // 未完成的学生，含只有无效作答的学生
+}
+
+// CampaignProgress 筛查批次的完成情况
+type CampaignProgress struct {
+	Campaign       models.Campaign         `json:"campaign"`        // 筛查批次
+	MemberCount    int                     `json:"member_count"`    // 目标班级的花名册总人数
+	CompletedCount int                     `json:"completed_count"` // 已完成人数
+	CompletionRate float64                 `json:"completion_rate"` // 完成率（%）
+	Classes        []CampaignClassProgress `json:"classes"`         // 各目标班级的完成情况
+}
+
+// CampaignMetric 一项得分指标的均值与标准差
+type CampaignMetric struct {
+	Key  string  `json:"key"`  // 指标键：score 为总分，其余为分量表键
+	Name string  `json:"name"` // 指标名称
+	Mean float64 `json:"mean"` // 均值
+	SD   float64 `json:"sd"`   // 标准差（样本）
+}
+
+// CampaignSummary 比较视图中一个筛查批次的汇总结果，只统计目标班级学生的有效作答
+type CampaignSummary struct {
+	Campaign       models.Campaign    `json:"campaign"`        // 筛查批次
+	MemberCount    int                `json:"member_count"`    // 目标班级的花名册总人数
+	CompletedCount int                `json:"completed_count"` // 已完成人数
+	CompletionRate float64            `json:"completion_rate"` // 完成率（%）
+	LevelCounts    map[string]int     `json:"level_counts"`    // 各等级人数
+	LevelPercent   map[string]float64 `json:"level_percent"`   // 各等级占已完成人数的比例（%）
+	PositiveCount  int                `json:"positive_count"`  // 筛查阳性（等级非“基本正常”）人数
+	PositiveRate   float64            `json:"positive_rate"`   // 筛查阳性率（%）
+	Metrics        []CampaignMetric   `json:"metrics"`         // 总分及各分量表得分的统计
+}
+
+// CampaignSeries 一项指标在各批次间的变化，Values 与比较结果中的批次一一对应
+type CampaignSeries struct {
+	Key    string    `json:"key"`    // 指标键
+	Name   string    `json:"name"`   // 指标名称
+	Values []float64 `json:"values"` // 各批次的取值：完成率、阳性率为百分比，得分指标为均值
+}
+
+// CampaignComparison 多个筛查批次的比较结果
+type CampaignComparison struct {
+	ScaleCode string            `json:"scale_code"` // 量表编码
+	ScaleName string            `json:"scale_name"` // 量表名称
+	Campaigns []CampaignSummary `json:"campaigns"`  // 各批次汇总，按开放时间正序
+	Series    []CampaignSeries  `json:"series"`     // 完成率、阳性率及各指标均值的变化
+}
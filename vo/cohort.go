@@ -0,0 +1,52 @@
+package vo
+
+import "time"
+
+// CohortQuery 群体统计的查询条件
+type CohortQuery struct {
+	GroupBy        string     `json:"group_by"`        // 分组维度：gender/age_band/org/period，为空时只统计总体
+	OrgID          int64      `json:"org_id"`          // 只统计该组织（含下级）花名册学生的记录，0 为不限；按 org 分组时按其直接下级分组
+	From           *time.Time `json:"from"`            // 测评日期下限（含）
+	To             *time.Time `json:"to"`              // 测评日期上限（不含）
+	Period         string     `json:"period"`          // 按 period 分组时的周期：month/quarter/year
+	AgeBands       []int      `json:"age_bands"`       // 按 age_band 分组时的年龄分段下界（升序）
+	IncludeInvalid bool       `json:"include_invalid"` // 是否计入无效作答的记录
+}
+
+// HistogramBin 分布直方图的一个区间 [Min, Max)，最后一个区间含上界
+type HistogramBin struct {
+	Min   float64 `json:"min"`   // 区间下界
+	Max   float64 `json:"max"`   // 区间上界
+	Count int     `json:"count"` // 记录数
+}
+
+// CohortMetric 一项指标的描述统计与分布
+type CohortMetric struct {
+	Key       string         `json:"key"`       // 指标字段名
+	Name      string         `json:"name"`      // 指标中文名
+	Mean      float64        `json:"mean"`      // 均值
+	SD        float64        `json:"sd"`        // 标准差（样本）
+	Histogram []HistogramBin `json:"histogram"` // 分布直方图
+}
+
+// CohortGroup 一个分组的统计结果
+type CohortGroup struct {
+	Key           string             `json:"key"`            // 分组键
+	Label         string             `json:"label"`          // 分组名称
+	RecordCount   int                `json:"record_count"`   // 记录数
+	StudentCount  int                `json:"student_count"`  // 学生数（有学生ID的记录去重）
+	Metrics       []CohortMetric     `json:"metrics"`        // 各因子及总分、阳性项目数、总均分的统计
+	LevelCounts   map[string]int     `json:"level_counts"`   // 各等级记录数
+	LevelPercent  map[string]float64 `json:"level_percent"`  // 各等级记录占比（%）
+	PositiveCount int                `json:"positive_count"` // 筛查阳性（等级非“基本正常”）的记录数
+	PositiveRate  float64            `json:"positive_rate"`  // 筛查阳性率（%）
+}
+
+// CohortStats 群体统计结果
+type CohortStats struct {
+	Query       CohortQuery   `json:"query"`        // 实际使用的查询条件
+	Total       CohortGroup   `json:"total"`        // 总体统计
+	Groups      []CohortGroup `json:"groups"`       // 分组统计，按分组键排列
+	GeneratedAt time.Time     `json:"generated_at"` // 统计时间
+	Cached      bool          `json:"cached"`       // 是否来自缓存
+}
@@ -0,0 +1,23 @@
+package vo
+
+import "mental/models"
+
+// TimelineEntry 学生时间线中的一个事件，同一天内按 测评、预警、跟进 的顺序排列
+type TimelineEntry struct {
+	Type    string            `json:"type"`    // 事件类型：assessment 测评 / alert 预警 / follow_up 跟进
+	Date    models.CustomTime `json:"date"`    // 事件日期（测评日期、预警对应的测评日期、跟进日期）
+	Title   string            `json:"title"`   // 标题
+	Level   string            `json:"level"`   // 等级（测评、预警），跟进为空
+	Summary string            `json:"summary"` // 摘要
+
+	Assessment *ScaleResultVO   `json:"assessment,omitempty"` // 测评结果（type=assessment）
+	Alert      *AlertVO         `json:"alert,omitempty"`      // 预警（type=alert）
+	FollowUp   *models.FollowUp `json:"follow_up,omitempty"`  // 跟进记录（type=follow_up）
+}
+
+// StudentTimeline 学生的测评与跟进时间线
+type StudentTimeline struct {
+	StudentID        int64              `json:"student_id"`                    // 学生ID
+	Entries          []TimelineEntry    `json:"entries"`                       // 按日期正序排列的事件
+	NextFollowUpDate *models.CustomTime `json:"next_follow_up_date,omitempty"` // 最近一次跟进记录中的下次跟进日期
+}
@@ -0,0 +1,43 @@
+package vo
+
+// 预览行状态
+const (
+	ImportRowOK      = "ok"      // 可导入
+	ImportRowWarning = "warning" // 可导入，但有需要确认的问题
+	ImportRowError   = "error"   // 校验未通过，不会导入
+)
+
+// ImportPreview Excel 导入预览结果，只解析和校验，不写入数据
+type ImportPreview struct {
+	Token        string             `json:"token"`         // 确认导入凭证，有效期内调用确认导入接口时使用；没有可导入的行时为空
+	Profile      string             `json:"profile"`       // 使用的映射方案名称
+	Total        int                `json:"total"`         // 数据行数（不含空行）
+	ValidCount   int                `json:"valid_count"`   // 可导入行数（含有警告的行）
+	ErrorCount   int                `json:"error_count"`   // 校验未通过的行数
+	WarningCount int                `json:"warning_count"` // 有警告的行数
+	Errors       []string           `json:"errors"`        // 工作表级错误（如缺少表头列）
+	Rows         []ImportPreviewRow `json:"rows"`          // 逐行结果
+}
+
+// ImportPreviewRow 一行数据的预览结果
+type ImportPreviewRow struct {
+	Sheet      string   `json:"sheet"`                // 工作表名
+	Row        int      `json:"row"`                  // 行号（从 1 开始）
+	Status     string   `json:"status"`               // ok/warning/error
+	Errors     []string `json:"errors"`               // 错误
+	Warnings   []string `json:"warnings"`             // 警告
+	StudentID  *int64   `json:"student_id,omitempty"` // 学生ID
+	Name       string   `json:"name"`                 // 姓名
+	TestDate   string   `json:"test_date"`            // 测评日期
+	TotalScore float64  `json:"total_score"`          // 总分
+	Level      string   `json:"level"`                // 总体等级
+}
+
+// ImportResult Excel 导入结果
+type ImportResult struct {
+	BatchID    int64    `json:"batch_id"`    // 导入批次ID，文件级错误时为 0
+	Status     string   `json:"status"`      // 批次状态：completed/failed，文件级错误时为空
+	SuccessNum int      `json:"success_num"` // 导入成功的行数
+	ErrorRows  []string `json:"error_rows"`  // 错误信息
+	ErrorFile  string   `json:"error_file"`  // 失败行文件下载地址：未导入的行及错误原因，修改后可重新导入；没有失败行时为空
+}
@@ -0,0 +1,28 @@
+package vo
+
+// OrgMetricMean 组织内某项指标的均值
+type OrgMetricMean struct {
+	Key  string  `json:"key"`  // 指标字段名
+	Name string  `json:"name"` // 指标中文名
+	Mean float64 `json:"mean"` // 均值
+}
+
+// OrgStat 一个组织单元（含全部下级）的测评汇总，每名学生取统计区间内最近一次有效测评
+type OrgStat struct {
+	OrgID         int64           `json:"org_id"`         // 组织ID
+	Name          string          `json:"name"`           // 组织名称
+	Type          string          `json:"type"`           // 组织类型
+	MemberCount   int             `json:"member_count"`   // 花名册人数
+	AssessedCount int             `json:"assessed_count"` // 有有效测评的学生数
+	CoverageRate  float64         `json:"coverage_rate"`  // 测评覆盖率（%）
+	Means         []OrgMetricMean `json:"means"`          // 各因子及总分、阳性项目数、总均分的均值
+	LevelCounts   map[string]int  `json:"level_counts"`   // 各等级学生数
+	PositiveCount int             `json:"positive_count"` // 等级非“基本正常”的学生数
+	PositiveRate  float64         `json:"positive_rate"`  // 阳性检出率（%）
+}
+
+// OrgStats 组织单元自身及其直接下级的测评汇总
+type OrgStats struct {
+	OrgStat
+	Children []OrgStat `json:"children"` // 直接下级组织的汇总，按排序号
+}
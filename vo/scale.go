@@ -0,0 +1,38 @@
+package vo
+
+import "mental/models"
+
+// ScaleVO 量表及其完整定义
+type ScaleVO struct {
+	models.Scale
+	Definition models.ScaleDefinition `json:"definition"` // 结构化的量表定义
+}
+
+// ScaleSubmission 一次量表作答的提交内容
+type ScaleSubmission struct {
+	ScaleCode string            `json:"scale_code"` // 量表编码
+	TestDate  models.CustomTime `json:"test_date"`  // 测评日期
+	Answers   []int             `json:"answers"`    // 按题号顺序的作答分值
+	StudentID *int64            `json:"-"`          // 学生ID，由登录信息填充
+	Duration  *int              `json:"-"`          // 作答用时（秒），仅在线测评提交时由会话填充
+	Name      string            `json:"name"`       // 姓名（SCL-90 必填）
+	Gender    int               `json:"gender"`     // 性别 0女 1男（SCL-90 必填）
+	Age       int               `json:"age"`        // 年龄（SCL-90 必填）
+}
+
+// ScaleResultVO 统一的量表结果，SCL-90 记录与其他量表作答使用相同结构
+type ScaleResultVO struct {
+	ID        int64                    `json:"id"`                   // 记录ID（SCL-90 为 scl 表ID，其余为 scale_response 表ID）
+	ScaleCode string                   `json:"scale_code"`           // 量表编码
+	ScaleName string                   `json:"scale_name"`           // 量表名称
+	StudentID *int64                   `json:"student_id,omitempty"` // 学生ID
+	TestDate  models.CustomTime        `json:"test_date"`            // 测评日期
+	RawScore  float64                  `json:"raw_score"`            // 粗分
+	Score     float64                  `json:"score"`                // 换算后总分
+	Level     string                   `json:"level"`                // 等级：normal/mild/moderate/severe
+	Result    string                   `json:"result"`               // 结论
+	Subscores []models.ScaleSubscore   `json:"subscores"`            // 分量表得分
+	Flags     []models.ScaleFlag       `json:"flags"`                // 单题预警
+	Invalid   bool                     `json:"invalid"`              // 是否为无效作答
+	Validity  *models.ResponseValidity `json:"validity,omitempty"`   // 作答有效性指标，仅逐题作答的记录有
+}
@@ -1,14 +1,167 @@
 package vo
 
-import "mental/models"
+import (
+	"mental/models"
+	"time"
+)
 
 // 单次测评分析结构体，嵌入SCL，增加计算字段
+// 总分、阳性/阴性项目数、GSI（总症状指数）、PSDI（阳性症状均分）随嵌入的 SCL 一并输出
 type SCLRecordAnalysisVO struct {
-	models.SCL          // SCL基础结构体
-	HealthStatus string `json:"health_status"` // 测评整体状态
+	models.SCL                  // SCL基础结构体
+	HealthStatus string         `json:"health_status"`  // 测评整体状态
+	Analysis     SCLAnalysis    `json:"analysis"`       // 结构化分析结果
+	Norm         *SCLNormResult `json:"norm,omitempty"` // 常模比较结果
+	RuleSet      SCLRuleSetInfo `json:"rule_set"`       // 生成结论所用的规则集
+
+	Validity *models.ResponseValidity `json:"validity,omitempty"` // 作答有效性指标，仅逐题作答的记录有；无效记录的 invalid 为 true
+}
+
+// SCLQuery 测评记录列表与导出的筛选、排序和分页条件，零值表示不限
+type SCLQuery struct {
+	Name       string         `json:"name"`       // 姓名（模糊匹配）
+	Gender     *int           `json:"gender"`     // 性别 0女 1男
+	AgeMin     *int           `json:"age_min"`    // 年龄下限（含）
+	AgeMax     *int           `json:"age_max"`    // 年龄上限（含）
+	From       *time.Time     `json:"from"`       // 测评日期下限（含）
+	To         *time.Time     `json:"to"`         // 测评日期上限（不含）
+	OrgID      int64          `json:"org_id"`     // 只查询该组织（含下级）花名册学生的记录
+	Level      string         `json:"level"`      // 只查询总体等级为该等级的记录：normal/mild/moderate/severe
+	Thresholds []SCLThreshold `json:"thresholds"` // 因子分、总分、阳性项目数、GSI 的取值范围
+	Sort       string         `json:"sort"`       // 排序字段，为空时按测评日期
+	Order      string         `json:"order"`      // 排序方向 asc/desc，为空时倒序
+	Page       int            `json:"page"`       // 页码，从 1 开始
+	PageSize   int            `json:"page_size"`  // 每页条数
+}
+
+// SCLThreshold 一项指标的取值范围（含两端），Min、Max 为空时不限
+type SCLThreshold struct {
+	Key string   `json:"key"` // 指标字段名
+	Min *float64 `json:"min"` // 下限
+	Max *float64 `json:"max"` // 上限
+}
+
+// Pagination 分页信息
+type Pagination struct {
+	Page     int   `json:"page"`      // 页码
+	PageSize int   `json:"page_size"` // 每页条数
+	Total    int64 `json:"total"`     // 符合条件的总数
+}
+
+// SCLPage 分页的测评记录列表
+type SCLPage struct {
+	Records []SCLRecordAnalysisVO `json:"records"` // 当页记录
+	Pagination
 }
 
 type UserSCLResult struct {
-	Records           []SCLRecordAnalysisVO `json:"records"`       // 用户每次测评记录
-	UserOverallHealth string                `json:"health_result"` // 整体心理状态
+	Records           []SCLRecordAnalysisVO `json:"records"`          // 用户每次测评记录（当页）
+	UserOverallHealth string                `json:"health_result"`    // 整体心理状态
+	OverallAnalysis   *SCLAnalysis          `json:"overall_analysis"` // 整体结构化分析结果，无记录时为 null
+	RuleSet           SCLRuleSetInfo        `json:"rule_set"`         // 整体心理状态所用的规则集（当前启用）
+	ExcludedCount     int                   `json:"excluded_count"`   // 未计入整体心理状态的无效记录数
+	Pagination
+}
+
+// SCLRuleSetInfo 规则集概要，用于说明结论由哪个版本的规则生成
+type SCLRuleSetInfo struct {
+	ID      int64  `json:"id"`      // 规则集ID，0 为内置规则
+	Name    string `json:"name"`    // 规则集名称
+	Version int    `json:"version"` // 版本号
+}
+
+// SCLAnalysis 结构化分析结果，各列表顺序固定，相同输入得到相同输出
+type SCLAnalysis struct {
+	Level          string             `json:"level"`           // 总体等级：normal/mild/moderate/severe，取命中规则中最严重的等级
+	Factors        []SCLFactorResult  `json:"factors"`         // 各因子等级，按 SCL-90 因子顺序
+	TriggeredRules []SCLTriggeredRule `json:"triggered_rules"` // 命中的规则，按 总分、阳性项目数、因子 顺序
+	Summary        string             `json:"summary"`         // 可读摘要
+}
+
+// SCLFactorResult 单个因子的分级结果
+type SCLFactorResult struct {
+	Key   string  `json:"key"`   // 因子字段名
+	Name  string  `json:"name"`  // 因子中文名
+	Score float32 `json:"score"` // 因子均分
+	Level string  `json:"level"` // 因子等级
+}
+
+// SCLTriggeredRule 命中的规则
+type SCLTriggeredRule struct {
+	Type      string  `json:"type"`             // 规则类型：total/positive/factor
+	Factor    string  `json:"factor,omitempty"` // 因子字段名（因子规则）
+	Criterion string  `json:"criterion"`        // 比较依据：raw/t_score
+	Threshold float64 `json:"threshold"`        // 规则阈值
+	Value     float64 `json:"value"`            // 实际值
+	Level     string  `json:"level"`            // 规则等级
+	Result    string  `json:"result"`           // 规则结论
+	Estimated bool    `json:"estimated"`        // 实际值是否为估算值（记录没有逐题作答时的总分、阳性项目数）
+}
+
+// SCLNormResult 单次测评与常模组的比较结果
+type SCLNormResult struct {
+	Code   string         `json:"code"`   // 常模组编码
+	Name   string         `json:"name"`   // 常模组名称
+	Scores []SCLNormScore `json:"scores"` // 各指标标准分，常模中没有的指标不输出
+}
+
+// SCLNormScore 单个指标的标准分
+type SCLNormScore struct {
+	Key        string  `json:"key"`        // 指标字段名
+	Name       string  `json:"name"`       // 指标中文名
+	Raw        float64 `json:"raw"`        // 原始值
+	Mean       float64 `json:"mean"`       // 常模均值
+	SD         float64 `json:"sd"`         // 常模标准差
+	Z          float64 `json:"z"`          // z 分
+	T          float64 `json:"t"`          // T 分 = 50 + 10z
+	Percentile float64 `json:"percentile"` // 百分位（正态近似）
+}
+
+// SCLTrendResult 学生历次测评的纵向趋势分析
+type SCLTrendResult struct {
+	StudentID          int64            `json:"student_id"`          // 学生ID
+	RecordCount        int              `json:"record_count"`        // 测评次数
+	Metrics            []SCLMetricTrend `json:"metrics"`             // 各指标趋势，按十个因子、总分、GSI 顺序
+	DeteriorationFlags []SCLTrendFlag   `json:"deterioration_flags"` // 有临床意义的恶化标记，按指标、时间顺序
+}
+
+// SCLMetricTrend 单个指标的时间序列与趋势
+type SCLMetricTrend struct {
+	Key       string              `json:"key"`       // 指标字段名
+	Name      string              `json:"name"`      // 指标中文名
+	Points    []SCLTrendPoint     `json:"points"`    // 按测评日期升序的时间序列
+	Slope     float64             `json:"slope"`     // 线性回归斜率，每 30 天的变化量
+	Direction string              `json:"direction"` // 趋势方向：improving/stable/deteriorating/insufficient
+	Changes   []SCLReliableChange `json:"changes"`   // 相邻两次测评的可靠变化
+}
+
+// SCLTrendPoint 时间序列中的一次测评
+type SCLTrendPoint struct {
+	SCLID    int64             `json:"scl_id"`    // 测评记录ID
+	TestDate models.CustomTime `json:"test_date"` // 测评日期
+	Value    float64           `json:"value"`     // 指标值
+}
+
+// SCLReliableChange 相邻两次测评之间的可靠变化指数（RCI）
+type SCLReliableChange struct {
+	FromSCLID             int64             `json:"from_scl_id"`            // 前测记录ID
+	ToSCLID               int64             `json:"to_scl_id"`              // 后测记录ID
+	FromDate              models.CustomTime `json:"from_date"`              // 前测日期
+	ToDate                models.CustomTime `json:"to_date"`                // 后测日期
+	FromValue             float64           `json:"from_value"`             // 前测值
+	ToValue               float64           `json:"to_value"`               // 后测值
+	Change                float64           `json:"change"`                 // 变化量（后测 - 前测）
+	RCI                   float64           `json:"rci"`                    // 可靠变化指数
+	Reliable              string            `json:"reliable"`               // 可靠变化方向：improving/stable/deteriorating
+	ClinicallySignificant bool              `json:"clinically_significant"` // 是否为有临床意义的恶化（可靠恶化且越过临床界值）
+}
+
+// SCLTrendFlag 有临床意义的恶化标记
+type SCLTrendFlag struct {
+	Key      string            `json:"key"`       // 指标字段名
+	Name     string            `json:"name"`      // 指标中文名
+	SCLID    int64             `json:"scl_id"`    // 出现恶化的测评记录ID
+	TestDate models.CustomTime `json:"test_date"` // 出现恶化的测评日期
+	RCI      float64           `json:"rci"`       // 可靠变化指数
+	Message  string            `json:"message"`   // 说明
 }
@@ -0,0 +1,52 @@
+package vo
+
+import (
+	"mental/models"
+	"time"
+)
+
+// SessionStartRequest 开始在线测评的请求
+type SessionStartRequest struct {
+	ScaleCode string `json:"scale_code"` // 量表编码
+	Name      string `json:"name"`       // 姓名（SCL-90 必填）
+	Gender    int    `json:"gender"`     // 性别 0女 1男（SCL-90 必填）
+	Age       int    `json:"age"`        // 年龄（SCL-90 必填）
+}
+
+// SessionSaveRequest 自动保存作答的请求
+type SessionSaveRequest struct {
+	ID      string      `json:"id"`      // 会话ID
+	Answers map[int]int `json:"answers"` // 题号 -> 作答分值，只需提交本次变更的题目
+}
+
+// SessionVO 在线测评会话的进度
+type SessionVO struct {
+	ID               string    `json:"id"`                // 会话ID
+	ScaleCode        string    `json:"scale_code"`        // 量表编码
+	ScaleName        string    `json:"scale_name"`        // 量表名称
+	Instruction      string    `json:"instruction"`       // 指导语
+	ItemCount        int       `json:"item_count"`        // 题目总数
+	AnsweredCount    int       `json:"answered_count"`    // 已作答题数
+	NextItem         int       `json:"next_item"`         // 第一道未作答的题号，全部作答后为 0
+	Answers          []int     `json:"answers"`           // 按题号顺序的作答分值，未作答为 -1
+	StartedAt        time.Time `json:"started_at"`        // 开始时间
+	ExpiresAt        time.Time `json:"expires_at"`        // 截止时间
+	RemainingSeconds int64     `json:"remaining_seconds"` // 剩余秒数
+	Resumed          bool      `json:"resumed"`           // 是否为恢复的未完成会话
+}
+
+// SessionItemsVO 分页题目
+type SessionItemsVO struct {
+	Page  int           `json:"page"`  // 页码，从 1 开始
+	Size  int           `json:"size"`  // 每页题数
+	Total int           `json:"total"` // 题目总数
+	Items []SessionItem `json:"items"` // 本页题目
+}
+
+// SessionItem 题目及已保存的作答
+type SessionItem struct {
+	No      int                  `json:"no"`      // 题号
+	Text    string               `json:"text"`    // 题干
+	Options []models.ScaleOption `json:"options"` // 选项
+	Answer  int                  `json:"answer"`  // 已保存的作答分值，未作答为 -1
+}